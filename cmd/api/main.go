@@ -1,42 +1,104 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"ai-backend/internal/accountdeletion"
+	"ai-backend/internal/background"
+	"ai-backend/internal/blocklist"
+	"ai-backend/internal/config"
 	"ai-backend/internal/database"
+	"ai-backend/internal/dataexport"
+	"ai-backend/internal/handlers/auth"
 	"ai-backend/internal/handlers/user"
+	"ai-backend/internal/health"
+	"ai-backend/internal/logging"
+	"ai-backend/internal/metrics"
 	"ai-backend/internal/middleware"
-	"ai-backend/internal/models"
+	"ai-backend/internal/notification"
+	"ai-backend/internal/outbox"
+	"ai-backend/internal/realtime"
+	"ai-backend/internal/repository"
 	"ai-backend/internal/routes"
+	"ai-backend/internal/service"
+	"ai-backend/internal/tracing"
+	"ai-backend/internal/webhook"
+	"ai-backend/pkg/email"
+	"ai-backend/pkg/storage"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
-	// Load .env file
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error loading .env file")
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		// Flags after "migrate" belong to the subcommand
+		cfg, err := config.Load(nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := logging.Setup(cfg.Log); err != nil {
+			log.Fatal(err)
+		}
+		runMigrate(os.Args[2:])
+		return
 	}
 
+	// Load configuration from .env, the environment and flags
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := logging.Setup(cfg.Log); err != nil {
+		log.Fatal(err)
+	}
+	cfg.LogEffective()
+
+	// Initialize tracing before anything creates spans
+	flushTraces, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize tracing:", err)
+	}
+
+	// Initialize metrics before anything records them
+	appMetrics := metrics.New(prometheus.NewRegistry())
+
+	// Initialize the email transport selected by EMAIL_DRIVER
+	mailer, err := email.New(cfg.Email, appMetrics)
+	if err != nil {
+		log.Fatal("Failed to initialize email:", err)
+	}
+	email.SetDefault(mailer)
+
 	// Initialize database
 	database.InitDB()
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		log.Fatal("Failed to get database instance:", err)
+	}
+	if err := appMetrics.RegisterDB(sqlDB, "postgres"); err != nil {
+		log.Fatal("Failed to register database metrics:", err)
+	}
 
-	// Migrate database schemas
-	if err := database.DB.AutoMigrate(
-		&models.User{},
-		&models.Account{},
-		&models.Session{},
-		&models.VerificationToken{},
-		&models.Question{},
-		&models.Answer{},
-		&models.Vote{},
-		&models.FreezeHistory{},
-		&models.RoleHistory{},
-		&models.BanHistory{},
-	); err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	// Apply or verify database migrations
+	migrator := newMigrator()
+	if cfg.Database.MigrateOnStart {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+	} else if pending, err := migrator.Pending(context.Background()); err != nil {
+		log.Fatal("Failed to check migrations:", err)
+	} else if pending > 0 {
+		log.Fatalf("Database has %d pending migrations. Run `main migrate up` or set MIGRATE_ON_START=true", pending)
 	}
 
 	// Seed default user
@@ -44,34 +106,176 @@ func main() {
 		log.Fatal("Failed to seed default user:", err)
 	}
 
+	// Load disposable email domain list
+	if path := cfg.Moderation.DisposableDomainsFile; path != "" {
+		if err := blocklist.LoadDisposableDomains(path); err != nil {
+			log.Fatal("Failed to load disposable email domains:", err)
+		}
+	}
+
 	// Initialize Gin router
-	r := gin.Default()
+	r := gin.New()
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName), middleware.RequestLogger(), gin.Recovery())
+	if cfg.Metrics.Enabled {
+		r.Use(middleware.Metrics(appMetrics))
+	}
 
 	// Add global error handler
 	r.Use(middleware.ErrorHandler())
 
-	// Initialize handlers
-	userHandler := user.NewUserHandler(database.DB)
+	// Emails sent while handling a request use the client's language
+	r.Use(middleware.Locale())
+
+	// Initialize file storage
+	store, err := storage.NewLocalStorage(cfg.Storage.Dir)
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+
+	// Erase accounts whose deletion grace period has ended
+	accountdeletion.StartPurgeWorker(database.DB, store, time.Hour, appMetrics)
+
+	// Delete expired data export archives and fail stuck exports
+	dataexport.StartWorker(database.DB, store, 5*time.Minute, cfg.Storage.DataExportTimeout, appMetrics)
+
+	// Deliver queued emails
+	repositories := repository.NewGormStore(database.DB)
+	outbox.Start(repositories, cfg.Outbox, appMetrics)
+
+	// Send domain events to webhook subscriptions
+	webhook.Start(repositories, cfg.Webhook, appMetrics)
+
+	// Email digests of unread notifications
+	notification.StartDigest(repositories, cfg.Notification, appMetrics)
+
+	// Fan out real-time events published by every replica to this one's streams
+	hub := realtime.NewHub(appMetrics)
+	realtime.Listen(hub, cfg.Database.URL)
+
+	// Initialize services and handlers
+	authHandler := auth.NewAuthHandler(service.NewAuthService(repositories, appMetrics))
+	userHandler := user.NewUserHandler(service.NewUserService(repositories))
+	dataExportHandler := user.NewDataExportHandler(repositories, dataexport.NewExporter(database.DB, store, appMetrics), store)
+	notificationHandler := user.NewNotificationHandler(service.NewNotificationService(repositories))
+	realtimeHandler := user.NewRealtimeHandler(hub, repositories, cfg.Realtime, appMetrics)
 
 	// Setup routes
-	routes.SetupAuthRoutes(r)
-	routes.SetupUserRoutes(r, userHandler)
-	routes.SetupAdminRoutes(r, database.DB)
-
-	// Basic health check endpoint
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ok",
-		})
+	routes.SetupAuthRoutes(r, repositories, authHandler)
+	routes.SetupUserRoutes(r, repositories, userHandler, dataExportHandler)
+	routes.SetupNotificationRoutes(r, repositories, notificationHandler)
+	routes.SetupRealtimeRoutes(r, repositories, realtimeHandler)
+	routes.SetupAdminRoutes(r, repositories, appMetrics)
+	routes.SetupFlagRoutes(r, repositories, appMetrics)
+	if cfg.Metrics.Enabled {
+		routes.SetupMetricsRoutes(r, appMetrics, cfg.Metrics.Token)
+	}
+
+	// Liveness and readiness probes
+	checks := health.New()
+	checks.AddReadiness(health.Check{Name: "database", Check: health.PingDB(sqlDB)})
+	checks.AddReadiness(health.Check{Name: "migrations", Check: migrator.Verify})
+	// Only reported in development, where a local SMTP catcher may not be running
+	checks.AddReadiness(health.Check{
+		Name:     "email",
+		Check:    email.Ping,
+		Optional: cfg.Env == config.EnvDevelopment,
 	})
+	// Without the listener streams stay open but receive no events
+	checks.AddReadiness(health.Check{
+		Name:     "realtime",
+		Check:    realtime.CheckListener,
+		Optional: true,
+	})
+	// A scheduler whose loop has stopped only runs again after a restart
+	for _, name := range []string{
+		accountdeletion.PurgeWorkerName,
+		dataexport.WorkerName,
+		outbox.DispatcherName,
+		webhook.DispatcherName,
+		notification.DigestName,
+	} {
+		checks.AddLiveness(health.Check{
+			Name: name,
+			Check: func(context.Context) error {
+				return background.CheckScheduler(name)
+			},
+		})
+	}
+	routes.SetupHealthRoutes(r, checks)
+
+	srv := &http.Server{
+		Addr:              ":" + cfg.HTTP.Port,
+		Handler:           r,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+	// Open event streams would otherwise keep the server from draining
+	srv.RegisterOnShutdown(hub.Close)
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "addr", srv.Addr)
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	// Wait for SIGINT/SIGTERM, or for the server to fail on its own
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server failed to start:", err)
+		}
+	case sig := <-signals:
+		slog.Info("Shutting down", "signal", sig.String())
+	}
+	signal.Stop(signals)
+
+	// Fail readiness first and keep serving until load balancers have noticed
+	checks.SetShuttingDown()
+	if delay := cfg.HTTP.ShutdownDrainDelay; delay > 0 {
+		slog.Info("Waiting for load balancers to stop routing requests", "delay", delay)
+		time.Sleep(delay)
 	}
-	
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Server failed to start:", err)
+
+	shutdown(srv, flushTraces, cfg.HTTP.ShutdownTimeout)
+}
+
+// shutdown stops the application in dependency order: stop accepting requests and
+// drain in-flight ones, stop schedulers and background jobs, deliver the emails
+// they queued, export the remaining spans, then close the database pool
+// everything else used. All steps share one deadline.
+func shutdown(srv *http.Server, flushTraces func(context.Context) error, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("HTTP server did not drain in time", "error", err)
+	} else {
+		slog.Info("HTTP server stopped")
+	}
+
+	if err := background.Stop(ctx); err != nil {
+		slog.Error("Background jobs did not finish in time", "error", err)
+	} else {
+		slog.Info("Background jobs stopped")
+	}
+
+	// Deliver the emails queued by the last requests and jobs
+	if err := outbox.Flush(ctx); err != nil {
+		slog.Error("Queued emails were not all delivered in time", "error", err)
+	}
+
+	if err := flushTraces(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+
+	if err := database.Close(); err != nil {
+		slog.Error("Failed to close database", "error", err)
+	} else {
+		slog.Info("Database connection closed")
 	}
-} 
\ No newline at end of file
+}
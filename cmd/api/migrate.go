@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"ai-backend/internal/database"
+	"ai-backend/internal/migrations"
+)
+
+const migrateUsage = `Usage: main migrate <command>
+
+Commands:
+  up              Apply all pending migrations
+  down [steps]    Roll back the last applied migrations (default 1)
+  status          List migrations and whether they are applied
+  create <name>   Create an empty up/down migration pair in ` + migrations.SourceDir
+
+// newMigrator loads the embedded migrations for the initialized database
+func newMigrator() *migrations.Migrator {
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		log.Fatal("Failed to get database instance:", err)
+	}
+
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+	return migrator
+}
+
+// runMigrate handles the migrate subcommand
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	if args[0] != "create" {
+		database.InitDB()
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := newMigrator().Up(ctx)
+		if err != nil {
+			log.Fatal("Failed to apply migrations:", err)
+		}
+		log.Printf("Applied %d migrations", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			parsed, err := strconv.Atoi(args[1])
+			if err != nil || parsed < 1 {
+				log.Fatalf("Invalid number of steps: %s", args[1])
+			}
+			steps = parsed
+		}
+		rolledBack, err := newMigrator().Down(ctx, steps)
+		if err != nil {
+			log.Fatal("Failed to roll back migrations:", err)
+		}
+		log.Printf("Rolled back %d migrations", rolledBack)
+
+	case "status":
+		statuses, err := newMigrator().Status(ctx)
+		if err != nil {
+			log.Fatal("Failed to read migration status:", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			switch {
+			case status.Missing:
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05") + " (missing from binary)"
+			case status.AppliedAt != nil:
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", status.Version, status.Name, state)
+		}
+
+	case "create":
+		if len(args) < 2 {
+			fmt.Println(migrateUsage)
+			os.Exit(2)
+		}
+		up, down, err := migrations.Create(migrations.SourceDir, args[1])
+		if err != nil {
+			log.Fatal("Failed to create migration:", err)
+		}
+		log.Printf("Created %s and %s", up, down)
+
+	default:
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+}
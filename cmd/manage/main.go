@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"ai-backend/internal/accountdeletion"
+	"ai-backend/internal/config"
+	"ai-backend/internal/database"
+	"ai-backend/internal/dataexport"
+	"ai-backend/internal/logging"
+	"ai-backend/internal/metrics"
+	"ai-backend/internal/models"
+	"ai-backend/internal/moderation"
+	"ai-backend/internal/repository"
+	"ai-backend/internal/service"
+	"ai-backend/internal/votes"
+	"ai-backend/internal/webhook"
+	"ai-backend/pkg/storage"
+)
+
+const usage = `Usage: manage <command> [flags]
+
+Commands:
+  create-superadmin  Create a SUPER_ADMIN user
+  reset-password     Set a new password for a user
+  ban                Ban a user
+  unban              Lift a user's active ban
+  set-role           Change a user's role
+  list-users         List users
+  run-expiry         Close expired bans, freezes and restrictions, purge expired data exports and erase deleted accounts
+  recompute-votes    Recalculate question and answer vote counts
+
+Users can be given by ID, username or email. Commands that act on other users run as
+the first SUPER_ADMIN unless -as is set, and follow the same rules as the admin API.
+Run "manage <command> -h" for the flags of a command.`
+
+var (
+	repositories      repository.Store
+	userService       *service.UserService
+	moderationService *service.ModerationService
+)
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Queries are logged by the same redacting logger as the API; LOG_LEVEL=debug shows them
+	if err := logging.Setup(cfg.Log); err != nil {
+		log.Fatal(err)
+	}
+
+	commands := map[string]func(args []string) error{
+		"create-superadmin": createSuperAdmin,
+		"reset-password":    resetPassword,
+		"ban":               banUser,
+		"unban":             unbanUser,
+		"set-role":          setRole,
+		"list-users":        listUsers,
+		"run-expiry":        runExpiry,
+		"recompute-votes":   recomputeVotes,
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	database.InitDB()
+
+	repositories = repository.NewGormStore(database.DB)
+	userService = service.NewUserService(repositories)
+	// The CLI exposes no metrics endpoint, so what it records is never scraped
+	moderationService = service.NewModerationService(repositories, metrics.New(prometheus.NewRegistry()))
+
+	// Record webhook deliveries for the changes commands make. The API's
+	// dispatcher sends them.
+	webhook.Subscribe()
+
+	if err := command(os.Args[2:]); err != nil {
+		var serviceErr service.Error
+		if errors.As(err, &serviceErr) {
+			log.Fatalf("Error: %s", serviceErr.Message)
+		}
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+// findUser looks a user up by ID, username or email
+func findUser(identifier string) (*models.User, error) {
+	if identifier == "" {
+		return nil, fmt.Errorf("a user is required")
+	}
+
+	var user *models.User
+	var err error
+	if id, parseErr := strconv.ParseUint(identifier, 10, 32); parseErr == nil {
+		user, err = repositories.Users().FindByID(uint(id))
+	} else {
+		user, err = repositories.Users().FindByLogin(identifier)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("user not found: %s", identifier)
+	}
+	return user, err
+}
+
+// findActor returns the user a command acts as, defaulting to the first SUPER_ADMIN
+func findActor(identifier string) (*models.User, error) {
+	if identifier != "" {
+		return findUser(identifier)
+	}
+	firstSuperAdmin, err := repositories.Users().FindFirstSuperAdmin()
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("no SUPER_ADMIN exists, create one with create-superadmin")
+	}
+	return firstSuperAdmin, err
+}
+
+// readPassword returns value, or reads the password from stdin so it stays out of shell history
+func readPassword(value string) (string, error) {
+	if value == "" {
+		fmt.Print("Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		value = strings.TrimSpace(line)
+	}
+	if len(value) < 6 {
+		return "", fmt.Errorf("password must be at least 6 characters long")
+	}
+	return value, nil
+}
+
+func createSuperAdmin(args []string) error {
+	fs := flag.NewFlagSet("create-superadmin", flag.ExitOnError)
+	username := fs.String("username", "", "username (required)")
+	email := fs.String("email", "", "email (required)")
+	password := fs.String("password", "", "password, read from stdin when empty")
+	fs.Parse(args)
+
+	if len(*username) < 3 || *email == "" {
+		return fmt.Errorf("-username (at least 3 characters) and -email are required")
+	}
+
+	plain, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	user, err := userService.CreateSuperAdmin(*username, *email, plain)
+	if err != nil {
+		return err
+	}
+	log.Printf("Created SUPER_ADMIN %s (ID %d)", *user.Username, user.ID)
+	return nil
+}
+
+func resetPassword(args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	userFlag := fs.String("user", "", "user ID, username or email (required)")
+	password := fs.String("password", "", "new password, read from stdin when empty")
+	fs.Parse(args)
+
+	user, err := findUser(*userFlag)
+	if err != nil {
+		return err
+	}
+
+	plain, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+	if err := userService.SetPassword(user.ID, plain); err != nil {
+		return err
+	}
+	log.Printf("Password reset for user %d", user.ID)
+	return nil
+}
+
+func banUser(args []string) error {
+	fs := flag.NewFlagSet("ban", flag.ExitOnError)
+	userFlag := fs.String("user", "", "user ID, username or email (required)")
+	reason := fs.String("reason", "", "ban reason, at least 15 characters (required)")
+	duration := fs.String("duration", "", `number of days or "permanent" (required)`)
+	as := fs.String("as", "", "act as this ADMIN or SUPER_ADMIN (default first SUPER_ADMIN)")
+	fs.Parse(args)
+
+	if len(*reason) < 15 {
+		return fmt.Errorf("-reason must be at least 15 characters long")
+	}
+
+	actor, err := findActor(*as)
+	if err != nil {
+		return err
+	}
+	target, err := findUser(*userFlag)
+	if err != nil {
+		return err
+	}
+
+	if _, err := moderationService.Ban(actor, service.BanInput{
+		UserID:   target.ID,
+		Reason:   *reason,
+		Duration: *duration,
+	}); err != nil {
+		return err
+	}
+	log.Printf("User %d banned by %d", target.ID, actor.ID)
+	return nil
+}
+
+func unbanUser(args []string) error {
+	fs := flag.NewFlagSet("unban", flag.ExitOnError)
+	userFlag := fs.String("user", "", "user ID, username or email (required)")
+	reason := fs.String("reason", "", "unban reason, at least 15 characters (required)")
+	as := fs.String("as", "", "act as this ADMIN or SUPER_ADMIN (default first SUPER_ADMIN)")
+	fs.Parse(args)
+
+	if len(*reason) < 15 {
+		return fmt.Errorf("-reason must be at least 15 characters long")
+	}
+
+	actor, err := findActor(*as)
+	if err != nil {
+		return err
+	}
+	target, err := findUser(*userFlag)
+	if err != nil {
+		return err
+	}
+
+	if _, err := moderationService.Unban(actor, target.ID, *reason); err != nil {
+		return err
+	}
+	log.Printf("User %d unbanned by %d", target.ID, actor.ID)
+	return nil
+}
+
+func setRole(args []string) error {
+	fs := flag.NewFlagSet("set-role", flag.ExitOnError)
+	userFlag := fs.String("user", "", "user ID, username or email (required)")
+	role := fs.String("role", "", "USER, EDITOR, ADMIN or SUPER_ADMIN (required)")
+	reason := fs.String("reason", "", "reason for the change")
+	as := fs.String("as", "", "act as this ADMIN or SUPER_ADMIN (default first SUPER_ADMIN)")
+	fs.Parse(args)
+
+	newRole := models.UserRole(*role)
+	switch newRole {
+	case models.RoleUser, models.RoleEditor, models.RoleAdmin, models.RoleSuperAdmin:
+	default:
+		return fmt.Errorf("-role must be one of USER, EDITOR, ADMIN, SUPER_ADMIN")
+	}
+
+	actor, err := findActor(*as)
+	if err != nil {
+		return err
+	}
+	target, err := findUser(*userFlag)
+	if err != nil {
+		return err
+	}
+
+	result, err := moderationService.ChangeRole(actor, target.ID, newRole, *reason)
+	if err != nil {
+		return err
+	}
+	log.Printf("User %d role changed from %s to %s by %d", target.ID, result.History.OldRole, result.History.NewRole, actor.ID)
+	return nil
+}
+
+func listUsers(args []string) error {
+	fs := flag.NewFlagSet("list-users", flag.ExitOnError)
+	search := fs.String("search", "", "filter by username or email")
+	role := fs.String("role", "", "filter by role")
+	status := fs.String("status", "", "filter by status")
+	limit := fs.Int("limit", 50, "maximum number of users")
+	fs.Parse(args)
+
+	users, _, err := userService.List(service.ListUsersInput{
+		Page:   1,
+		Limit:  *limit,
+		Search: *search,
+		Role:   *role,
+		Status: *status,
+		Sort:   "created_at",
+		Order:  "asc",
+	})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\tROLE\tSTATUS\tCREATED")
+	for _, u := range users {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", u.ID, stringOrDash(u.Username), stringOrDash(u.Email), u.Role, u.Status,
+			u.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	return w.Flush()
+}
+
+func stringOrDash(value *string) string {
+	if value == nil || *value == "" {
+		return "-"
+	}
+	return *value
+}
+
+func runExpiry(args []string) error {
+	fs := flag.NewFlagSet("run-expiry", flag.ExitOnError)
+	fs.Parse(args)
+
+	bans, err := moderationService.ExpireBans()
+	if err != nil {
+		return fmt.Errorf("failed to expire bans: %w", err)
+	}
+	log.Printf("Expired bans: %d", bans)
+
+	freezes, err := moderationService.ExpireFreezes()
+	if err != nil {
+		return fmt.Errorf("failed to expire freezes: %w", err)
+	}
+	log.Printf("Expired freezes: %d", freezes)
+
+	restrictions, err := moderation.ExpireRestrictions(database.DB)
+	if err != nil {
+		return fmt.Errorf("failed to expire restrictions: %w", err)
+	}
+	log.Printf("Expired restrictions: %d", restrictions)
+
+	store, err := storage.NewLocalStorage(config.Get().Storage.Dir)
+	if err != nil {
+		return err
+	}
+
+	exports, err := dataexport.PurgeExpired(database.DB, store)
+	if err != nil {
+		return fmt.Errorf("failed to purge data exports: %w", err)
+	}
+	log.Printf("Purged data exports: %d", exports)
+
+	stale, err := dataexport.FailStale(database.DB, time.Now().Add(-config.Get().Storage.DataExportTimeout))
+	if err != nil {
+		return fmt.Errorf("failed to time out stale data exports: %w", err)
+	}
+	log.Printf("Timed out data exports: %d", stale)
+
+	erased, err := accountdeletion.PurgeDue(database.DB, store)
+	if err != nil {
+		return fmt.Errorf("failed to erase deleted accounts: %w", err)
+	}
+	log.Printf("Erased deleted accounts: %d", erased)
+
+	return nil
+}
+
+func recomputeVotes(args []string) error {
+	fs := flag.NewFlagSet("recompute-votes", flag.ExitOnError)
+	fs.Parse(args)
+
+	fixed, err := votes.RecomputeAll(database.DB)
+	if err != nil {
+		return err
+	}
+	log.Printf("Vote counts fixed: %d", fixed)
+	return nil
+}
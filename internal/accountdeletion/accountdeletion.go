@@ -0,0 +1,345 @@
+package accountdeletion
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"ai-backend/internal/background"
+	"ai-backend/internal/config"
+	"ai-backend/internal/metrics"
+	"ai-backend/internal/models"
+	"ai-backend/pkg/storage"
+)
+
+// placeholderUsername is the username of the user that inherits erased users' content.
+// Erased users are renamed to placeholderUsername followed by "-<id>".
+const placeholderUsername = "deleted-user"
+
+// IsReservedUsername reports whether username could collide with the placeholder
+// user or an erased user. Such usernames cannot be chosen.
+func IsReservedUsername(username string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(username)), placeholderUsername)
+}
+
+// reactivationPrefix starts the identifier of reactivation tokens so they cannot be used as password reset tokens
+const reactivationPrefix = "reactivate:"
+
+var (
+	// ErrNotScheduled is returned when a user has no deletion waiting for its grace period to end
+	ErrNotScheduled = errors.New("account deletion is not scheduled")
+	// ErrErased is returned when restoring an account whose personal data was already erased
+	ErrErased = errors.New("account has been permanently erased")
+	// ErrGracePeriodEnded is returned when restoring an account that is waiting to be erased
+	ErrGracePeriodEnded = errors.New("account deletion grace period has ended")
+	// ErrMerged is returned when restoring a duplicate account that was merged into another user
+	ErrMerged = errors.New("account was merged into another user")
+	// ErrUsernameTaken is returned when a restored username now belongs to another user
+	ErrUsernameTaken = errors.New("username is already taken")
+	// ErrEmailTaken is returned when a restored email now belongs to another user
+	ErrEmailTaken = errors.New("email is already taken")
+	// ErrUsernameReserved is returned when a restored username is reserved, see IsReservedUsername
+	ErrUsernameReserved = errors.New("username is reserved")
+)
+
+// GracePeriod returns how long a deleted account can still be restored
+func GracePeriod() time.Duration {
+	return config.Get().Moderation.AccountDeletionGrace
+}
+
+// FindScheduled returns the user's pending deletion, or ErrNotScheduled
+func FindScheduled(db *gorm.DB, userID uint) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := db.Where("user_id = ? AND status = ?", userID, models.AccountDeletionScheduled).
+		Order("created_at DESC").
+		First(&deletion).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotScheduled
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// ReactivationIdentifier is the verification token identifier used for a user's reactivation tokens
+func ReactivationIdentifier(userID uint) string {
+	return fmt.Sprintf("%s%d", reactivationPrefix, userID)
+}
+
+// ParseReactivationIdentifier returns the user ID of a reactivation token identifier
+func ParseReactivationIdentifier(identifier string) (uint, bool) {
+	if !strings.HasPrefix(identifier, reactivationPrefix) {
+		return 0, false
+	}
+	userID, err := strconv.ParseUint(strings.TrimPrefix(identifier, reactivationPrefix), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+// Placeholder returns the "deleted user" account that erased users' content is
+// reassigned to, creating it on first use. It is identified by its IsPlaceholder
+// flag, has no email or password and cannot log in.
+func Placeholder(db *gorm.DB) (*models.User, error) {
+	username := placeholderUsername
+	name := "Deleted user"
+	var placeholder models.User
+	if err := db.Where("is_placeholder = ?", true).
+		Attrs(models.User{
+			Username:      &username,
+			Name:          &name,
+			Role:          models.RoleUser,
+			Status:        models.StatusPassive,
+			IsPlaceholder: true,
+		}).
+		FirstOrCreate(&placeholder).Error; err != nil {
+		return nil, err
+	}
+	return &placeholder, nil
+}
+
+// hashEmail returns the hex encoded sha256 of a lowercased email address
+func hashEmail(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// redactWebhookPayloads blanks email in the payloads of webhook deliveries,
+// such as those of user.registered events
+func redactWebhookPayloads(tx *gorm.DB, email string) error {
+	var deliveries []models.WebhookDelivery
+	if err := tx.Where("STRPOS(LOWER(payload), LOWER(?)) > 0", email).Find(&deliveries).Error; err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		payload, redacted, err := redactEmail(delivery.Payload, email)
+		if err != nil {
+			return fmt.Errorf("failed to redact webhook delivery %d: %w", delivery.ID, err)
+		}
+		if !redacted {
+			continue
+		}
+		if err := tx.Model(&delivery).Update("payload", payload).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redactEmail replaces every string in a JSON payload that equals email,
+// ignoring case, with null and reports whether it replaced any
+func redactEmail(payload, email string) (string, bool, error) {
+	// Numbers are kept as written instead of going through float64
+	decoder := json.NewDecoder(strings.NewReader(payload))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", false, err
+	}
+	redacted := false
+	var redact func(v any) any
+	redact = func(v any) any {
+		switch v := v.(type) {
+		case string:
+			if strings.EqualFold(v, email) {
+				redacted = true
+				return nil
+			}
+		case map[string]any:
+			for key, item := range v {
+				v[key] = redact(item)
+			}
+		case []any:
+			for i, item := range v {
+				v[i] = redact(item)
+			}
+		}
+		return v
+	}
+	value = redact(value)
+	if !redacted {
+		return payload, false, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", false, err
+	}
+	return string(encoded), true, nil
+}
+
+// Erase anonymizes the user's personal data, reassigns their content to the
+// placeholder user and completes the deletion, recording what was removed
+func Erase(db *gorm.DB, store storage.Storage, deletion *models.AccountDeletion) error {
+	placeholder, err := Placeholder(db)
+	if err != nil {
+		return fmt.Errorf("failed to load placeholder user: %w", err)
+	}
+
+	var archiveKeys []string
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Unscoped().First(&user, deletion.UserID).Error; err != nil {
+			return err
+		}
+
+		// Questions, answers and votes survive under the placeholder so threads and vote counts stay intact
+		result := tx.Unscoped().Model(&models.Question{}).Where("user_id = ?", user.ID).Update("user_id", placeholder.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		deletion.QuestionsReassigned = result.RowsAffected
+
+		result = tx.Unscoped().Model(&models.Answer{}).Where("user_id = ?", user.ID).Update("user_id", placeholder.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		deletion.AnswersReassigned = result.RowsAffected
+
+		result = tx.Unscoped().Model(&models.Vote{}).Where("user_id = ?", user.ID).Update("user_id", placeholder.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		deletion.VotesReassigned = result.RowsAffected
+
+		result = tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Account{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deletion.AccountsRemoved = result.RowsAffected
+
+		result = tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Session{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deletion.SessionsRemoved = result.RowsAffected
+
+		identifiers := []string{ReactivationIdentifier(user.ID)}
+		if user.Email != nil {
+			identifiers = append(identifiers, *user.Email)
+		}
+		result = tx.Where("identifier IN ?", identifiers).Delete(&models.VerificationToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deletion.TokensRemoved = result.RowsAffected
+
+		// Notifications quote ban reasons and question titles
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.Notification{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.NotificationPreference{}).Error; err != nil {
+			return err
+		}
+
+		if user.Email != nil {
+			emailHash := hashEmail(*user.Email)
+			deletion.EmailHash = &emailHash
+
+			// Queued and dead-lettered emails keep the address and the rendered bodies
+			if err := tx.Where("LOWER(recipient) = LOWER(?)", *user.Email).Delete(&models.OutboxMessage{}).Error; err != nil {
+				return err
+			}
+			if err := redactWebhookPayloads(tx, *user.Email); err != nil {
+				return err
+			}
+		}
+
+		var exports []models.DataExport
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Find(&exports).Error; err != nil {
+			return err
+		}
+		for _, export := range exports {
+			if export.StorageKey != nil {
+				archiveKeys = append(archiveKeys, *export.StorageKey)
+			}
+		}
+		result = tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.DataExport{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deletion.ExportsRemoved = result.RowsAffected
+
+		// Moderation histories keep pointing at the user row, so it is anonymized rather than removed
+		if err := tx.Unscoped().Model(&user).Updates(map[string]interface{}{
+			"name":          nil,
+			"username":      fmt.Sprintf("deleted-user-%d", user.ID),
+			"email":         nil,
+			"emailVerified": nil,
+			"password":      nil,
+			"image":         nil,
+			"status":        models.StatusPassive,
+		}).Error; err != nil {
+			return err
+		}
+
+		certificate := make([]byte, 16)
+		if _, err := rand.Read(certificate); err != nil {
+			return err
+		}
+		certificateID := hex.EncodeToString(certificate)
+		now := time.Now()
+		deletion.CertificateID = &certificateID
+		deletion.Status = models.AccountDeletionCompleted
+		deletion.CompletedAt = &now
+
+		return tx.Save(deletion).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range archiveKeys {
+		if err := store.Delete(key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			slog.Error("Failed to delete data export archive of erased user", "key", key, "user_id", deletion.UserID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// PurgeDue erases every account whose grace period has ended and returns how many were erased
+func PurgeDue(db *gorm.DB, store storage.Storage) (int, error) {
+	var deletions []models.AccountDeletion
+	if err := db.Where("status = ? AND scheduled_for <= ?", models.AccountDeletionScheduled, time.Now()).
+		Order("scheduled_for").
+		Find(&deletions).Error; err != nil {
+		return 0, err
+	}
+
+	erased := 0
+	for i := range deletions {
+		if err := Erase(db, store, &deletions[i]); err != nil {
+			slog.Error("Failed to erase user", "user_id", deletions[i].UserID, "error", err)
+			continue
+		}
+		erased++
+		slog.Info("User erased", "user_id", deletions[i].UserID, "certificate_id", *deletions[i].CertificateID)
+	}
+	return erased, nil
+}
+
+// PurgeWorkerName identifies the purge worker in metrics and health checks
+const PurgeWorkerName = "account_purge"
+
+// StartPurgeWorker runs PurgeDue every interval in the background until shutdown
+func StartPurgeWorker(db *gorm.DB, store storage.Storage, interval time.Duration, m *metrics.Metrics) {
+	background.Every(PurgeWorkerName, interval, m, func(ctx context.Context) {
+		if erased, err := PurgeDue(db, store); err != nil {
+			slog.ErrorContext(ctx, "Failed to purge deleted accounts", "error", err)
+		} else if erased > 0 {
+			slog.InfoContext(ctx, "Erased deleted accounts", "count", erased)
+		}
+	})
+}
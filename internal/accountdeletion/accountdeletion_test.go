@@ -0,0 +1,189 @@
+package accountdeletion
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"ai-backend/internal/events"
+	"ai-backend/internal/migrations"
+	"ai-backend/internal/models"
+	"ai-backend/pkg/storage"
+)
+
+func TestRedactEmail(t *testing.T) {
+	username, email := "alice", "alice@example.com"
+	user := &models.User{Username: &username, Email: &email, Role: models.RoleUser}
+	user.ID = 4294967295
+	payload, err := json.Marshal(events.Registered(user))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redacted, ok, err := redactEmail(string(payload), "Alice@Example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || strings.Contains(redacted, email) {
+		t.Fatalf("redacted payload is %s, want the email blanked", redacted)
+	}
+	var event events.Event
+	if err := json.Unmarshal([]byte(redacted), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Data["email"] != nil || event.Data["username"] != username {
+		t.Errorf("redacted data is %v, want only the email blanked", event.Data)
+	}
+	if !strings.Contains(redacted, `"user_id":4294967295`) {
+		t.Errorf("redacted payload %s does not keep the user ID as written", redacted)
+	}
+
+	unchanged, ok, err := redactEmail(string(payload), "bob@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok || unchanged != string(payload) {
+		t.Errorf("payload without the email became %s", unchanged)
+	}
+}
+
+// openTestDB connects to MIGRATIONS_TEST_DATABASE_URL with a new schema that
+// has every migration applied, dropping it when the test ends. The test is
+// skipped when the variable is not set.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	databaseURL := os.Getenv("MIGRATIONS_TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("MIGRATIONS_TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("accountdeletion_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(`CREATE SCHEMA "` + schema + `"`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA "` + schema + `" CASCADE`); err != nil {
+			t.Errorf("failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		t.Fatalf("MIGRATIONS_TEST_DATABASE_URL must be a URL: %v", err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	db, err := gorm.Open(postgres.Open(u.String()), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate up failed: %v", err)
+	}
+	return db
+}
+
+func TestEraseRemovesEmailFromOutboxAndWebhooks(t *testing.T) {
+	db := openTestDB(t)
+	files, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	username, email := "alice", "alice@example.com"
+	user := models.User{Username: &username, Email: &email, Role: models.RoleUser, Status: models.StatusActive}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	otherEmail := "bob@example.com"
+	now := time.Now()
+	for _, msg := range []models.OutboxMessage{
+		{Kind: "password_reset", Recipient: email, Subject: "Reset", HTMLBody: email, TextBody: email, Status: models.OutboxPending, NextAttemptAt: now},
+		{Kind: "ban_notice", Recipient: "ALICE@example.com", Subject: "Ban", HTMLBody: "ban", TextBody: "ban", Status: models.OutboxDead, NextAttemptAt: now},
+		{Kind: "password_reset", Recipient: otherEmail, Subject: "Reset", HTMLBody: "reset", TextBody: "reset", Status: models.OutboxPending, NextAttemptAt: now},
+	} {
+		if err := db.Create(&msg).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	subscription := models.WebhookSubscription{URL: "https://example.com/hook", Secret: "secret", EventTypes: events.TypeUserRegistered, CreatedByID: user.ID}
+	if err := db.Create(&subscription).Error; err != nil {
+		t.Fatal(err)
+	}
+	event := events.Registered(&user)
+	event.ID = "event"
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivery := models.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        string(payload),
+		Status:         models.WebhookDeliverySucceeded,
+		NextAttemptAt:  now,
+	}
+	if err := db.Create(&delivery).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	deletion := models.AccountDeletion{UserID: user.ID, Status: models.AccountDeletionScheduled, ScheduledFor: now.Add(-time.Hour)}
+	if err := db.Create(&deletion).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Erase(db, files, &deletion); err != nil {
+		t.Fatal(err)
+	}
+
+	var recipients []string
+	if err := db.Model(&models.OutboxMessage{}).Order("id").Pluck("recipient", &recipients).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(recipients) != 1 || recipients[0] != otherEmail {
+		t.Errorf("outbox holds emails to %v, want only %s", recipients, otherEmail)
+	}
+
+	if err := db.First(&delivery, delivery.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(strings.ToLower(delivery.Payload), email) {
+		t.Errorf("webhook payload %s still holds the email", delivery.Payload)
+	}
+	if deletion.Status != models.AccountDeletionCompleted || deletion.CertificateID == nil {
+		t.Errorf("deletion is %+v, want it completed with a certificate", deletion)
+	}
+}
@@ -0,0 +1,157 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"ai-backend/internal/metrics"
+)
+
+// Tasks started with Go are tracked so shutdown can wait for them
+var (
+	mu       sync.Mutex
+	tasks    sync.WaitGroup
+	stopping bool
+
+	baseCtx, cancelTasks = context.WithCancel(context.Background())
+)
+
+// Go runs fn in a tracked goroutine. ctx is cancelled when shutdown begins; jobs
+// that cannot stop halfway may ignore it and Stop waits for them to finish.
+// Tasks started after shutdown began are dropped. The run time is recorded in
+// the background job metrics of m under name.
+func Go(name string, m *metrics.Metrics, fn func(ctx context.Context)) {
+	spawn(name, func(ctx context.Context) {
+		start := time.Now()
+		fn(ctx)
+		m.ObserveJob(name, time.Since(start))
+	})
+}
+
+// spawn runs fn in a tracked goroutine
+func spawn(name string, fn func(ctx context.Context)) {
+	mu.Lock()
+	defer mu.Unlock()
+	if stopping {
+		slog.Warn("Background task dropped, shutting down", "task", name)
+		return
+	}
+
+	tasks.Add(1)
+	go func() {
+		defer tasks.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("Background task panicked", "task", name, "panic", r)
+			}
+		}()
+		fn(baseCtx)
+	}()
+}
+
+// schedule tracks a task started with Every so health checks can tell whether it still runs
+type schedule struct {
+	stopped bool
+	// trigger wakes the task before the next tick
+	trigger chan struct{}
+}
+
+var schedules = map[string]*schedule{}
+
+// Every runs fn immediately and then every interval until shutdown, or earlier
+// when Trigger is called. A run that panics is logged and the next one still
+// happens. Each run is recorded in the background job metrics of m under name.
+func Every(name string, interval time.Duration, m *metrics.Metrics, fn func(ctx context.Context)) {
+	mu.Lock()
+	current := &schedule{trigger: make(chan struct{}, 1)}
+	schedules[name] = current
+	mu.Unlock()
+
+	spawn(name, func(ctx context.Context) {
+		defer func() {
+			mu.Lock()
+			current.stopped = true
+			mu.Unlock()
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			runOnce(ctx, name, m, fn)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-current.trigger:
+			}
+		}
+	})
+}
+
+// runOnce runs one scheduled run of fn, recovering from a panic so the schedule keeps going
+func runOnce(ctx context.Context, name string, m *metrics.Metrics, fn func(ctx context.Context)) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "Scheduled task panicked", "task", name, "panic", r)
+		}
+		m.ObserveJob(name, time.Since(start))
+	}()
+	fn(ctx)
+}
+
+// Trigger makes the task started with Every under name run again as soon as its
+// current run, if any, finishes. Triggers that arrive during a run are coalesced.
+func Trigger(name string) {
+	mu.Lock()
+	current, ok := schedules[name]
+	mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case current.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Stop cancels the context passed to background tasks and waits for them to
+// return. It gives up when ctx is done and returns its error.
+func Stop(ctx context.Context) error {
+	mu.Lock()
+	stopping = true
+	mu.Unlock()
+	cancelTasks()
+
+	done := make(chan struct{})
+	go func() {
+		tasks.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CheckScheduler returns an error unless the loop of the task started with Every
+// under name is still running. A run that takes long does not fail the check.
+func CheckScheduler(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	current, ok := schedules[name]
+	switch {
+	case !ok:
+		return fmt.Errorf("scheduler %s was not started", name)
+	case current.stopped:
+		return fmt.Errorf("scheduler %s has stopped", name)
+	}
+	return nil
+}
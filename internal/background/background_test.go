@@ -0,0 +1,70 @@
+package background
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"ai-backend/internal/metrics"
+)
+
+func newTestMetrics() *metrics.Metrics {
+	return metrics.New(prometheus.NewRegistry())
+}
+
+func TestEverySurvivesPanics(t *testing.T) {
+	const name = "test_panicking"
+	var runs atomic.Int32
+	m := newTestMetrics()
+	Every(name, time.Hour, m, func(context.Context) {
+		runs.Add(1)
+		panic("run failed")
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runs.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("ran %d times, want the schedule to keep running after a panic", runs.Load())
+		}
+		Trigger(name)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := CheckScheduler(name); err != nil {
+		t.Errorf("scheduler failed its check after panicking runs: %v", err)
+	}
+	// Runs that panicked are still recorded
+	if n, err := testutil.GatherAndCount(m.Registry, "ai_backend_background_job_duration_seconds"); err != nil || n != 1 {
+		t.Errorf("recorded %d job series (%v), want 1", n, err)
+	}
+}
+
+func TestCheckSchedulerAllowsLongRuns(t *testing.T) {
+	const name = "test_long_run"
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	Every(name, time.Millisecond, newTestMetrics(), func(context.Context) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+	})
+
+	<-started
+	// The run has outlasted many intervals but the loop is still alive
+	time.Sleep(20 * time.Millisecond)
+	if err := CheckScheduler(name); err != nil {
+		t.Errorf("scheduler failed its check during a long run: %v", err)
+	}
+}
+
+func TestCheckSchedulerNotStarted(t *testing.T) {
+	if err := CheckScheduler("test_missing"); err == nil {
+		t.Error("a scheduler that was never started passed its check")
+	}
+}
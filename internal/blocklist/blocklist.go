@@ -0,0 +1,220 @@
+package blocklist
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"ai-backend/internal/models"
+)
+
+var (
+	disposableMu      sync.RWMutex
+	disposableDomains = map[string]bool{}
+)
+
+// LoadDisposableDomains loads a disposable email domain list, one domain per line with # comments
+func LoadDisposableDomains(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open disposable domain list: %w", err)
+	}
+	defer file.Close()
+
+	domains := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains[NormalizeDomain(line)] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read disposable domain list: %w", err)
+	}
+
+	disposableMu.Lock()
+	disposableDomains = domains
+	disposableMu.Unlock()
+
+	slog.Info("Loaded disposable email domains", "count", len(domains))
+	return nil
+}
+
+// NormalizeIP converts a single IP or CIDR range into canonical CIDR notation
+func NormalizeIP(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid CIDR range: %s", value)
+		}
+		return network.String(), nil
+	}
+
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return "", fmt.Errorf("invalid IP address: %s", value)
+	}
+	if ip.To4() != nil {
+		return ip.String() + "/32", nil
+	}
+	return ip.String() + "/128", nil
+}
+
+// NormalizeDomain lowercases a domain and strips a leading "@" or "*." prefix
+func NormalizeDomain(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	value = strings.TrimPrefix(value, "@")
+	value = strings.TrimPrefix(value, "*.")
+	return strings.TrimSuffix(value, ".")
+}
+
+// emailDomainCandidates returns the email's domain and all of its parent domains
+func emailDomainCandidates(email string) []string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return nil
+	}
+
+	domain := NormalizeDomain(email[at+1:])
+	var candidates []string
+	for domain != "" {
+		candidates = append(candidates, domain)
+		dot := strings.Index(domain, ".")
+		if dot < 0 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+	return candidates
+}
+
+// activeEntries scopes a query to blocklist entries of the given type that have not expired
+func activeEntries(db *gorm.DB, entryType models.BlocklistType) *gorm.DB {
+	return db.Model(&models.BlocklistEntry{}).
+		Where("type = ? AND (expires_at IS NULL OR expires_at > ?)", entryType, time.Now())
+}
+
+// ActiveEntry returns the unexpired entry blocking value, or nil when there is none.
+// An expired entry is soft deleted so the value can be blocked again without
+// colliding with it in the unique index.
+func ActiveEntry(tx *gorm.DB, entryType models.BlocklistType, value string) (*models.BlocklistEntry, error) {
+	var entry models.BlocklistEntry
+	if err := tx.Where("type = ? AND value = ?", entryType, value).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if entry.ExpiresAt == nil || entry.ExpiresAt.After(time.Now()) {
+		return &entry, nil
+	}
+	if err := tx.Delete(&entry).Error; err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// IsIPBlocked reports whether ip falls inside an active blocked IP or CIDR range
+func IsIPBlocked(db *gorm.DB, ip string) (bool, error) {
+	if net.ParseIP(ip) == nil {
+		return false, nil
+	}
+
+	var count int64
+	if err := activeEntries(db, models.BlocklistIP).
+		Where("?::inet <<= value::cidr", ip).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// IsEmailDomainBlocked reports whether the email's domain, or one of its parents, is blocked by an admin.
+// When includeDisposable is set the disposable domain list is checked as well.
+func IsEmailDomainBlocked(db *gorm.DB, email string, includeDisposable bool) (bool, error) {
+	candidates := emailDomainCandidates(email)
+	if len(candidates) == 0 {
+		return false, nil
+	}
+
+	if includeDisposable {
+		disposableMu.RLock()
+		for _, domain := range candidates {
+			if disposableDomains[domain] {
+				disposableMu.RUnlock()
+				return true, nil
+			}
+		}
+		disposableMu.RUnlock()
+	}
+
+	var count int64
+	if err := activeEntries(db, models.BlocklistEmailDomain).
+		Where("value IN ?", candidates).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RecordUserIPs blocks every distinct IP seen in the user's sessions during the last sinceDays days.
+// An active block ending before expiresAt is extended, a longer one is left alone,
+// and an expired one is replaced. It returns the values whose block was added or extended.
+func RecordUserIPs(tx *gorm.DB, userID uint, createdByID uint, reason string, sinceDays int, expiresAt *time.Time) ([]string, error) {
+	var ips []string
+	if err := tx.Model(&models.Session{}).Unscoped().
+		Where("user_id = ? AND ip_address IS NOT NULL AND created_at > ?", userID, time.Now().AddDate(0, 0, -sinceDays)).
+		Distinct().
+		Pluck("ip_address", &ips).Error; err != nil {
+		return nil, err
+	}
+
+	var recorded []string
+	for _, ip := range ips {
+		value, err := NormalizeIP(ip)
+		if err != nil {
+			continue
+		}
+
+		existing, err := ActiveEntry(tx, models.BlocklistIP, value)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			if existing.ExpiresAt == nil || (expiresAt != nil && !existing.ExpiresAt.Before(*expiresAt)) {
+				continue
+			}
+			if err := tx.Model(existing).Update("expires_at", expiresAt).Error; err != nil {
+				return nil, err
+			}
+			recorded = append(recorded, value)
+			continue
+		}
+
+		entry := models.BlocklistEntry{
+			Type:         models.BlocklistIP,
+			Value:        value,
+			Reason:       reason,
+			CreatedByID:  createdByID,
+			SourceUserID: &userID,
+			ExpiresAt:    expiresAt,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return nil, err
+		}
+		recorded = append(recorded, value)
+	}
+
+	return recorded, nil
+}
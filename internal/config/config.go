@@ -0,0 +1,769 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Environment names accepted in APP_ENV
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// defaultFile is read when no config file is given; unlike an explicit file it may be missing
+const defaultFile = ".env"
+
+// devJWTSecret signs tokens in development when JWT_SECRET is not set
+const devJWTSecret = "your-256-bit-secret"
+
+type HTTPConfig struct {
+	Port              string
+	BaseURL           string // public URL used in emailed links
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// ShutdownTimeout bounds how long shutdown waits for requests and background jobs
+	ShutdownTimeout time.Duration
+	// ShutdownDrainDelay keeps serving after readiness starts failing, so load
+	// balancers stop routing to the instance before it closes its listener
+	ShutdownDrainDelay time.Duration
+}
+
+type DatabaseConfig struct {
+	URL             string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	MigrateOnStart  bool
+}
+
+type LogConfig struct {
+	Level              string // debug, info, warn or error
+	Format             string // text or json
+	SlowQueryThreshold time.Duration
+}
+
+type MetricsConfig struct {
+	Enabled bool
+	// Token, when set, must be sent as a bearer token to read /metrics
+	Token string
+}
+
+type TracingConfig struct {
+	Exporter    string // otlp, stdout or none
+	Endpoint    string // OTLP/HTTP traces URL
+	Headers     map[string]string
+	ServiceName string
+	SampleRatio float64
+}
+
+type AuthConfig struct {
+	JWTSecret        string
+	TokenTTL         time.Duration
+	SessionTTL       time.Duration
+	PasswordResetTTL time.Duration
+	ReactivationTTL  time.Duration
+}
+
+// Email drivers
+const (
+	EmailDriverResend = "resend"
+	EmailDriverSMTP   = "smtp"
+	EmailDriverFile   = "file"
+	EmailDriverMemory = "memory"
+)
+
+type EmailConfig struct {
+	// Driver selects the transport: resend, smtp, file or memory
+	Driver       string
+	From         string
+	ResendAPIKey string
+	SMTP         SMTPConfig
+	// Dir is where the file driver writes messages
+	Dir string
+}
+
+// SMTPConfig describes the server used by the smtp email driver. STARTTLS is
+// used whenever the server offers it.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+// OutboxConfig controls the delivery of queued emails
+type OutboxConfig struct {
+	Workers      int
+	PollInterval time.Duration
+	// MaxAttempts is the number of deliveries tried before a message is dead-lettered
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Retention is how long delivered messages are kept
+	Retention time.Duration
+}
+
+// NotificationConfig controls user notifications
+type NotificationConfig struct {
+	// DigestInterval is how often unread notifications are summarized in a digest email
+	DigestInterval time.Duration
+}
+
+// RealtimeConfig controls the real-time event streams
+type RealtimeConfig struct {
+	// HeartbeatInterval is how often idle streams get a keep-alive message
+	HeartbeatInterval time.Duration
+	// MaxSubscriptions caps the questions one connection can follow
+	MaxSubscriptions int
+	// BufferSize is how many events may wait for a slow connection before it is closed
+	BufferSize int
+}
+
+// WebhookConfig controls the delivery of webhook events
+type WebhookConfig struct {
+	Workers      int
+	PollInterval time.Duration
+	// Timeout bounds a single delivery, including reading the response
+	Timeout time.Duration
+	// MaxAttempts is the number of deliveries tried before a delivery is marked failed
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Retention is how long delivery logs are kept
+	Retention time.Duration
+}
+
+type StorageConfig struct {
+	Dir               string
+	DataExportLinkTTL time.Duration
+	// DataExportTimeout is how long an export may stay pending or processing
+	// before it is marked as failed, so the user can request a new one
+	DataExportTimeout time.Duration
+}
+
+type ModerationConfig struct {
+	FlagAutoHideThreshold int
+	DisposableDomainsFile string
+	AccountDeletionGrace  time.Duration
+	StatsCacheTTL         time.Duration
+}
+
+// SeedConfig describes the user created on startup if it does not exist yet
+type SeedConfig struct {
+	Username string
+	Email    string
+	Password string
+	Role     string
+}
+
+// Config is the effective configuration of the application
+type Config struct {
+	Env          string
+	HTTP         HTTPConfig
+	Database     DatabaseConfig
+	Log          LogConfig
+	Metrics      MetricsConfig
+	Tracing      TracingConfig
+	Auth         AuthConfig
+	Email        EmailConfig
+	Outbox       OutboxConfig
+	Notification NotificationConfig
+	Realtime     RealtimeConfig
+	Webhook      WebhookConfig
+	Storage      StorageConfig
+	Moderation   ModerationConfig
+	Seed         SeedConfig
+}
+
+var (
+	mu      sync.RWMutex
+	current *Config
+)
+
+// Get returns the loaded configuration, or the defaults overlaid with the
+// environment when Load has not been called
+func Get() *Config {
+	mu.RLock()
+	cfg := current
+	mu.RUnlock()
+	if cfg != nil {
+		return cfg
+	}
+
+	cfg, problems := parse(environ(nil))
+	for _, problem := range problems {
+		slog.Warn("Invalid configuration, using default", "problem", problem)
+	}
+	mu.Lock()
+	if current == nil {
+		current = cfg
+	}
+	cfg = current
+	mu.Unlock()
+	return cfg
+}
+
+// IsProduction reports whether the application runs in production
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// Load reads the configuration from, in increasing order of precedence, built-in
+// defaults, the config file, the environment and command line flags. args are the
+// command line arguments after the program name; nil skips flag parsing. The file
+// is given with -config or CONFIG_FILE and defaults to an optional ".env".
+// The result is validated for its environment and returned by Get afterwards.
+func Load(args []string) (*Config, error) {
+	flags := map[string]string{}
+	if args != nil {
+		fs := flag.NewFlagSet("config", flag.ContinueOnError)
+		configFile := fs.String("config", "", "configuration file in KEY=VALUE format (default .env when present)")
+		env := fs.String("env", "", "environment: development, staging or production")
+		port := fs.String("port", "", "HTTP port")
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		for key, value := range map[string]string{"CONFIG_FILE": *configFile, "APP_ENV": *env, "PORT": *port} {
+			if value != "" {
+				flags[key] = value
+			}
+		}
+	}
+
+	values, err := readFile(flags)
+	if err != nil {
+		return nil, err
+	}
+	values = environ(values)
+	for key, value := range flags {
+		values[key] = value
+	}
+
+	cfg, problems := parse(values)
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+
+	mu.Lock()
+	current = cfg
+	mu.Unlock()
+	return cfg, nil
+}
+
+// readFile reads the config file named by a flag or CONFIG_FILE. The default
+// .env file is optional so containers can be configured with the environment only.
+func readFile(flags map[string]string) (map[string]string, error) {
+	path, explicit := flags["CONFIG_FILE"]
+	if !explicit {
+		path, explicit = os.LookupEnv("CONFIG_FILE")
+	}
+	if !explicit || path == "" {
+		path = defaultFile
+	}
+
+	values, err := godotenv.Read(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// environ overlays the process environment on values
+func environ(values map[string]string) map[string]string {
+	if values == nil {
+		values = map[string]string{}
+	}
+	for _, entry := range os.Environ() {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// reader converts raw values into typed settings, collecting the invalid ones
+type reader struct {
+	values   map[string]string
+	problems []string
+}
+
+func (r *reader) string(key, def string) string {
+	if value := strings.TrimSpace(r.values[key]); value != "" {
+		return value
+	}
+	return def
+}
+
+func (r *reader) int(key string, def, min int) int {
+	value := strings.TrimSpace(r.values[key])
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < min {
+		r.problems = append(r.problems, fmt.Sprintf("%s must be an integer of at least %d, got %q", key, min, value))
+		return def
+	}
+	return parsed
+}
+
+func (r *reader) bool(key string, def bool) bool {
+	value := strings.TrimSpace(r.values[key])
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s must be true or false, got %q", key, value))
+		return def
+	}
+	return parsed
+}
+
+// duration reads a Go duration such as "24h" or "90m"
+func (r *reader) duration(key string, def time.Duration) time.Duration {
+	value := strings.TrimSpace(r.values[key])
+	if value == "" {
+		return def
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		r.problems = append(r.problems, fmt.Sprintf("%s must be a positive duration such as 24h, got %q", key, value))
+		return def
+	}
+	return parsed
+}
+
+// float reads a number between min and max
+func (r *reader) float(key string, def, min, max float64) float64 {
+	value := strings.TrimSpace(r.values[key])
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < min || parsed > max {
+		r.problems = append(r.problems, fmt.Sprintf("%s must be a number between %g and %g, got %q", key, min, max, value))
+		return def
+	}
+	return parsed
+}
+
+// pairs reads comma separated key=value pairs such as "api-key=abc,team=core"
+func (r *reader) pairs(key string) map[string]string {
+	value := strings.TrimSpace(r.values[key])
+	if value == "" {
+		return nil
+	}
+	pairs := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		name, val, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			r.problems = append(r.problems, fmt.Sprintf("%s must be comma separated key=value pairs", key))
+			return nil
+		}
+		pairs[strings.TrimSpace(name)] = strings.TrimSpace(val)
+	}
+	return pairs
+}
+
+// count reads a whole number of units, for settings such as ACCOUNT_DELETION_GRACE_DAYS
+func (r *reader) count(key string, def time.Duration, unit time.Duration, min int) time.Duration {
+	if strings.TrimSpace(r.values[key]) == "" {
+		return def
+	}
+	return time.Duration(r.int(key, int(def/unit), min)) * unit
+}
+
+// parse builds a Config from raw values, falling back to defaults for invalid ones
+func parse(values map[string]string) (*Config, []string) {
+	r := &reader{values: values}
+
+	cfg := &Config{
+		Env: r.string("APP_ENV", EnvDevelopment),
+		HTTP: HTTPConfig{
+			Port:    r.string("PORT", "8080"),
+			BaseURL: strings.TrimRight(r.string("APP_BASE_URL", "http://localhost:8080"), "/"),
+
+			ReadTimeout:       r.duration("HTTP_READ_TIMEOUT", 15*time.Second),
+			ReadHeaderTimeout: r.duration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+			WriteTimeout:      r.duration("HTTP_WRITE_TIMEOUT", 60*time.Second),
+			IdleTimeout:       r.duration("HTTP_IDLE_TIMEOUT", 120*time.Second),
+			ShutdownTimeout:   r.duration("SHUTDOWN_TIMEOUT", 30*time.Second),
+
+			ShutdownDrainDelay: r.count("SHUTDOWN_DRAIN_DELAY_SECONDS", 0, time.Second, 0),
+		},
+		Database: DatabaseConfig{
+			URL:             r.string("DATABASE_URL", ""),
+			MaxOpenConns:    r.int("DB_MAX_OPEN_CONNS", 100, 1),
+			MaxIdleConns:    r.int("DB_MAX_IDLE_CONNS", 10, 0),
+			ConnMaxLifetime: r.duration("DB_CONN_MAX_LIFETIME", time.Hour),
+			MigrateOnStart:  r.bool("MIGRATE_ON_START", false),
+		},
+		Log: LogConfig{
+			Level:              strings.ToLower(r.string("LOG_LEVEL", "info")),
+			Format:             strings.ToLower(r.string("LOG_FORMAT", "")),
+			SlowQueryThreshold: r.duration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		},
+		Metrics: MetricsConfig{
+			Enabled: r.bool("METRICS_ENABLED", true),
+			Token:   r.string("METRICS_TOKEN", ""),
+		},
+		Tracing: TracingConfig{
+			Exporter:    strings.ToLower(r.string("OTEL_TRACES_EXPORTER", "none")),
+			Endpoint:    otlpTracesEndpoint(r.string("OTEL_EXPORTER_OTLP_ENDPOINT", "")),
+			Headers:     r.pairs("OTEL_EXPORTER_OTLP_HEADERS"),
+			ServiceName: r.string("OTEL_SERVICE_NAME", "ai-backend"),
+			SampleRatio: r.float("OTEL_TRACES_SAMPLER_ARG", 1, 0, 1),
+		},
+		Auth: AuthConfig{
+			JWTSecret:        r.string("JWT_SECRET", ""),
+			TokenTTL:         r.duration("JWT_TTL", 24*time.Hour),
+			SessionTTL:       r.duration("SESSION_TTL", 24*time.Hour),
+			PasswordResetTTL: r.duration("PASSWORD_RESET_TTL", time.Hour),
+			ReactivationTTL:  r.duration("REACTIVATION_TTL", 24*time.Hour),
+		},
+		Email: EmailConfig{
+			Driver:       strings.ToLower(r.string("EMAIL_DRIVER", "")),
+			From:         r.string("EMAIL_FROM", "Answer App <onboarding@resend.dev>"),
+			ResendAPIKey: r.string("RESEND_API_KEY", ""),
+			SMTP: SMTPConfig{
+				Host:     r.string("SMTP_HOST", "localhost"),
+				Port:     r.int("SMTP_PORT", 1025, 1),
+				Username: r.string("SMTP_USERNAME", ""),
+				Password: r.string("SMTP_PASSWORD", ""),
+			},
+			Dir: r.string("EMAIL_DIR", ""),
+		},
+		Outbox: OutboxConfig{
+			Workers:      r.int("OUTBOX_WORKERS", 4, 1),
+			PollInterval: r.duration("OUTBOX_POLL_INTERVAL", 5*time.Second),
+			MaxAttempts:  r.int("OUTBOX_MAX_ATTEMPTS", 8, 1),
+			BackoffBase:  r.duration("OUTBOX_BACKOFF_BASE", 30*time.Second),
+			BackoffMax:   r.duration("OUTBOX_BACKOFF_MAX", time.Hour),
+			Retention:    r.count("OUTBOX_RETENTION_DAYS", 7*24*time.Hour, 24*time.Hour, 1),
+		},
+		Notification: NotificationConfig{
+			DigestInterval: r.duration("NOTIFICATION_DIGEST_INTERVAL", 24*time.Hour),
+		},
+		Realtime: RealtimeConfig{
+			HeartbeatInterval: r.duration("REALTIME_HEARTBEAT_INTERVAL", 25*time.Second),
+			MaxSubscriptions:  r.int("REALTIME_MAX_SUBSCRIPTIONS", 50, 1),
+			BufferSize:        r.int("REALTIME_BUFFER_SIZE", 64, 1),
+		},
+		Webhook: WebhookConfig{
+			Workers:      r.int("WEBHOOK_WORKERS", 4, 1),
+			PollInterval: r.duration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+			Timeout:      r.duration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts:  r.int("WEBHOOK_MAX_ATTEMPTS", 8, 1),
+			BackoffBase:  r.duration("WEBHOOK_BACKOFF_BASE", 30*time.Second),
+			BackoffMax:   r.duration("WEBHOOK_BACKOFF_MAX", time.Hour),
+			Retention:    r.count("WEBHOOK_RETENTION_DAYS", 30*24*time.Hour, 24*time.Hour, 1),
+		},
+		Storage: StorageConfig{
+			Dir:               r.string("STORAGE_DIR", "storage"),
+			DataExportLinkTTL: r.count("DATA_EXPORT_LINK_TTL_HOURS", 48*time.Hour, time.Hour, 1),
+			DataExportTimeout: r.count("DATA_EXPORT_TIMEOUT_MINUTES", 30*time.Minute, time.Minute, 1),
+		},
+		Moderation: ModerationConfig{
+			FlagAutoHideThreshold: r.int("FLAG_AUTO_HIDE_THRESHOLD", 3, 1),
+			DisposableDomainsFile: r.string("DISPOSABLE_DOMAINS_FILE", ""),
+			AccountDeletionGrace:  r.count("ACCOUNT_DELETION_GRACE_DAYS", 30*24*time.Hour, 24*time.Hour, 0),
+			StatsCacheTTL:         r.count("STATS_CACHE_TTL_SECONDS", 5*time.Minute, time.Second, 0),
+		},
+		Seed: SeedConfig{
+			Username: r.string("DEFAULT_USERNAME", ""),
+			Email:    r.string("DEFAULT_EMAIL", ""),
+			Password: r.string("DEFAULT_PASSWORD", ""),
+			Role:     r.string("DEFAULT_ROLES", "SUPER_ADMIN"),
+		},
+	}
+
+	switch strings.ToLower(cfg.Env) {
+	case "dev", EnvDevelopment:
+		cfg.Env = EnvDevelopment
+	case "stage", EnvStaging:
+		cfg.Env = EnvStaging
+	case "prod", EnvProduction:
+		cfg.Env = EnvProduction
+	default:
+		r.problems = append(r.problems, fmt.Sprintf("APP_ENV must be development, staging or production, got %q", cfg.Env))
+		cfg.Env = EnvDevelopment
+	}
+
+	if cfg.Tracing.Exporter == "console" {
+		cfg.Tracing.Exporter = "stdout"
+	}
+
+	// Humans read development logs, log collectors read the others
+	if cfg.Log.Format == "" {
+		cfg.Log.Format = "json"
+		if cfg.Env == EnvDevelopment {
+			cfg.Log.Format = "text"
+		}
+	}
+
+	// Without an explicit driver, development writes emails to disk unless a
+	// Resend key is configured; the other environments use Resend
+	if cfg.Email.Driver == "" {
+		cfg.Email.Driver = EmailDriverResend
+		if cfg.Env == EnvDevelopment && cfg.Email.ResendAPIKey == "" {
+			cfg.Email.Driver = EmailDriverFile
+		}
+	}
+	if cfg.Email.Dir == "" {
+		cfg.Email.Dir = filepath.Join(cfg.Storage.Dir, "emails")
+	}
+
+	// Development keeps working without a secret; other environments are rejected by validate
+	if cfg.Auth.JWTSecret == "" && cfg.Env == EnvDevelopment {
+		cfg.Auth.JWTSecret = devJWTSecret
+	}
+
+	return cfg, r.problems
+}
+
+// validate checks the settings that are required, or unsafe, for the environment
+func (c *Config) validate() []string {
+	var problems []string
+
+	if c.Database.URL == "" {
+		problems = append(problems, "DATABASE_URL is required")
+	}
+	if port, err := strconv.Atoi(c.HTTP.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("PORT must be a TCP port, got %q", c.HTTP.Port))
+	}
+	if _, err := url.ParseRequestURI(c.HTTP.BaseURL); err != nil {
+		problems = append(problems, fmt.Sprintf("APP_BASE_URL must be an absolute URL, got %q", c.HTTP.BaseURL))
+	}
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL must be debug, info, warn or error, got %q", c.Log.Level))
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		problems = append(problems, fmt.Sprintf("LOG_FORMAT must be text or json, got %q", c.Log.Format))
+	}
+	switch c.Tracing.Exporter {
+	case "otlp", "stdout", "none":
+	default:
+		problems = append(problems, fmt.Sprintf("OTEL_TRACES_EXPORTER must be otlp, stdout or none, got %q", c.Tracing.Exporter))
+	}
+	switch c.Email.Driver {
+	case EmailDriverResend:
+		if c.Email.ResendAPIKey == "" {
+			problems = append(problems, "RESEND_API_KEY is required by the resend email driver")
+		}
+	case EmailDriverSMTP:
+		if c.Email.SMTP.Host == "" {
+			problems = append(problems, "SMTP_HOST is required by the smtp email driver")
+		}
+	case EmailDriverFile, EmailDriverMemory:
+	default:
+		problems = append(problems, fmt.Sprintf("EMAIL_DRIVER must be resend, smtp, file or memory, got %q", c.Email.Driver))
+	}
+	if c.Outbox.PollInterval <= 0 || c.Outbox.BackoffBase <= 0 {
+		problems = append(problems, "OUTBOX_POLL_INTERVAL and OUTBOX_BACKOFF_BASE must be positive")
+	}
+	if c.Outbox.BackoffMax < c.Outbox.BackoffBase {
+		problems = append(problems, "OUTBOX_BACKOFF_MAX must not be less than OUTBOX_BACKOFF_BASE")
+	}
+	if c.Notification.DigestInterval <= 0 {
+		problems = append(problems, "NOTIFICATION_DIGEST_INTERVAL must be positive")
+	}
+	if c.Realtime.HeartbeatInterval <= 0 {
+		problems = append(problems, "REALTIME_HEARTBEAT_INTERVAL must be positive")
+	}
+	if c.Webhook.PollInterval <= 0 || c.Webhook.BackoffBase <= 0 {
+		problems = append(problems, "WEBHOOK_POLL_INTERVAL and WEBHOOK_BACKOFF_BASE must be positive")
+	}
+	if c.Webhook.BackoffMax < c.Webhook.BackoffBase {
+		problems = append(problems, "WEBHOOK_BACKOFF_MAX must not be less than WEBHOOK_BACKOFF_BASE")
+	}
+	// Deliveries are claimed for two minutes; a longer timeout could deliver twice
+	if c.Webhook.Timeout <= 0 || c.Webhook.Timeout > time.Minute {
+		problems = append(problems, "WEBHOOK_TIMEOUT must be positive and at most 1m")
+	}
+	if c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		problems = append(problems, "DB_MAX_IDLE_CONNS must not exceed DB_MAX_OPEN_CONNS")
+	}
+
+	seedSet := 0
+	for _, value := range []string{c.Seed.Username, c.Seed.Email, c.Seed.Password} {
+		if value != "" {
+			seedSet++
+		}
+	}
+	if seedSet != 0 && seedSet != 3 {
+		problems = append(problems, "DEFAULT_USERNAME, DEFAULT_EMAIL and DEFAULT_PASSWORD must be set together")
+	}
+
+	if c.Env == EnvDevelopment {
+		return problems
+	}
+
+	// Staging and production send real emails and sign real tokens
+	if c.Auth.JWTSecret == "" || c.Auth.JWTSecret == devJWTSecret {
+		problems = append(problems, "JWT_SECRET is required outside development")
+	} else if len(c.Auth.JWTSecret) < 32 {
+		problems = append(problems, "JWT_SECRET must be at least 32 characters outside development")
+	}
+	if c.Email.Driver == EmailDriverFile || c.Email.Driver == EmailDriverMemory {
+		problems = append(problems, "EMAIL_DRIVER must be resend or smtp outside development")
+	}
+	if strings.Contains(c.HTTP.BaseURL, "localhost") {
+		problems = append(problems, "APP_BASE_URL must be the public URL outside development")
+	}
+	if c.Seed.Password != "" && len(c.Seed.Password) < 12 {
+		problems = append(problems, "DEFAULT_PASSWORD must be at least 12 characters outside development")
+	}
+	if c.IsProduction() && !strings.HasPrefix(c.HTTP.BaseURL, "https://") {
+		problems = append(problems, "APP_BASE_URL must use https in production")
+	}
+
+	return problems
+}
+
+// redact hides a secret, keeping only whether it is set
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+// redactHeaders lists header names without their values, which usually hold credentials
+func redactHeaders(headers map[string]string) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name+"="+redact(headers[name]))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// otlpTracesEndpoint turns an OTLP base endpoint into the traces URL, like the
+// OpenTelemetry SDKs do for OTEL_EXPORTER_OTLP_ENDPOINT
+func otlpTracesEndpoint(endpoint string) string {
+	if endpoint == "" {
+		return ""
+	}
+	return strings.TrimRight(endpoint, "/") + "/v1/traces"
+}
+
+// redactURL hides the password of a connection URL
+func redactURL(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.User == nil {
+		return value
+	}
+	if _, ok := parsed.User.Password(); ok {
+		parsed.User = url.UserPassword(parsed.User.Username(), "xxxxx")
+	}
+	return parsed.String()
+}
+
+// Redacted returns the effective configuration by setting name, with secrets hidden
+func (c *Config) Redacted() []slog.Attr {
+	entries := []struct {
+		key   string
+		value interface{}
+	}{
+		{"APP_ENV", c.Env},
+		{"PORT", c.HTTP.Port},
+		{"APP_BASE_URL", c.HTTP.BaseURL},
+		{"HTTP_READ_TIMEOUT", c.HTTP.ReadTimeout},
+		{"HTTP_READ_HEADER_TIMEOUT", c.HTTP.ReadHeaderTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.HTTP.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.HTTP.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", c.HTTP.ShutdownTimeout},
+		{"SHUTDOWN_DRAIN_DELAY_SECONDS", int(c.HTTP.ShutdownDrainDelay / time.Second)},
+		{"DATABASE_URL", redactURL(c.Database.URL)},
+		{"DB_MAX_OPEN_CONNS", c.Database.MaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", c.Database.MaxIdleConns},
+		{"DB_CONN_MAX_LIFETIME", c.Database.ConnMaxLifetime},
+		{"MIGRATE_ON_START", c.Database.MigrateOnStart},
+		{"DB_SLOW_QUERY_THRESHOLD", c.Log.SlowQueryThreshold},
+		{"LOG_LEVEL", c.Log.Level},
+		{"LOG_FORMAT", c.Log.Format},
+		{"METRICS_ENABLED", c.Metrics.Enabled},
+		{"METRICS_TOKEN", redact(c.Metrics.Token)},
+		{"OTEL_TRACES_EXPORTER", c.Tracing.Exporter},
+		{"OTEL_EXPORTER_OTLP_ENDPOINT", c.Tracing.Endpoint},
+		{"OTEL_EXPORTER_OTLP_HEADERS", redactHeaders(c.Tracing.Headers)},
+		{"OTEL_SERVICE_NAME", c.Tracing.ServiceName},
+		{"OTEL_TRACES_SAMPLER_ARG", c.Tracing.SampleRatio},
+		{"JWT_SECRET", redact(c.Auth.JWTSecret)},
+		{"JWT_TTL", c.Auth.TokenTTL},
+		{"SESSION_TTL", c.Auth.SessionTTL},
+		{"PASSWORD_RESET_TTL", c.Auth.PasswordResetTTL},
+		{"REACTIVATION_TTL", c.Auth.ReactivationTTL},
+		{"EMAIL_DRIVER", c.Email.Driver},
+		{"EMAIL_FROM", c.Email.From},
+		{"RESEND_API_KEY", redact(c.Email.ResendAPIKey)},
+		{"SMTP_HOST", c.Email.SMTP.Host},
+		{"SMTP_PORT", c.Email.SMTP.Port},
+		{"SMTP_USERNAME", c.Email.SMTP.Username},
+		{"SMTP_PASSWORD", redact(c.Email.SMTP.Password)},
+		{"EMAIL_DIR", c.Email.Dir},
+		{"OUTBOX_WORKERS", c.Outbox.Workers},
+		{"OUTBOX_POLL_INTERVAL", c.Outbox.PollInterval},
+		{"OUTBOX_MAX_ATTEMPTS", c.Outbox.MaxAttempts},
+		{"OUTBOX_BACKOFF_BASE", c.Outbox.BackoffBase},
+		{"OUTBOX_BACKOFF_MAX", c.Outbox.BackoffMax},
+		{"OUTBOX_RETENTION_DAYS", int(c.Outbox.Retention / (24 * time.Hour))},
+		{"NOTIFICATION_DIGEST_INTERVAL", c.Notification.DigestInterval},
+		{"REALTIME_HEARTBEAT_INTERVAL", c.Realtime.HeartbeatInterval},
+		{"REALTIME_MAX_SUBSCRIPTIONS", c.Realtime.MaxSubscriptions},
+		{"REALTIME_BUFFER_SIZE", c.Realtime.BufferSize},
+		{"WEBHOOK_WORKERS", c.Webhook.Workers},
+		{"WEBHOOK_POLL_INTERVAL", c.Webhook.PollInterval},
+		{"WEBHOOK_TIMEOUT", c.Webhook.Timeout},
+		{"WEBHOOK_MAX_ATTEMPTS", c.Webhook.MaxAttempts},
+		{"WEBHOOK_BACKOFF_BASE", c.Webhook.BackoffBase},
+		{"WEBHOOK_BACKOFF_MAX", c.Webhook.BackoffMax},
+		{"WEBHOOK_RETENTION_DAYS", int(c.Webhook.Retention / (24 * time.Hour))},
+		{"STORAGE_DIR", c.Storage.Dir},
+		{"DATA_EXPORT_LINK_TTL_HOURS", int(c.Storage.DataExportLinkTTL / time.Hour)},
+		{"DATA_EXPORT_TIMEOUT_MINUTES", int(c.Storage.DataExportTimeout / time.Minute)},
+		{"FLAG_AUTO_HIDE_THRESHOLD", c.Moderation.FlagAutoHideThreshold},
+		{"DISPOSABLE_DOMAINS_FILE", c.Moderation.DisposableDomainsFile},
+		{"ACCOUNT_DELETION_GRACE_DAYS", int(c.Moderation.AccountDeletionGrace / (24 * time.Hour))},
+		{"STATS_CACHE_TTL_SECONDS", int(c.Moderation.StatsCacheTTL / time.Second)},
+		{"DEFAULT_USERNAME", c.Seed.Username},
+		{"DEFAULT_EMAIL", c.Seed.Email},
+		{"DEFAULT_PASSWORD", redact(c.Seed.Password)},
+		{"DEFAULT_ROLES", c.Seed.Role},
+	}
+
+	attrs := make([]slog.Attr, 0, len(entries))
+	for _, entry := range entries {
+		attrs = append(attrs, slog.String(entry.key, fmt.Sprint(entry.value)))
+	}
+	return attrs
+}
+
+// LogEffective logs the redacted effective configuration
+func (c *Config) LogEffective() {
+	slog.LogAttrs(context.Background(), slog.LevelInfo, "Effective configuration", c.Redacted()...)
+}
@@ -2,12 +2,14 @@ package database
 
 import (
 	"log"
-	"os"
-	"time"
+	"log/slog"
+
+	"ai-backend/internal/config"
+	"ai-backend/internal/logging"
+	"ai-backend/internal/tracing"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
@@ -15,15 +17,22 @@ var DB *gorm.DB
 func InitDB() {
 	var err error
 	
-	config := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+	cfg := config.Get().Database
+
+	gormConfig := &gorm.Config{
+		Logger: logging.NewGormLogger(config.Get().Log.SlowQueryThreshold),
 	}
 
-	DB, err = gorm.Open(postgres.Open(os.Getenv("DATABASE_URL")), config)
+	DB, err = gorm.Open(postgres.Open(cfg.URL), gormConfig)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	// Trace queries that run with a request context
+	if err := DB.Use(tracing.GormPlugin{}); err != nil {
+		log.Fatal("Failed to register database tracing:", err)
+	}
+
 	// Get generic database object sql.DB to use its functions
 	sqlDB, err := DB.DB()
 	if err != nil {
@@ -31,13 +40,25 @@ func InitDB() {
 	}
 
 	// SetMaxIdleConns sets the maximum number of connections in the idle connection pool
-	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
 
 	// SetMaxOpenConns sets the maximum number of open connections to the database
-	sqlDB.SetMaxOpenConns(100)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
 
 	// SetConnMaxLifetime sets the maximum amount of time a connection may be reused
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	slog.Info("Database connection established")
+}
 
-	log.Println("Database connection established")
+// Close closes the connection pool. It is the last step of shutdown.
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 } 
\ No newline at end of file
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation is the Postgres error code of a unique constraint violation
+const uniqueViolation = "23505"
+
+// IsUniqueViolation reports whether err was caused by a unique index rejecting a
+// row, such as a concurrent request inserting the same row first
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+// ViolatedConstraint returns the name of the constraint or unique index that
+// rejected a row, or "" when err was not caused by one
+func ViolatedConstraint(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+	return ""
+}
@@ -1,9 +1,9 @@
 package database
 
 import (
+	"ai-backend/internal/config"
 	"ai-backend/internal/models"
-	"log"
-	"os"
+	"log/slog"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -11,26 +11,32 @@ import (
 
 // SeedDefaultUser creates a default admin user if it doesn't exist
 func SeedDefaultUser() error {
+	seed := config.Get().Seed
+	if seed.Email == "" {
+		slog.Info("No default user configured, skipping seed")
+		return nil
+	}
+
 	var user models.User
 
 	// Check if user already exists
-	result := DB.Where("email = ?", os.Getenv("DEFAULT_EMAIL")).First(&user)
+	result := DB.Where("email = ?", seed.Email).First(&user)
 	if result.Error == nil {
-		log.Println("Default user already exists")
+		slog.Info("Default user already exists")
 		return nil
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(os.Getenv("DEFAULT_PASSWORD")), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(seed.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 
 	// Get current time for email verification
 	now := time.Now()
-	username := os.Getenv("DEFAULT_USERNAME")
-	email := os.Getenv("DEFAULT_EMAIL")
-	role := models.UserRole(os.Getenv("DEFAULT_ROLES"))
+	username := seed.Username
+	email := seed.Email
+	role := models.UserRole(seed.Role)
 	password := string(hashedPassword)
 
 	// Create default user
@@ -49,6 +55,6 @@ func SeedDefaultUser() error {
 		return result.Error
 	}
 
-	log.Printf("Default user created with email: %s and role: %s", email, role)
+	slog.Info("Default user created", "user_id", defaultUser.ID, "role", role)
 	return nil
 } 
\ No newline at end of file
@@ -0,0 +1,397 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"ai-backend/internal/background"
+	"ai-backend/internal/config"
+	"ai-backend/internal/metrics"
+	"ai-backend/internal/models"
+	"ai-backend/internal/outbox"
+	"ai-backend/internal/repository"
+	"ai-backend/pkg/email"
+	"ai-backend/pkg/storage"
+)
+
+// object is a JSON object written to the archive
+type object = map[string]interface{}
+
+// LinkTTL returns how long a download link stays valid
+func LinkTTL() time.Duration {
+	return config.Get().Storage.DataExportLinkTTL
+}
+
+// HashToken returns the hex encoded sha256 of a download token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// downloadLink builds the public download URL for a token
+func downloadLink(token string) string {
+	return fmt.Sprintf("%s/api/users/data-export/download?token=%s", config.Get().HTTP.BaseURL, token)
+}
+
+// Build writes a ZIP archive with the user's personal data to w.
+// Secrets such as password hashes, OAuth tokens and session tokens are left out.
+func Build(db *gorm.DB, userID uint, w io.Writer) error {
+	var user models.User
+	if err := db.Unscoped().First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	var accounts []models.Account
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&accounts).Error; err != nil {
+		return err
+	}
+	var sessions []models.Session
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&sessions).Error; err != nil {
+		return err
+	}
+	var questions []models.Question
+	if err := db.Unscoped().Where("user_id = ?", userID).Order("id").Find(&questions).Error; err != nil {
+		return err
+	}
+	var answers []models.Answer
+	if err := db.Unscoped().Where("user_id = ?", userID).Order("id").Find(&answers).Error; err != nil {
+		return err
+	}
+	var votes []models.Vote
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&votes).Error; err != nil {
+		return err
+	}
+	var freezes []models.FreezeHistory
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&freezes).Error; err != nil {
+		return err
+	}
+	var bans []models.BanHistory
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&bans).Error; err != nil {
+		return err
+	}
+
+	files := map[string]interface{}{
+		"export.json": object{
+			"user_id":      user.ID,
+			"generated_at": time.Now(),
+		},
+		"profile.json": object{
+			"id":             user.ID,
+			"name":           user.Name,
+			"username":       user.Username,
+			"email":          user.Email,
+			"email_verified": user.EmailVerified,
+			"image":          user.Image,
+			"role":           user.Role,
+			"status":         user.Status,
+			"created_at":     user.CreatedAt,
+			"updated_at":     user.UpdatedAt,
+		},
+	}
+
+	accountRows := make([]object, len(accounts))
+	for i, a := range accounts {
+		accountRows[i] = object{
+			"id":                  a.ID,
+			"type":                a.Type,
+			"provider":            a.Provider,
+			"provider_account_id": a.ProviderAccountID,
+			"scope":               a.Scope,
+			"expires_at":          a.ExpiresAt,
+			"created_at":          a.CreatedAt,
+		}
+	}
+	files["accounts.json"] = accountRows
+
+	sessionRows := make([]object, len(sessions))
+	for i, s := range sessions {
+		sessionRows[i] = object{
+			"id":         s.ID,
+			"ip_address": s.IPAddress,
+			"user_agent": s.UserAgent,
+			"expires":    s.Expires,
+			"created_at": s.CreatedAt,
+		}
+	}
+	files["sessions.json"] = sessionRows
+
+	questionRows := make([]object, len(questions))
+	for i, q := range questions {
+		questionRows[i] = object{
+			"id":          q.ID,
+			"title":       q.Title,
+			"content":     q.Content,
+			"view_count":  q.ViewCount,
+			"vote_count":  q.VoteCount,
+			"is_resolved": q.IsResolved,
+			"created_at":  q.CreatedAt,
+			"updated_at":  q.UpdatedAt,
+			"deleted_at":  q.DeletedAt,
+		}
+	}
+	files["questions.json"] = questionRows
+
+	answerRows := make([]object, len(answers))
+	for i, a := range answers {
+		answerRows[i] = object{
+			"id":          a.ID,
+			"question_id": a.QuestionID,
+			"content":     a.Content,
+			"vote_count":  a.VoteCount,
+			"is_accepted": a.IsAccepted,
+			"created_at":  a.CreatedAt,
+			"updated_at":  a.UpdatedAt,
+			"deleted_at":  a.DeletedAt,
+		}
+	}
+	files["answers.json"] = answerRows
+
+	voteRows := make([]object, len(votes))
+	for i, v := range votes {
+		voteRows[i] = object{
+			"id":          v.ID,
+			"question_id": v.QuestionID,
+			"answer_id":   v.AnswerID,
+			"vote_type":   v.VoteType,
+			"created_at":  v.CreatedAt,
+		}
+	}
+	files["votes.json"] = voteRows
+
+	freezeRows := make([]object, len(freezes))
+	for i, f := range freezes {
+		freezeRows[i] = object{
+			"id":            f.ID,
+			"reason":        f.Reason,
+			"duration_days": f.Duration,
+			"start_date":    f.StartDate,
+			"end_date":      f.EndDate,
+			"is_active":     f.IsActive,
+			"unfrozen_at":   f.UnfrozenAt,
+			"created_at":    f.CreatedAt,
+		}
+	}
+	files["freeze_history.json"] = freezeRows
+
+	// Moderator identities are other people's data and are left out
+	banRows := make([]object, len(bans))
+	for i, b := range bans {
+		banRows[i] = object{
+			"id":            b.ID,
+			"reason":        b.Reason,
+			"duration":      b.Duration,
+			"duration_days": b.DurationDays,
+			"start_date":    b.StartDate,
+			"end_date":      b.EndDate,
+			"is_active":     b.IsActive,
+			"unbanned_at":   b.UnbannedAt,
+			"created_at":    b.CreatedAt,
+		}
+	}
+	files["ban_history.json"] = banRows
+
+	archive := zip.NewWriter(w)
+	for name, content := range files {
+		data, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			return err
+		}
+		file, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// Exporter starts export requests in the background
+type Exporter struct {
+	db      *gorm.DB
+	store   storage.Storage
+	metrics *metrics.Metrics
+}
+
+func NewExporter(db *gorm.DB, store storage.Storage, m *metrics.Metrics) *Exporter {
+	return &Exporter{db: db, store: store, metrics: m}
+}
+
+// Start processes the export request in the background. Shutdown waits for it.
+// ctx carries the locale of the email; it is not cancelled with the request.
+func (e *Exporter) Start(ctx context.Context, exportID uint) {
+	background.Go("data_export", e.metrics, func(context.Context) {
+		Process(ctx, e.db, e.store, exportID)
+	})
+}
+
+// Process builds and stores the archive for an export request, then queues an
+// email with the download link. ctx carries the locale of the email.
+func Process(ctx context.Context, db *gorm.DB, store storage.Storage, exportID uint) {
+	var export models.DataExport
+	if err := db.Preload("User").First(&export, exportID).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to load data export", "export_id", exportID, "error", err)
+		return
+	}
+
+	fail := func(reason string, err error) {
+		slog.ErrorContext(ctx, "Data export failed", "export_id", exportID, "reason", reason, "error", err)
+		if err := db.Model(&export).Updates(map[string]interface{}{
+			"status": models.DataExportFailed,
+			"error":  reason,
+		}).Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to mark data export as failed", "export_id", exportID, "error", err)
+		}
+	}
+
+	started := db.Model(&export).Where("status = ?", models.DataExportPending).Update("status", models.DataExportProcessing)
+	if started.Error != nil {
+		slog.ErrorContext(ctx, "Failed to mark data export as processing", "export_id", exportID, "error", started.Error)
+		return
+	}
+	if started.RowsAffected == 0 {
+		slog.InfoContext(ctx, "Data export is no longer pending, skipping it", "export_id", exportID)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := Build(db, export.UserID, &buf); err != nil {
+		fail("failed to build archive", err)
+		return
+	}
+
+	key := fmt.Sprintf("data-exports/%d/%d-%d.zip", export.UserID, export.ID, time.Now().Unix())
+	if err := store.Save(key, &buf); err != nil {
+		fail("failed to store archive", err)
+		return
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		store.Delete(key)
+		fail("failed to generate download token", err)
+		return
+	}
+	token := hex.EncodeToString(tokenBytes)
+	tokenHash := HashToken(token)
+	now := time.Now()
+	expiresAt := now.Add(LinkTTL())
+
+	// Without the email the user cannot reach the link, so the export is only
+	// marked ready together with queuing it
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&export).Where("status = ?", models.DataExportProcessing).Updates(map[string]interface{}{
+			"status":       models.DataExportReady,
+			"storage_key":  key,
+			"token_hash":   tokenHash,
+			"expires_at":   expiresAt,
+			"completed_at": now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		// FailStale gave up on the export while it was being built
+		if result.RowsAffected == 0 {
+			return errTimedOut
+		}
+		if export.User.Email == nil {
+			slog.WarnContext(ctx, "Data export is ready but the user has no email", "export_id", exportID, "user_id", export.UserID)
+			return nil
+		}
+		return outbox.Enqueue(ctx, repository.NewGormStore(tx), *export.User.Email, email.TemplateDataExport, email.DataExportData{
+			Link:      downloadLink(token),
+			ExpiresAt: expiresAt,
+		})
+	})
+	if errors.Is(err, errTimedOut) {
+		store.Delete(key)
+		slog.WarnContext(ctx, "Data export finished after it timed out, discarding the archive", "export_id", exportID)
+		return
+	}
+	if err != nil {
+		store.Delete(key)
+		fail("failed to save export", err)
+		return
+	}
+	outbox.Wake()
+
+	slog.InfoContext(ctx, "Data export ready", "export_id", exportID, "user_id", export.UserID)
+}
+
+// errTimedOut is returned when an export was marked as failed by FailStale before it finished
+var errTimedOut = errors.New("data export timed out")
+
+// FailStale marks exports that have been pending or processing since before
+// cutoff as failed, so a crashed or stuck export does not block new requests.
+// It returns how many were marked.
+func FailStale(db *gorm.DB, cutoff time.Time) (int64, error) {
+	result := db.Model(&models.DataExport{}).
+		Where("status IN ? AND updated_at < ?", []models.DataExportStatus{models.DataExportPending, models.DataExportProcessing}, cutoff).
+		Updates(map[string]interface{}{
+			"status": models.DataExportFailed,
+			"error":  "timed out",
+		})
+	return result.RowsAffected, result.Error
+}
+
+// PurgeExpired deletes archives whose download links have expired and returns how many were removed
+func PurgeExpired(db *gorm.DB, store storage.Storage) (int, error) {
+	var exports []models.DataExport
+	if err := db.Where("status = ? AND expires_at <= ?", models.DataExportReady, time.Now()).
+		Find(&exports).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, export := range exports {
+		if export.StorageKey != nil {
+			if err := store.Delete(*export.StorageKey); err != nil {
+				slog.Error("Failed to delete data export archive", "export_id", export.ID, "error", err)
+				continue
+			}
+		}
+		if err := db.Model(&export).Updates(map[string]interface{}{
+			"status":      models.DataExportExpired,
+			"storage_key": nil,
+			"token_hash":  nil,
+		}).Error; err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// WorkerName identifies the cleanup worker in metrics and health checks
+const WorkerName = "data_export_cleanup"
+
+// StartWorker deletes expired archives and fails exports stuck for longer than
+// timeout every interval in the background until shutdown
+func StartWorker(db *gorm.DB, store storage.Storage, interval, timeout time.Duration, m *metrics.Metrics) {
+	background.Every(WorkerName, interval, m, func(ctx context.Context) {
+		if purged, err := PurgeExpired(db, store); err != nil {
+			slog.ErrorContext(ctx, "Failed to purge expired data exports", "error", err)
+		} else if purged > 0 {
+			slog.InfoContext(ctx, "Purged expired data exports", "count", purged)
+		}
+
+		if failed, err := FailStale(db, time.Now().Add(-timeout)); err != nil {
+			slog.ErrorContext(ctx, "Failed to time out stale data exports", "error", err)
+		} else if failed > 0 {
+			slog.InfoContext(ctx, "Marked stale data exports as failed", "count", failed)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+)
+
+// Event types
+const (
+	TypeUserRegistered  = "user.registered"
+	TypeUserBanned      = "user.banned"
+	TypeUserUnbanned    = "user.unbanned"
+	TypeUserRoleChanged = "user.role_changed"
+)
+
+// Types lists every event type emitted by the application
+var Types = []string{
+	TypeUserRegistered,
+	TypeUserBanned,
+	TypeUserUnbanned,
+	TypeUserRoleChanged,
+}
+
+// IsType reports whether eventType is one of Types
+func IsType(eventType string) bool {
+	for _, known := range Types {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is a change in the domain that other systems may react to. Build one
+// with the constructors below; Emit gives it its ID.
+type Event struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Data       map[string]any `json:"data"`
+}
+
+// New returns an event of eventType with data. Prefer the constructors of the
+// known types.
+func New(eventType string, data map[string]any) Event {
+	return Event{Type: eventType, OccurredAt: time.Now().UTC(), Data: data}
+}
+
+// Registered reports a new user
+func Registered(user *models.User) Event {
+	return New(TypeUserRegistered, map[string]any{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"email":    user.Email,
+		"role":     user.Role,
+	})
+}
+
+// Banned reports a ban
+func Banned(ban *models.BanHistory) Event {
+	return New(TypeUserBanned, map[string]any{
+		"user_id":      ban.UserID,
+		"banned_by_id": ban.BannedByID,
+		"reason":       ban.Reason,
+		"permanent":    ban.EndDate == nil,
+		"until":        ban.EndDate,
+	})
+}
+
+// Unbanned reports that unbannedBy lifted the ban of a user
+func Unbanned(userID, unbannedBy uint, reason string) Event {
+	return New(TypeUserUnbanned, map[string]any{
+		"user_id":        userID,
+		"unbanned_by_id": unbannedBy,
+		"reason":         reason,
+	})
+}
+
+// RoleChanged reports a change of a user's role
+func RoleChanged(history *models.RoleHistory) Event {
+	return New(TypeUserRoleChanged, map[string]any{
+		"user_id":       history.UserID,
+		"changed_by_id": history.ChangedByID,
+		"old_role":      history.OldRole,
+		"new_role":      history.NewRole,
+		"reason":        history.Reason,
+	})
+}
+
+// NewID returns a random event ID
+func NewID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate event ID: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// Subscriber reacts to emitted events
+type Subscriber struct {
+	Name string
+	// Handle runs inside the transaction of the change the event reports. An
+	// error rolls the change back, so handlers only record work to be done later.
+	Handle func(ctx context.Context, store repository.Store, event Event) error
+	// Committed, if set, is called by Committed to start the recorded work
+	Committed func()
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers []Subscriber
+)
+
+// Subscribe adds s to the subscribers of every event. It is meant to be called
+// on startup.
+func Subscribe(s Subscriber) {
+	mu.Lock()
+	defer mu.Unlock()
+	subscribers = append(subscribers, s)
+}
+
+// Emit hands event to every subscriber. Pass the Store of the transaction that
+// makes the change the event reports, and call Committed once it has committed.
+func Emit(ctx context.Context, store repository.Store, event Event) error {
+	if event.ID == "" {
+		id, err := NewID()
+		if err != nil {
+			return err
+		}
+		event.ID = id
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, s := range subscribers {
+		if err := s.Handle(ctx, store, event); err != nil {
+			return fmt.Errorf("%s failed to handle %s event: %w", s.Name, event.Type, err)
+		}
+	}
+	return nil
+}
+
+// Committed tells the subscribers that emitted events were committed
+func Committed() {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, s := range subscribers {
+		if s.Committed != nil {
+			s.Committed()
+		}
+	}
+}
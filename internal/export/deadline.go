@@ -0,0 +1,34 @@
+package export
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// WriteTimeout bounds writing one batch of a download. Downloads can outlive
+// the server's write timeout, so the deadline is pushed back before each batch.
+const WriteTimeout = 30 * time.Second
+
+// ExtendWriteDeadline gives the connection behind w another WriteTimeout to
+// write. Writers that do not support deadlines are left as they are.
+func ExtendWriteDeadline(w http.ResponseWriter) error {
+	err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(WriteTimeout))
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil
+	}
+	return err
+}
+
+// DeadlineWriter extends the write deadline of the response before each write,
+// for copying files of any size to it
+type DeadlineWriter struct {
+	W http.ResponseWriter
+}
+
+func (d DeadlineWriter) Write(p []byte) (int, error) {
+	if err := ExtendWriteDeadline(d.W); err != nil {
+		return 0, err
+	}
+	return d.W.Write(p)
+}
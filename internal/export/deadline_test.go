@@ -0,0 +1,47 @@
+package export
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// slowReader returns one byte of s per read, waiting delay before each
+type slowReader struct {
+	s     string
+	delay time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.s == "" {
+		return 0, io.EOF
+	}
+	time.Sleep(r.delay)
+	p[0], r.s = r.s[0], r.s[1:]
+	return 1, nil
+}
+
+func TestDeadlineWriterOutlivesWriteTimeout(t *testing.T) {
+	const content = "archive"
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		io.Copy(DeadlineWriter{W: w}, &slowReader{s: content, delay: 30 * time.Millisecond})
+	}))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("download was cut off after %q: %v", body, err)
+	}
+	if string(body) != content {
+		t.Errorf("downloaded %q, want %q", body, content)
+	}
+}
@@ -0,0 +1,143 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ContentType returns the HTTP content type for the format
+func (f Format) ContentType() string {
+	if f == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Extension returns the file extension for the format
+func (f Format) Extension() string {
+	if f == FormatNDJSON {
+		return "ndjson"
+	}
+	return "csv"
+}
+
+// ParseFormat validates an export format, defaulting to CSV
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatNDJSON:
+		return FormatNDJSON, nil
+	}
+	return "", fmt.Errorf("invalid format %q: must be csv or ndjson", value)
+}
+
+// Encoder writes rows with a fixed set of columns as CSV or NDJSON
+type Encoder struct {
+	format  Format
+	columns []string
+	csv     *csv.Writer
+	json    *json.Encoder
+}
+
+// NewEncoder creates an encoder and writes the CSV header if needed
+func NewEncoder(w io.Writer, format Format, columns []string) (*Encoder, error) {
+	e := &Encoder{format: format, columns: columns}
+	if format == FormatNDJSON {
+		e.json = json.NewEncoder(w)
+		return e, nil
+	}
+
+	e.csv = csv.NewWriter(w)
+	if err := e.csv.Write(columns); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Write encodes a single row; values must be in column order
+func (e *Encoder) Write(values []interface{}) error {
+	if len(values) != len(e.columns) {
+		return fmt.Errorf("expected %d values, got %d", len(e.columns), len(values))
+	}
+
+	if e.format == FormatNDJSON {
+		record := make(map[string]interface{}, len(values))
+		for i, column := range e.columns {
+			record[column] = values[i]
+		}
+		return e.json.Encode(record)
+	}
+
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = csvValue(value)
+	}
+	return e.csv.Write(record)
+}
+
+// Flush writes any buffered CSV data
+func (e *Encoder) Flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+	return nil
+}
+
+// escapeFormula keeps spreadsheets from evaluating text that starts like a
+// formula by prefixing it with a quote
+func escapeFormula(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// csvValue formats a value for a CSV cell, rendering nil as an empty cell.
+// Text is escaped with escapeFormula; numbers and times are written as is.
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return escapeFormula(*v)
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(*v)
+	case *uint:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(*v)
+	}
+	// Covers string and named string types such as roles
+	if text := reflect.ValueOf(value); text.Kind() == reflect.String {
+		return escapeFormula(text.String())
+	}
+	return fmt.Sprint(value)
+}
@@ -2,14 +2,14 @@ package admin
 
 import (
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 
 	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
 )
 
 type BanHistoryResponse struct {
@@ -30,79 +30,39 @@ type BanHistoryResponse struct {
 }
 
 // GetUserBanHistory returns the ban history for a specific user
-func GetUserBanHistory(db *gorm.DB) gin.HandlerFunc {
+func GetUserBanHistory(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
 		if err != nil {
-			log.Printf("Invalid user ID: %v", err)
+			slog.WarnContext(c.Request.Context(), "Invalid user ID", "error", err)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 			return
 		}
 
+		banStore := store.WithContext(c.Request.Context())
+
 		// Check if user exists
-		var user models.User
-		if err := db.First(&user, userID).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err := banStore.Users().FindByID(uint(userID))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 				return
 			}
-			log.Printf("Database error while fetching user: %v", err)
+			slog.ErrorContext(c.Request.Context(), "Database error while fetching user", "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 			return
 		}
 
-		var histories []models.BanHistory
-		if err := db.Preload("User").Preload("BannedBy").Preload("Unbanner").
-			Where("user_id = ?", userID).
-			Order("created_at desc").
-			Find(&histories).Error; err != nil {
-			log.Printf("Failed to fetch ban histories: %v", err)
+		histories, err := banStore.Bans().ListByUser(user.ID)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to fetch ban histories", "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ban histories"})
 			return
 		}
 
 		response := make([]BanHistoryResponse, len(histories))
 		for i, history := range histories {
-			var endDate, unbannedAt *string
-			if history.EndDate != nil {
-				formatted := history.EndDate.Format("2006-01-02 15:04:05")
-				endDate = &formatted
-			}
-			if history.UnbannedAt != nil {
-				formatted := history.UnbannedAt.Format("2006-01-02 15:04:05")
-				unbannedAt = &formatted
-			}
-
-			var unbannedBy *string
-			if history.UnbannedBy != nil {
-				var unbanner models.User
-				if err := db.First(&unbanner, *history.UnbannedBy).Error; err == nil {
-					username := *unbanner.Username
-					unbannedBy = &username
-				}
-			}
-
-			durationText := "permanent"
-			if history.DurationDays != nil {
-				durationText = strconv.Itoa(*history.DurationDays) + " days"
-			}
-
-			response[i] = BanHistoryResponse{
-				ID:           history.ID,
-				UserID:       history.UserID,
-				Username:     *history.User.Username,
-				BannedByID:   history.BannedByID,
-				BannedBy:     *history.BannedBy.Username,
-				Reason:       history.Reason,
-				Duration:     durationText,
-				DurationDays: history.DurationDays,
-				StartDate:    history.StartDate.Format("2006-01-02 15:04:05"),
-				EndDate:      endDate,
-				IsActive:     history.IsActive,
-				UnbannedAt:   unbannedAt,
-				UnbannedBy:   unbannedBy,
-				CreatedAt:    history.CreatedAt.Format("2006-01-02 15:04:05"),
-			}
+			response[i] = toBanHistoryResponse(history)
 		}
 
 		c.JSON(http.StatusOK, gin.H{
@@ -117,7 +77,7 @@ func GetUserBanHistory(db *gorm.DB) gin.HandlerFunc {
 }
 
 // GetAllBanHistories returns all ban histories with pagination
-func GetAllBanHistories(db *gorm.DB) gin.HandlerFunc {
+func GetAllBanHistories(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
@@ -131,85 +91,32 @@ func GetAllBanHistories(db *gorm.DB) gin.HandlerFunc {
 			limit = 10
 		}
 
-		offset := (page - 1) * limit
-
-		// Build query
-		query := db.Model(&models.BanHistory{})
-
-		// Apply filters
-		if status == "active" {
-			query = query.Where("is_active = ?", true)
-		} else if status == "inactive" {
-			query = query.Where("is_active = ?", false)
+		filter := repository.BanFilter{
+			Offset: (page - 1) * limit,
+			Limit:  limit,
 		}
 
-		if durationType == "permanent" {
-			query = query.Where("duration_days IS NULL")
-		} else if durationType == "temporary" {
-			query = query.Where("duration_days IS NOT NULL")
+		// Apply filters
+		if status == "active" || status == "inactive" {
+			active := status == "active"
+			filter.Active = &active
 		}
 
-		// Count total records
-		var total int64
-		if err := query.Count(&total).Error; err != nil {
-			log.Printf("Failed to count ban histories: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count ban histories"})
-			return
+		if durationType == "permanent" || durationType == "temporary" {
+			permanent := durationType == "permanent"
+			filter.Permanent = &permanent
 		}
 
-		// Get records
-		var histories []models.BanHistory
-		if err := query.Preload("User").Preload("BannedBy").Preload("Unbanner").
-			Order("created_at desc").
-			Offset(offset).Limit(limit).
-			Find(&histories).Error; err != nil {
-			log.Printf("Failed to fetch ban histories: %v", err)
+		histories, total, err := store.WithContext(c.Request.Context()).Bans().List(filter)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to fetch ban histories", "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ban histories"})
 			return
 		}
 
 		response := make([]BanHistoryResponse, len(histories))
 		for i, history := range histories {
-			var endDate, unbannedAt *string
-			if history.EndDate != nil {
-				formatted := history.EndDate.Format("2006-01-02 15:04:05")
-				endDate = &formatted
-			}
-			if history.UnbannedAt != nil {
-				formatted := history.UnbannedAt.Format("2006-01-02 15:04:05")
-				unbannedAt = &formatted
-			}
-
-			var unbannedBy *string
-			if history.UnbannedBy != nil {
-				var unbanner models.User
-				if err := db.First(&unbanner, *history.UnbannedBy).Error; err == nil {
-					username := *unbanner.Username
-					unbannedBy = &username
-				}
-			}
-
-			durationText := "permanent"
-			if history.DurationDays != nil {
-				durationText = strconv.Itoa(*history.DurationDays) + " days"
-			}
-
-			response[i] = BanHistoryResponse{
-				ID:           history.ID,
-				UserID:       history.UserID,
-				Username:     *history.User.Username,
-				BannedByID:   history.BannedByID,
-				BannedBy:     *history.BannedBy.Username,
-				Reason:       history.Reason,
-				Duration:     durationText,
-				DurationDays: history.DurationDays,
-				StartDate:    history.StartDate.Format("2006-01-02 15:04:05"),
-				EndDate:      endDate,
-				IsActive:     history.IsActive,
-				UnbannedAt:   unbannedAt,
-				UnbannedBy:   unbannedBy,
-				CreatedAt:    history.CreatedAt.Format("2006-01-02 15:04:05"),
-			}
+			response[i] = toBanHistoryResponse(history)
 		}
 
 		totalPages := (int(total) + limit - 1) / limit
@@ -226,4 +133,45 @@ func GetAllBanHistories(db *gorm.DB) gin.HandlerFunc {
 			},
 		})
 	}
-} 
\ No newline at end of file
+}
+
+func toBanHistoryResponse(history models.BanHistory) BanHistoryResponse {
+	var endDate, unbannedAt *string
+	if history.EndDate != nil {
+		formatted := history.EndDate.Format("2006-01-02 15:04:05")
+		endDate = &formatted
+	}
+	if history.UnbannedAt != nil {
+		formatted := history.UnbannedAt.Format("2006-01-02 15:04:05")
+		unbannedAt = &formatted
+	}
+
+	// Unbanner is empty when the unbanning user was deleted
+	var unbannedBy *string
+	if history.UnbannedBy != nil && history.Unbanner.Username != nil {
+		username := *history.Unbanner.Username
+		unbannedBy = &username
+	}
+
+	durationText := "permanent"
+	if history.DurationDays != nil {
+		durationText = strconv.Itoa(*history.DurationDays) + " days"
+	}
+
+	return BanHistoryResponse{
+		ID:           history.ID,
+		UserID:       history.UserID,
+		Username:     *history.User.Username,
+		BannedByID:   history.BannedByID,
+		BannedBy:     *history.BannedBy.Username,
+		Reason:       history.Reason,
+		Duration:     durationText,
+		DurationDays: history.DurationDays,
+		StartDate:    history.StartDate.Format("2006-01-02 15:04:05"),
+		EndDate:      endDate,
+		IsActive:     history.IsActive,
+		UnbannedAt:   unbannedAt,
+		UnbannedBy:   unbannedBy,
+		CreatedAt:    history.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -0,0 +1,217 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/blocklist"
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+)
+
+type CreateBlocklistEntryRequest struct {
+	Type          models.BlocklistType `json:"type" binding:"required,oneof=ip email_domain"`
+	Value         string               `json:"value" binding:"required"`
+	Reason        string               `json:"reason" binding:"required,min=15"`
+	ExpiresInDays *int                 `json:"expires_in_days" binding:"omitempty,min=1"` // null for permanent blocks
+}
+
+type BlocklistEntryResponse struct {
+	ID           uint                 `json:"id"`
+	Type         models.BlocklistType `json:"type"`
+	Value        string               `json:"value"`
+	Reason       string               `json:"reason"`
+	CreatedByID  uint                 `json:"created_by_id"`
+	CreatedBy    string               `json:"created_by"`
+	SourceUserID *uint                `json:"source_user_id"`
+	ExpiresAt    *string              `json:"expires_at"`
+	CreatedAt    string               `json:"created_at"`
+}
+
+func toBlocklistEntryResponse(entry models.BlocklistEntry) BlocklistEntryResponse {
+	var expiresAt *string
+	if entry.ExpiresAt != nil {
+		formatted := entry.ExpiresAt.Format("2006-01-02 15:04:05")
+		expiresAt = &formatted
+	}
+
+	createdBy := ""
+	if entry.CreatedBy.Username != nil {
+		createdBy = *entry.CreatedBy.Username
+	}
+
+	return BlocklistEntryResponse{
+		ID:           entry.ID,
+		Type:         entry.Type,
+		Value:        entry.Value,
+		Reason:       entry.Reason,
+		CreatedByID:  entry.CreatedByID,
+		CreatedBy:    createdBy,
+		SourceUserID: entry.SourceUserID,
+		ExpiresAt:    expiresAt,
+		CreatedAt:    entry.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// GetBlocklist returns blocklist entries with pagination
+func GetBlocklist(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		entryType := c.Query("type")
+
+		if page < 1 {
+			page = 1
+		}
+		if limit < 1 || limit > 50 {
+			limit = 10
+		}
+
+		entries, total, err := store.WithContext(c.Request.Context()).Blocklist().
+			List(models.BlocklistType(entryType), (page-1)*limit, limit)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to fetch blocklist entries", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blocklist entries"})
+			return
+		}
+
+		response := make([]BlocklistEntryResponse, len(entries))
+		for i, entry := range entries {
+			response[i] = toBlocklistEntryResponse(entry)
+		}
+
+		totalPages := (int(total) + limit - 1) / limit
+
+		c.JSON(http.StatusOK, gin.H{
+			"entries": response,
+			"pagination": gin.H{
+				"current_page": page,
+				"total_pages":  totalPages,
+				"total_items":  total,
+				"per_page":     limit,
+				"has_next":     page < totalPages,
+				"has_prev":     page > 1,
+			},
+		})
+	}
+}
+
+// CreateBlocklistEntry blocks an IP, CIDR range or email domain
+func CreateBlocklistEntry(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CreateBlocklistEntryRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid request body", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+			return
+		}
+
+		// Get current user from context
+		currentUser, exists := c.Get("user")
+		if !exists {
+			slog.WarnContext(c.Request.Context(), "User not found in context")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		cu, ok := currentUser.(*models.User)
+		if !ok {
+			slog.ErrorContext(c.Request.Context(), "Failed to cast user from context")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		value := blocklist.NormalizeDomain(req.Value)
+		if req.Type == models.BlocklistIP {
+			normalized, err := blocklist.NormalizeIP(req.Value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			value = normalized
+		} else if value == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email domain"})
+			return
+		}
+
+		var expiresAt *time.Time
+		if req.ExpiresInDays != nil {
+			t := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+			expiresAt = &t
+		}
+
+		entry := models.BlocklistEntry{
+			Type:        req.Type,
+			Value:       value,
+			Reason:      req.Reason,
+			CreatedByID: cu.ID,
+			ExpiresAt:   expiresAt,
+		}
+		errExists := errors.New("blocklist entry already exists")
+		if err := store.WithContext(c.Request.Context()).Transaction(func(tx repository.Store) error {
+			// An expired entry for the value is replaced
+			_, err := tx.Blocklist().ActiveEntry(req.Type, value)
+			if err == nil {
+				return errExists
+			}
+			if !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+			return tx.Blocklist().Create(&entry)
+		}); err != nil {
+			if errors.Is(err, errExists) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Blocklist entry already exists"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Failed to create blocklist entry", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create blocklist entry"})
+			return
+		}
+		entry.CreatedBy = *cu
+
+		slog.InfoContext(c.Request.Context(), "Blocklist entry created", "entry_id", entry.ID, "type", entry.Type, "value", entry.Value)
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "Blocklist entry created successfully",
+			"entry":   toBlocklistEntryResponse(entry),
+		})
+	}
+}
+
+// DeleteBlocklistEntry removes a blocklist entry
+func DeleteBlocklistEntry(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entryID, err := strconv.ParseUint(c.Param("entry_id"), 10, 32)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid entry ID", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry ID"})
+			return
+		}
+
+		blocklistStore := store.WithContext(c.Request.Context())
+		entry, err := blocklistStore.Blocklist().FindByID(uint(entryID))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Blocklist entry not found"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Database error while fetching blocklist entry", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		if err := blocklistStore.Blocklist().Delete(entry); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to delete blocklist entry", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete blocklist entry"})
+			return
+		}
+
+		slog.InfoContext(c.Request.Context(), "Blocklist entry deleted", "entry_id", entry.ID, "type", entry.Type, "value", entry.Value)
+		c.JSON(http.StatusOK, gin.H{"message": "Blocklist entry deleted successfully"})
+	}
+}
@@ -0,0 +1,418 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/background"
+	"ai-backend/internal/events"
+	"ai-backend/internal/metrics"
+	"ai-backend/internal/middleware"
+	"ai-backend/internal/models"
+	"ai-backend/internal/outbox"
+	"ai-backend/internal/repository"
+	"ai-backend/internal/service"
+)
+
+const (
+	bulkActionBan   = "ban"
+	bulkActionUnban = "unban"
+	bulkActionRole  = "role"
+
+	bulkModeTransaction = "transaction"
+	bulkModeBackground  = "background"
+
+	// maxTransactionBulkSize is the largest batch processed synchronously; bigger batches must use background mode
+	maxTransactionBulkSize = 500
+	// bulkChunkSize is the number of users processed per transaction in background mode
+	bulkChunkSize = 100
+)
+
+// BulkUserFilter selects target users the same way ListUsersQuery does
+type BulkUserFilter struct {
+	Search string `json:"search"`
+	Role   string `json:"role"`
+	Status string `json:"status"`
+}
+
+func (f *BulkUserFilter) userFilter() repository.UserFilter {
+	return repository.UserFilter{Search: f.Search, Role: f.Role, Status: f.Status}
+}
+
+type BulkUserActionRequest struct {
+	Action       string          `json:"action" binding:"required,oneof=ban unban role"`
+	UserIDs      []uint          `json:"user_ids"`
+	Filter       *BulkUserFilter `json:"filter"`
+	Reason       string          `json:"reason"`
+	Duration     string          `json:"duration"` // ban only: number of days or "permanent"
+	Role         models.UserRole `json:"role"`     // role only
+	Mode         string          `json:"mode" binding:"omitempty,oneof=transaction background"`
+	AllOrNothing bool            `json:"all_or_nothing"` // transaction mode only: roll back everything if one user fails
+}
+
+type BulkUserResult struct {
+	UserID  uint   `json:"user_id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// bulkAction holds a validated bulk request ready to be applied to users
+type bulkAction struct {
+	req         BulkUserActionRequest
+	currentUser models.User
+	moderation  *service.ModerationService
+	// ctx carries the request's log attributes. It is not cancelled with the
+	// request, because background jobs outlive it.
+	ctx context.Context
+}
+
+// validateBulkRequest checks the action specific fields of a bulk request
+func validateBulkRequest(req *BulkUserActionRequest) (*bulkAction, error) {
+	if len(req.UserIDs) == 0 && req.Filter == nil {
+		return nil, service.NewError(service.KindInvalid, "Either user_ids or filter is required")
+	}
+	if len(req.UserIDs) > 0 && req.Filter != nil {
+		return nil, service.NewError(service.KindInvalid, "Provide either user_ids or filter, not both")
+	}
+	if req.Filter != nil && req.Filter.Search == "" && req.Filter.Role == "" && req.Filter.Status == "" {
+		return nil, service.NewError(service.KindInvalid, "Filter must contain at least one criterion")
+	}
+	if req.Filter != nil {
+		if err := req.Filter.userFilter().Validate(); err != nil {
+			return nil, service.NewError(service.KindInvalid, err.Error())
+		}
+	}
+	if req.Mode == "" {
+		req.Mode = bulkModeTransaction
+	}
+
+	action := &bulkAction{req: *req}
+
+	switch req.Action {
+	case bulkActionBan:
+		if len(req.Reason) < 15 {
+			return nil, service.NewError(service.KindInvalid, "Reason must be at least 15 characters long")
+		}
+		if _, _, err := service.CalculateBanEndDate(req.Duration); err != nil {
+			return nil, service.NewError(service.KindInvalid, err.Error())
+		}
+	case bulkActionUnban:
+		if len(req.Reason) < 15 {
+			return nil, service.NewError(service.KindInvalid, "Reason must be at least 15 characters long")
+		}
+	case bulkActionRole:
+		if !req.Role.IsValid() {
+			return nil, service.NewError(service.KindInvalid, "Invalid role")
+		}
+	}
+
+	return action, nil
+}
+
+// resolveBulkTargets returns the de-duplicated list of user IDs targeted by the request
+func resolveBulkTargets(store repository.Store, req BulkUserActionRequest) ([]uint, error) {
+	if len(req.UserIDs) > 0 {
+		seen := make(map[uint]bool, len(req.UserIDs))
+		ids := make([]uint, 0, len(req.UserIDs))
+		for _, id := range req.UserIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+
+	return store.Users().ListIDs(req.Filter.userFilter())
+}
+
+// applyToUser runs the bulk action for a single user inside tx, applying the same rules as the single-user endpoints
+func (a *bulkAction) applyToUser(tx repository.Store, userID uint) error {
+	if userID == a.currentUser.ID {
+		return service.NewError(service.KindInvalid, "Cannot apply bulk action to yourself")
+	}
+
+	moderationService := a.moderation.WithStore(tx).WithContext(a.ctx)
+	cu := &a.currentUser
+	switch a.req.Action {
+	case bulkActionBan:
+		_, err := moderationService.Ban(cu, service.BanInput{
+			UserID:   userID,
+			Reason:   a.req.Reason,
+			Duration: a.req.Duration,
+		})
+		return err
+	case bulkActionUnban:
+		_, err := moderationService.Unban(cu, userID, a.req.Reason)
+		return err
+	case bulkActionRole:
+		_, err := moderationService.ChangeRole(cu, userID, a.req.Role, a.req.Reason)
+		return err
+	}
+
+	return service.NewError(service.KindInvalid, "Unsupported action")
+}
+
+// applyToUsers processes every user in its own savepoint inside tx and returns a per-user report
+func (a *bulkAction) applyToUsers(tx repository.Store, userIDs []uint) []BulkUserResult {
+	results := make([]BulkUserResult, 0, len(userIDs))
+	for _, userID := range userIDs {
+		err := tx.Transaction(func(sp repository.Store) error {
+			return a.applyToUser(sp, userID)
+		})
+
+		result := BulkUserResult{UserID: userID, Success: err == nil}
+		if err != nil {
+			var serviceErr service.Error
+			if errors.As(err, &serviceErr) {
+				result.Error = serviceErr.Message
+			} else {
+				result.Error = "Database error"
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// countBulkFailures returns the number of failed entries in results
+func countBulkFailures(results []BulkUserResult) int {
+	failed := 0
+	for _, r := range results {
+		if !r.Success {
+			failed++
+		}
+	}
+	return failed
+}
+
+// runBulkJob processes a background bulk job in chunks, persisting progress after each chunk
+func runBulkJob(store repository.Store, jobID uint, action *bulkAction, userIDs []uint) {
+	if err := store.BulkJobs().Update(jobID, map[string]interface{}{"status": models.BulkJobRunning}); err != nil {
+		slog.ErrorContext(action.ctx, "Failed to mark bulk job as running", "job_id", jobID, "error", err)
+	}
+
+	results := make([]BulkUserResult, 0, len(userIDs))
+	for start := 0; start < len(userIDs); start += bulkChunkSize {
+		end := start + bulkChunkSize
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+
+		var chunkResults []BulkUserResult
+		if err := store.Transaction(func(tx repository.Store) error {
+			chunkResults = action.applyToUsers(tx, userIDs[start:end])
+			return nil
+		}); err != nil {
+			slog.ErrorContext(action.ctx, "Bulk job chunk failed to commit", "job_id", jobID, "start", start, "end", end, "error", err)
+			chunkResults = chunkResults[:0]
+			for _, userID := range userIDs[start:end] {
+				chunkResults = append(chunkResults, BulkUserResult{UserID: userID, Error: "Database error"})
+			}
+		} else {
+			// Deliver the notices and events of the committed chunk
+			outbox.Wake()
+			events.Committed()
+		}
+		results = append(results, chunkResults...)
+
+		failed := countBulkFailures(results)
+		encoded, _ := json.Marshal(results)
+		if err := store.BulkJobs().Update(jobID, map[string]interface{}{
+			"processed": len(results),
+			"succeeded": len(results) - failed,
+			"failed":    failed,
+			"results":   string(encoded),
+		}); err != nil {
+			slog.ErrorContext(action.ctx, "Failed to update bulk job progress", "job_id", jobID, "error", err)
+		}
+	}
+
+	now := time.Now()
+	if err := store.BulkJobs().Update(jobID, map[string]interface{}{
+		"status":       models.BulkJobCompleted,
+		"completed_at": now,
+	}); err != nil {
+		slog.ErrorContext(action.ctx, "Failed to complete bulk job", "job_id", jobID, "error", err)
+	}
+
+	slog.InfoContext(action.ctx, "Bulk job completed", "job_id", jobID, "processed", len(results), "failed", countBulkFailures(results))
+}
+
+// BulkUserAction bans, unbans or changes the role of many users at once
+func BulkUserAction(store repository.Store, moderationService *service.ModerationService, m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req BulkUserActionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid request body", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+			return
+		}
+
+		// Get current user from context
+		currentUser, exists := c.Get("user")
+		if !exists {
+			slog.WarnContext(c.Request.Context(), "User not found in context")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		cu, ok := currentUser.(*models.User)
+		if !ok {
+			slog.ErrorContext(c.Request.Context(), "Failed to cast user from context")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		action, err := validateBulkRequest(&req)
+		if err != nil {
+			middleware.RespondWithError(c, err)
+			return
+		}
+		action.currentUser = *cu
+		action.moderation = moderationService
+		action.ctx = context.WithoutCancel(c.Request.Context())
+
+		bulkStore := store.WithContext(c.Request.Context())
+
+		userIDs, err := resolveBulkTargets(bulkStore, req)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to resolve bulk targets", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		if len(userIDs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No users matched the request"})
+			return
+		}
+
+		if req.Mode == bulkModeBackground {
+			job := models.BulkJob{
+				Action:      req.Action,
+				CreatedByID: cu.ID,
+				Status:      models.BulkJobPending,
+				Total:       len(userIDs),
+			}
+			if err := bulkStore.BulkJobs().Create(&job); err != nil {
+				slog.ErrorContext(c.Request.Context(), "Failed to create bulk job", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bulk job"})
+				return
+			}
+
+			// Shutdown waits for the job so a chunk is never cut off mid-transaction
+			background.Go("bulk_job", m, func(context.Context) {
+				runBulkJob(store, job.ID, action, userIDs)
+			})
+
+			slog.InfoContext(c.Request.Context(), "Bulk job queued", "job_id", job.ID, "action", req.Action, "users", len(userIDs))
+			c.JSON(http.StatusAccepted, gin.H{
+				"message": "Bulk job queued",
+				"job_id":  job.ID,
+				"total":   job.Total,
+			})
+			return
+		}
+
+		if len(userIDs) > maxTransactionBulkSize {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Too many users for transaction mode (%d > %d), use background mode", len(userIDs), maxTransactionBulkSize),
+			})
+			return
+		}
+
+		var results []BulkUserResult
+		rolledBack := false
+		errAllOrNothing := errors.New("bulk action rolled back")
+		if err := bulkStore.Transaction(func(tx repository.Store) error {
+			results = action.applyToUsers(tx, userIDs)
+			if req.AllOrNothing && countBulkFailures(results) > 0 {
+				return errAllOrNothing
+			}
+			return nil
+		}); err != nil {
+			if !errors.Is(err, errAllOrNothing) {
+				slog.ErrorContext(c.Request.Context(), "Failed to commit bulk action", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				return
+			}
+			rolledBack = true
+			// Nothing was applied, including the changes that succeeded before the rollback
+			for i := range results {
+				if results[i].Success {
+					results[i].Success = false
+					results[i].Error = "Rolled back because another user failed"
+				}
+			}
+		} else {
+			outbox.Wake()
+			events.Committed()
+		}
+
+		failed := countBulkFailures(results)
+		slog.InfoContext(c.Request.Context(), "Bulk action completed", "action", req.Action, "users", len(results), "failed", failed, "rolled_back", rolledBack)
+		c.JSON(http.StatusOK, gin.H{
+			"message":     "Bulk action completed",
+			"action":      req.Action,
+			"total":       len(results),
+			"succeeded":   len(results) - failed,
+			"failed":      failed,
+			"rolled_back": rolledBack,
+			"results":     results,
+		})
+	}
+}
+
+// GetBulkJob returns the progress and per-user report of a background bulk job
+func GetBulkJob(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobID, err := strconv.ParseUint(c.Param("job_id"), 10, 32)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid job ID", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+			return
+		}
+
+		job, err := store.WithContext(c.Request.Context()).BulkJobs().FindByID(uint(jobID))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Bulk job not found"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Database error while fetching bulk job", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		results := []BulkUserResult{}
+		if job.Results != "" {
+			if err := json.Unmarshal([]byte(job.Results), &results); err != nil {
+				slog.ErrorContext(c.Request.Context(), "Failed to decode bulk job results", "error", err)
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"job": gin.H{
+				"id":            job.ID,
+				"action":        job.Action,
+				"status":        job.Status,
+				"created_by_id": job.CreatedByID,
+				"total":         job.Total,
+				"processed":     job.Processed,
+				"succeeded":     job.Succeeded,
+				"failed":        job.Failed,
+				"created_at":    job.CreatedAt,
+				"completed_at":  job.CompletedAt,
+			},
+			"results": results,
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/locale"
+	"ai-backend/pkg/email"
+)
+
+// ListEmailTemplates lists the email templates and the locales they are available in
+func ListEmailTemplates() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"templates": email.TemplateNames(),
+			"locales":   locale.Supported,
+		})
+	}
+}
+
+// PreviewEmailTemplate renders a template with sample data. format selects the
+// HTML page (default), the text variant or a JSON document with subject and both bodies.
+func PreviewEmailTemplate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		loc := c.DefaultQuery("locale", locale.Default)
+		if !locale.IsSupported(loc) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported locale", "locales": locale.Supported})
+			return
+		}
+
+		msg, err := email.Preview(c.Param("name"), loc)
+		if err != nil {
+			if errors.Is(err, email.ErrUnknownTemplate) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Email template not found"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Failed to render email template preview", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render email template"})
+			return
+		}
+
+		switch c.DefaultQuery("format", "html") {
+		case "html":
+			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(msg.HTML))
+		case "text":
+			c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(msg.Text))
+		case "json":
+			c.JSON(http.StatusOK, gin.H{
+				"template": c.Param("name"),
+				"locale":   loc,
+				"subject":  msg.Subject,
+				"html":     msg.HTML,
+				"text":     msg.Text,
+			})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected html, text or json"})
+		}
+	}
+}
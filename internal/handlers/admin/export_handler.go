@@ -0,0 +1,189 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/export"
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+)
+
+// exportBatchSize is the number of rows fetched per keyset page while streaming an export
+const exportBatchSize = 1000
+
+// streamExport walks the batches and writes each row to the response as it goes
+func streamExport[T any](c *gin.Context, batches func(fn func([]T) error) error, name string, columns []string, row func(T) []interface{}) {
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), format.Extension())
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	encoder, err := export.NewEncoder(c.Writer, format, columns)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to start export", "export", name, "error", err)
+		return
+	}
+
+	total := 0
+	err = batches(func(batch []T) error {
+		// The export can outlive the server's write timeout
+		if err := export.ExtendWriteDeadline(c.Writer); err != nil {
+			return err
+		}
+		for _, item := range batch {
+			if err := encoder.Write(row(item)); err != nil {
+				return err
+			}
+		}
+		total += len(batch)
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		// Headers are already sent, so the client sees a truncated file
+		slog.ErrorContext(c.Request.Context(), "Failed to stream export", "export", name, "rows", total, "error", err)
+		return
+	}
+
+	if err := encoder.Flush(); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to flush export", "export", name, "error", err)
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Export completed", "export", name, "format", format, "rows", total)
+}
+
+// historyFilter reads the optional user_id, from and to filters shared by history exports
+func historyFilter(c *gin.Context) (repository.HistoryFilter, bool) {
+	var filter repository.HistoryFilter
+	if value := c.Query("user_id"); value != "" {
+		userID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return filter, false
+		}
+		filter.UserID = uint(userID)
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return filter, false
+		}
+		filter.From = &from
+	}
+
+	if value := c.Query("to"); value != "" {
+		to, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return filter, false
+		}
+		// The to date is inclusive
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	return filter, true
+}
+
+// ExportUsers streams users matching the List Users filters as CSV or NDJSON
+func ExportUsers(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := repository.UserFilter{
+			Search: c.Query("search"),
+			Role:   c.Query("role"),
+			Status: c.Query("status"),
+		}
+		if err := filter.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		users := store.WithContext(c.Request.Context()).Users()
+
+		columns := []string{"id", "username", "email", "name", "role", "status", "email_verified", "created_at", "updated_at"}
+		batches := func(fn func([]models.User) error) error {
+			return users.EachBatch(filter, exportBatchSize, fn)
+		}
+		streamExport(c, batches, "users", columns, func(u models.User) []interface{} {
+			return []interface{}{u.ID, u.Username, u.Email, u.Name, u.Role, u.Status, u.EmailVerified, u.CreatedAt, u.UpdatedAt}
+		})
+	}
+}
+
+// ExportBanHistories streams ban histories as CSV or NDJSON
+func ExportBanHistories(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := historyFilter(c)
+		if !ok {
+			return
+		}
+		histories := store.WithContext(c.Request.Context()).Bans()
+		batches := func(fn func([]models.BanHistory) error) error {
+			return histories.EachBatch(filter, exportBatchSize, fn)
+		}
+
+		columns := []string{"id", "user_id", "username", "banned_by_id", "banned_by", "reason", "duration", "duration_days",
+			"start_date", "end_date", "is_active", "unbanned_at", "unbanned_by_id", "created_at"}
+		streamExport(c, batches, "ban-histories", columns, func(h models.BanHistory) []interface{} {
+			return []interface{}{h.ID, h.UserID, h.User.Username, h.BannedByID, h.BannedBy.Username, h.Reason, h.Duration,
+				h.DurationDays, h.StartDate, h.EndDate, h.IsActive, h.UnbannedAt, h.UnbannedBy, h.CreatedAt}
+		})
+	}
+}
+
+// ExportRoleHistories streams role change histories as CSV or NDJSON
+func ExportRoleHistories(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := historyFilter(c)
+		if !ok {
+			return
+		}
+		histories := store.WithContext(c.Request.Context()).RoleHistories()
+		batches := func(fn func([]models.RoleHistory) error) error {
+			return histories.EachBatch(filter, exportBatchSize, fn)
+		}
+
+		columns := []string{"id", "user_id", "username", "changed_by_id", "changed_by", "old_role", "new_role", "reason", "created_at"}
+		streamExport(c, batches, "role-histories", columns, func(h models.RoleHistory) []interface{} {
+			return []interface{}{h.ID, h.UserID, h.User.Username, h.ChangedByID, h.ChangedBy.Username, h.OldRole, h.NewRole,
+				h.Reason, h.CreatedAt}
+		})
+	}
+}
+
+// ExportFreezeHistories streams account freeze histories as CSV or NDJSON
+func ExportFreezeHistories(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := historyFilter(c)
+		if !ok {
+			return
+		}
+		histories := store.WithContext(c.Request.Context()).Freezes()
+		batches := func(fn func([]models.FreezeHistory) error) error {
+			return histories.EachBatch(filter, exportBatchSize, fn)
+		}
+
+		columns := []string{"id", "user_id", "username", "reason", "duration_days", "start_date", "end_date", "is_active", "unfrozen_at", "created_at"}
+		streamExport(c, batches, "freeze-histories", columns, func(h models.FreezeHistory) []interface{} {
+			return []interface{}{h.ID, h.UserID, h.User.Username, h.Reason, h.Duration, h.StartDate, h.EndDate, h.IsActive,
+				h.UnfrozenAt, h.CreatedAt}
+		})
+	}
+}
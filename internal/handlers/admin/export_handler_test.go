@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStreamExportOutlivesWriteTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const batches = 5
+	router := gin.New()
+	router.GET("/export", func(c *gin.Context) {
+		streamExport(c, func(fn func([]int) error) error {
+			for i := 0; i < batches; i++ {
+				if err := fn([]int{i}); err != nil {
+					return err
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+			return nil
+		}, "numbers", []string{"n"}, func(n int) []interface{} { return []interface{}{n} })
+	})
+
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("export was cut off after %q: %v", body, err)
+	}
+	if want := "n\n0\n1\n2\n3\n4\n"; string(body) != want {
+		t.Errorf("export is %q, want %q", body, want)
+	}
+	if !strings.HasPrefix(res.Header.Get("Content-Disposition"), "attachment") {
+		t.Errorf("Content-Disposition is %q, want an attachment", res.Header.Get("Content-Disposition"))
+	}
+}
@@ -0,0 +1,263 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/events"
+	"ai-backend/internal/middleware"
+	"ai-backend/internal/models"
+	"ai-backend/internal/outbox"
+	"ai-backend/internal/repository"
+	"ai-backend/internal/service"
+)
+
+const (
+	flagActionDismiss       = "dismiss"
+	flagActionDeleteContent = "delete_content"
+	flagActionEscalate      = "escalate"
+)
+
+type ResolveFlagsRequest struct {
+	TargetType  models.FlagTargetType `json:"target_type" binding:"required,oneof=question answer user"`
+	TargetID    uint                  `json:"target_id" binding:"required"`
+	Action      string                `json:"action" binding:"required,oneof=dismiss delete_content escalate"`
+	Note        string                `json:"note"`
+	BanReason   string                `json:"ban_reason"`   // escalate only, ADMIN and SUPER_ADMIN
+	BanDuration string                `json:"ban_duration"` // escalate only: number of days or "permanent"
+}
+
+// FlagQueueItem groups the flags of a single target in the moderation queue
+type FlagQueueItem struct {
+	TargetType     models.FlagTargetType `json:"target_type"`
+	TargetID       uint                  `json:"target_id"`
+	FlagCount      int64                 `json:"flag_count"`
+	ReporterCount  int64                 `json:"reporter_count"`
+	Reasons        []string              `json:"reasons"`
+	FirstFlaggedAt time.Time             `json:"first_flagged_at"`
+	LastFlaggedAt  time.Time             `json:"last_flagged_at"`
+}
+
+// GetFlagQueue returns flagged targets grouped by target, most flagged first
+func GetFlagQueue(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		status := c.DefaultQuery("status", string(models.FlagStatusOpen))
+		targetType := c.Query("target_type")
+
+		if page < 1 {
+			page = 1
+		}
+		if limit < 1 || limit > 50 {
+			limit = 10
+		}
+
+		rows, total, err := store.WithContext(c.Request.Context()).Flags().Queue(repository.FlagQueueFilter{
+			Status:     models.FlagStatus(status),
+			TargetType: models.FlagTargetType(targetType),
+			Offset:     (page - 1) * limit,
+			Limit:      limit,
+		})
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to fetch flag queue", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch flag queue"})
+			return
+		}
+
+		items := make([]FlagQueueItem, len(rows))
+		for i, row := range rows {
+			items[i] = FlagQueueItem{
+				TargetType:     row.TargetType,
+				TargetID:       row.TargetID,
+				FlagCount:      row.FlagCount,
+				ReporterCount:  row.ReporterCount,
+				Reasons:        strings.Split(row.Reasons, ","),
+				FirstFlaggedAt: row.FirstFlaggedAt,
+				LastFlaggedAt:  row.LastFlaggedAt,
+			}
+		}
+
+		totalPages := (int(total) + limit - 1) / limit
+
+		c.JSON(http.StatusOK, gin.H{
+			"items": items,
+			"pagination": gin.H{
+				"current_page": page,
+				"total_pages":  totalPages,
+				"total_items":  total,
+				"per_page":     limit,
+				"has_next":     page < totalPages,
+				"has_prev":     page > 1,
+			},
+		})
+	}
+}
+
+// ResolveFlags resolves every open or escalated flag on a target by dismissing them,
+// deleting the content or escalating to a ban. Only admins resolve escalated flags.
+func ResolveFlags(store repository.Store, moderationService *service.ModerationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ResolveFlagsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid request body", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+			return
+		}
+
+		// Get current user from context
+		currentUser, exists := c.Get("user")
+		if !exists {
+			slog.WarnContext(c.Request.Context(), "User not found in context")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		cu, ok := currentUser.(*models.User)
+		if !ok {
+			slog.ErrorContext(c.Request.Context(), "Failed to cast user from context")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		if req.Action == flagActionDeleteContent && req.TargetType == models.FlagTargetUser {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "delete_content cannot be used on user flags"})
+			return
+		}
+
+		banRequested := req.Action == flagActionEscalate && req.BanDuration != ""
+		if banRequested {
+			if cu.Role != models.RoleAdmin && cu.Role != models.RoleSuperAdmin {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only ADMIN or SUPER_ADMIN can ban users"})
+				return
+			}
+			if len(req.BanReason) < 15 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Ban reason must be at least 15 characters long"})
+				return
+			}
+		}
+
+		flagStore := store.WithContext(c.Request.Context())
+
+		flagCount, err := flagStore.Flags().CountPending(req.TargetType, req.TargetID)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to count pending flags", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if flagCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No pending flags found for target"})
+			return
+		}
+
+		// Escalated flags wait for an admin, so editors cannot resolve them
+		if cu.Role != models.RoleAdmin && cu.Role != models.RoleSuperAdmin {
+			escalated, err := flagStore.Flags().CountEscalated(req.TargetType, req.TargetID)
+			if err != nil {
+				slog.ErrorContext(c.Request.Context(), "Failed to count escalated flags", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				return
+			}
+			if escalated > 0 {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only ADMIN or SUPER_ADMIN can resolve escalated flags"})
+				return
+			}
+		}
+
+		ownerID, err := flagStore.Flags().TargetOwner(req.TargetType, req.TargetID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			slog.ErrorContext(c.Request.Context(), "Database error while fetching flag target", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		newStatus := models.FlagStatusDismissed
+		var banHistory *models.BanHistory
+
+		err = flagStore.Transaction(func(tx repository.Store) error {
+			switch req.Action {
+			case flagActionDismiss:
+				if err := tx.Flags().SetContentHidden(req.TargetType, req.TargetID, false); err != nil {
+					return err
+				}
+			case flagActionDeleteContent:
+				newStatus = models.FlagStatusDeleted
+				if err := tx.Flags().DeleteContent(req.TargetType, req.TargetID); err != nil {
+					return err
+				}
+			case flagActionEscalate:
+				newStatus = models.FlagStatusEscalated
+				if banRequested {
+					newStatus = models.FlagStatusBanned
+					var banErr error
+					banHistory, banErr = banFlaggedUser(c.Request.Context(), moderationService, tx, cu, ownerID, req.BanReason, req.BanDuration)
+					if banErr != nil {
+						return banErr
+					}
+				}
+			}
+
+			now := time.Now()
+			updates := map[string]interface{}{
+				"status":          newStatus,
+				"resolved_by_id":  cu.ID,
+				"resolved_at":     now,
+				"resolution_note": req.Note,
+			}
+			return tx.Flags().ResolvePending(req.TargetType, req.TargetID, updates)
+		})
+		if err != nil {
+			var serviceErr service.Error
+			if !errors.As(err, &serviceErr) {
+				slog.ErrorContext(c.Request.Context(), "Failed to resolve flags", "error", err)
+			}
+			middleware.RespondWithError(c, err)
+			return
+		}
+
+		if banHistory != nil {
+			outbox.Wake()
+			events.Committed()
+		}
+
+		slog.InfoContext(c.Request.Context(), "Flags resolved", "target_type", req.TargetType, "target_id", req.TargetID, "action", req.Action, "flags", flagCount)
+		response := gin.H{
+			"message":        "Flags resolved successfully",
+			"target_type":    req.TargetType,
+			"target_id":      req.TargetID,
+			"action":         req.Action,
+			"status":         newStatus,
+			"resolved_flags": flagCount,
+		}
+		if banHistory != nil {
+			response["ban_details"] = gin.H{
+				"user_id":       banHistory.UserID,
+				"reason":        banHistory.Reason,
+				"duration_days": banHistory.DurationDays,
+				"end_date":      banHistory.EndDate,
+			}
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// banFlaggedUser bans the owner of flagged content using the same rules as BanUser
+func banFlaggedUser(ctx context.Context, moderationService *service.ModerationService, tx repository.Store, cu *models.User, ownerID uint, reason string, duration string) (*models.BanHistory, error) {
+	result, err := moderationService.WithStore(tx).WithContext(ctx).Ban(cu, service.BanInput{
+		UserID:   ownerID,
+		Reason:   reason,
+		Duration: duration,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Ban, nil
+}
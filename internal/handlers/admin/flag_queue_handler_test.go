@@ -0,0 +1,127 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+)
+
+// flagStore holds the flags of a single target; only the flag repository is implemented
+type flagStore struct {
+	repository.Store
+	flags []models.Flag
+}
+
+func (s *flagStore) WithContext(context.Context) repository.Store { return s }
+func (s *flagStore) Flags() repository.FlagRepository             { return flagRepository{s: s} }
+
+func (s *flagStore) Transaction(fn func(tx repository.Store) error) error {
+	return fn(s)
+}
+
+type flagRepository struct {
+	repository.FlagRepository
+	s *flagStore
+}
+
+func (r flagRepository) countStatus(match func(models.FlagStatus) bool) (int64, error) {
+	var total int64
+	for _, flag := range r.s.flags {
+		if match(flag.Status) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func isPending(status models.FlagStatus) bool {
+	return status == models.FlagStatusOpen || status == models.FlagStatusEscalated
+}
+
+func (r flagRepository) CountPending(models.FlagTargetType, uint) (int64, error) {
+	return r.countStatus(isPending)
+}
+
+func (r flagRepository) CountEscalated(models.FlagTargetType, uint) (int64, error) {
+	return r.countStatus(func(status models.FlagStatus) bool { return status == models.FlagStatusEscalated })
+}
+
+func (r flagRepository) TargetOwner(models.FlagTargetType, uint) (uint, error) {
+	return 99, nil
+}
+
+func (r flagRepository) SetContentHidden(models.FlagTargetType, uint, bool) error {
+	return nil
+}
+
+func (r flagRepository) ResolvePending(_ models.FlagTargetType, _ uint, fields map[string]interface{}) error {
+	for i := range r.s.flags {
+		if isPending(r.s.flags[i].Status) {
+			r.s.flags[i].Status = fields["status"].(models.FlagStatus)
+		}
+	}
+	return nil
+}
+
+// resolveFlags dismisses the flags of question 1 as a user with role
+func resolveFlags(t *testing.T, store *flagStore, role models.UserRole) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/flags/resolve", func(c *gin.Context) {
+		user := &models.User{Role: role}
+		user.ID = 1
+		c.Set("user", user)
+	}, ResolveFlags(store, nil))
+
+	body := `{"target_type": "question", "target_id": 1, "action": "dismiss"}`
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/flags/resolve", strings.NewReader(body)))
+	return recorder
+}
+
+func TestResolveEscalatedFlags(t *testing.T) {
+	tests := []struct {
+		role       models.UserRole
+		wantStatus int
+		wantFlag   models.FlagStatus
+	}{
+		{models.RoleEditor, http.StatusForbidden, models.FlagStatusEscalated},
+		{models.RoleAdmin, http.StatusOK, models.FlagStatusDismissed},
+		{models.RoleSuperAdmin, http.StatusOK, models.FlagStatusDismissed},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			store := &flagStore{flags: []models.Flag{
+				{Status: models.FlagStatusOpen},
+				{Status: models.FlagStatusEscalated},
+			}}
+
+			recorder := resolveFlags(t, store, tt.role)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("got status %d (%s), want %d", recorder.Code, recorder.Body, tt.wantStatus)
+			}
+			if status := store.flags[1].Status; status != tt.wantFlag {
+				t.Errorf("escalated flag is %s, want %s", status, tt.wantFlag)
+			}
+		})
+	}
+}
+
+func TestEditorResolvesOpenFlags(t *testing.T) {
+	store := &flagStore{flags: []models.Flag{{Status: models.FlagStatusOpen}}}
+
+	if recorder := resolveFlags(t, store, models.RoleEditor); recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d (%s), want %d", recorder.Code, recorder.Body, http.StatusOK)
+	}
+	if status := store.flags[0].Status; status != models.FlagStatusDismissed {
+		t.Errorf("flag is %s, want %s", status, models.FlagStatusDismissed)
+	}
+}
@@ -0,0 +1,141 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/middleware"
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+	"ai-backend/internal/service"
+)
+
+type MergeUsersRequest struct {
+	SourceUserID uint   `json:"source_user_id" binding:"required"`
+	TargetUserID uint   `json:"target_user_id" binding:"required"`
+	Reason       string `json:"reason" binding:"required,min=15"`
+	// CarryRestrictions must be given: true keeps the source's active content
+	// restrictions active on the target, false lifts them
+	CarryRestrictions *bool `json:"carry_restrictions" binding:"required"`
+}
+
+// validateMerge checks that source can be folded into target
+func validateMerge(ctx context.Context, source *models.User, target *models.User) error {
+	if source.ID == target.ID {
+		return service.NewError(service.KindInvalid, "Source and target users must be different")
+	}
+
+	if source.Role == models.RoleSuperAdmin {
+		slog.WarnContext(ctx, "Attempt to merge SUPER_ADMIN away", "source_user_id", source.ID)
+		return service.NewError(service.KindForbidden, "Cannot merge a SUPER_ADMIN into another user")
+	}
+
+	// Moving an active ban or freeze would leave the target with history that does not match its status
+	if source.Status == models.StatusBanned || source.Status == models.StatusFrozen {
+		return service.NewError(service.KindInvalid, "Source user is banned or frozen. Lift the ban or freeze before merging")
+	}
+
+	return nil
+}
+
+// MergeUsers folds a duplicate source account into a target account
+func MergeUsers(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req MergeUsersRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid request body", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		// Get current user from context
+		currentUser, exists := c.Get("user")
+		if !exists {
+			slog.WarnContext(c.Request.Context(), "User not found in context")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		cu, ok := currentUser.(*models.User)
+		if !ok {
+			slog.ErrorContext(c.Request.Context(), "Failed to cast user from context")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		mergeStore := store.WithContext(c.Request.Context())
+
+		var source, target *models.User
+		for _, lookup := range []struct {
+			id   uint
+			user **models.User
+			name string
+		}{
+			{req.SourceUserID, &source, "Source user"},
+			{req.TargetUserID, &target, "Target user"},
+		} {
+			user, err := mergeStore.Users().FindByID(lookup.id)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					slog.WarnContext(c.Request.Context(), "User not found", "target_user_id", lookup.id)
+					c.JSON(http.StatusNotFound, gin.H{"error": lookup.name + " not found"})
+					return
+				}
+				slog.ErrorContext(c.Request.Context(), "Database error while fetching user", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				return
+			}
+			*lookup.user = user
+		}
+
+		if err := validateMerge(c.Request.Context(), source, target); err != nil {
+			middleware.RespondWithError(c, err)
+			return
+		}
+
+		merge := &models.UserMerge{
+			SourceUserID:      source.ID,
+			TargetUserID:      target.ID,
+			MergedByID:        cu.ID,
+			Reason:            req.Reason,
+			CarryRestrictions: *req.CarryRestrictions,
+		}
+		if err := mergeStore.Transaction(func(tx repository.Store) error {
+			return tx.Merges().Apply(merge)
+		}); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to merge users", "source_user_id", source.ID, "target_user_id", target.ID, "error", err)
+			middleware.RespondWithError(c, err)
+			return
+		}
+
+		slog.InfoContext(c.Request.Context(), "Users merged", "source_user_id", source.ID, "target_user_id", target.ID)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Users merged successfully",
+			"merge_details": gin.H{
+				"id":                  merge.ID,
+				"source_user_id":      merge.SourceUserID,
+				"target_user_id":      merge.TargetUserID,
+				"merged_by":           cu.Username,
+				"reason":              merge.Reason,
+				"questions_moved":     merge.QuestionsMoved,
+				"answers_moved":       merge.AnswersMoved,
+				"votes_moved":         merge.VotesMoved,
+				"votes_dropped":       merge.VotesDropped,
+				"accounts_moved":      merge.AccountsMoved,
+				"histories_moved":     merge.HistoriesMoved,
+				"flags_moved":         merge.FlagsMoved,
+				"flags_dropped":       merge.FlagsDropped,
+				"flags_dismissed":     merge.FlagsDismissed,
+				"notifications_moved": merge.NotificationsMoved,
+				"exports_moved":       merge.ExportsMoved,
+				"carry_restrictions":  merge.CarryRestrictions,
+				"restrictions_lifted": merge.RestrictionsLifted,
+				"merged_at":           merge.CreatedAt.Format("2006-01-02 15:04:05"),
+			},
+		})
+	}
+}
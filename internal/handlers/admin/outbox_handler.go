@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/models"
+	"ai-backend/internal/outbox"
+	"ai-backend/internal/repository"
+)
+
+// OutboxMessageResponse describes a queued email. The body is left out because
+// it can contain tokens.
+type OutboxMessageResponse struct {
+	ID            uint    `json:"id"`
+	Kind          string  `json:"kind"`
+	Recipient     string  `json:"recipient"`
+	Subject       string  `json:"subject"`
+	Status        string  `json:"status"`
+	Attempts      int     `json:"attempts"`
+	LastError     *string `json:"last_error"`
+	NextAttemptAt string  `json:"next_attempt_at"`
+	SentAt        *string `json:"sent_at"`
+	CreatedAt     string  `json:"created_at"`
+	UpdatedAt     string  `json:"updated_at"`
+}
+
+func toOutboxMessageResponse(msg models.OutboxMessage) OutboxMessageResponse {
+	var sentAt *string
+	if msg.SentAt != nil {
+		formatted := msg.SentAt.Format(time.RFC3339)
+		sentAt = &formatted
+	}
+	return OutboxMessageResponse{
+		ID:            msg.ID,
+		Kind:          msg.Kind,
+		Recipient:     msg.Recipient,
+		Subject:       msg.Subject,
+		Status:        string(msg.Status),
+		Attempts:      msg.Attempts,
+		LastError:     msg.LastError,
+		NextAttemptAt: msg.NextAttemptAt.Format(time.RFC3339),
+		SentAt:        sentAt,
+		CreatedAt:     msg.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     msg.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// GetOutboxMessages lists queued emails by status with pagination. It defaults
+// to the dead-lettered ones, which need an admin's attention.
+func GetOutboxMessages(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		status := models.OutboxStatus(c.DefaultQuery("status", string(models.OutboxDead)))
+
+		switch status {
+		case models.OutboxPending, models.OutboxSent, models.OutboxDead:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status, expected pending, sent or dead"})
+			return
+		}
+		if page < 1 {
+			page = 1
+		}
+		if limit < 1 || limit > 50 {
+			limit = 10
+		}
+
+		messages, total, err := store.WithContext(c.Request.Context()).Outbox().List(status, (page-1)*limit, limit)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to fetch outbox messages", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch outbox messages"})
+			return
+		}
+
+		response := make([]OutboxMessageResponse, len(messages))
+		for i, msg := range messages {
+			response[i] = toOutboxMessageResponse(msg)
+		}
+
+		totalPages := (int(total) + limit - 1) / limit
+
+		c.JSON(http.StatusOK, gin.H{
+			"messages": response,
+			"pagination": gin.H{
+				"current_page": page,
+				"total_pages":  totalPages,
+				"total_items":  total,
+				"per_page":     limit,
+				"has_next":     page < totalPages,
+				"has_prev":     page > 1,
+			},
+		})
+	}
+}
+
+// ResendOutboxMessage queues a dead-lettered email for delivery again with a
+// fresh set of attempts
+func ResendOutboxMessage(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		messageID, err := strconv.ParseUint(c.Param("message_id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+			return
+		}
+
+		outboxStore := store.WithContext(c.Request.Context()).Outbox()
+		msg, err := outboxStore.FindByID(uint(messageID))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Outbox message not found"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Failed to fetch outbox message", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if msg.Status != models.OutboxDead {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only dead-lettered messages can be resent"})
+			return
+		}
+
+		if err := outboxStore.Requeue(msg.ID, time.Now()); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				// Someone else resent it in the meantime
+				c.JSON(http.StatusConflict, gin.H{"error": "Only dead-lettered messages can be resent"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Failed to requeue outbox message", "message_id", msg.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resend message"})
+			return
+		}
+		outbox.Wake()
+
+		slog.InfoContext(c.Request.Context(), "Outbox message queued for resend", "message_id", msg.ID)
+		c.JSON(http.StatusAccepted, gin.H{"message": "Message queued for delivery", "id": msg.ID})
+	}
+}
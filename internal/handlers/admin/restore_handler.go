@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/accountdeletion"
+	"ai-backend/internal/middleware"
+	"ai-backend/internal/models"
+	"ai-backend/internal/service"
+)
+
+type RestoreUserRequest struct {
+	Reason   string  `json:"reason" binding:"required,min=15"`
+	Username *string `json:"username" binding:"omitempty,min=3"` // replaces a username taken in the meantime
+	Email    *string `json:"email" binding:"omitempty,email"`    // replaces an email taken in the meantime
+}
+
+// RestoreUser restores a soft deleted or passive user, optionally renaming them
+// when their username or email was taken while they were gone
+func RestoreUser(userService *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid user ID", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		var req RestoreUserRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid request body", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		// Get current user from context
+		currentUser, exists := c.Get("user")
+		if !exists {
+			slog.WarnContext(c.Request.Context(), "User not found in context")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		cu, ok := currentUser.(*models.User)
+		if !ok {
+			slog.ErrorContext(c.Request.Context(), "Failed to cast user from context")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		targetUser, err := userService.WithContext(c.Request.Context()).Restore(cu, uint(userID), req.Username, req.Email, req.Reason)
+		switch {
+		case errors.Is(err, accountdeletion.ErrUsernameTaken):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    "Username is already taken by another user. Provide a new username.",
+				"conflict": "username",
+			})
+			return
+		case errors.Is(err, accountdeletion.ErrUsernameReserved):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Username is reserved"})
+			return
+		case errors.Is(err, accountdeletion.ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    "Email is already taken by another user. Provide a new email.",
+				"conflict": "email",
+			})
+			return
+		case errors.Is(err, accountdeletion.ErrErased):
+			c.JSON(http.StatusGone, gin.H{"error": "Account has been permanently erased"})
+			return
+		case errors.Is(err, accountdeletion.ErrMerged):
+			c.JSON(http.StatusGone, gin.H{"error": "Account was merged into another user"})
+			return
+		case errors.Is(err, accountdeletion.ErrGracePeriodEnded):
+			c.JSON(http.StatusGone, gin.H{"error": "Account deletion grace period has ended"})
+			return
+		case err != nil:
+			slog.ErrorContext(c.Request.Context(), "Failed to restore user", "target_user_id", userID, "error", err)
+			middleware.RespondWithError(c, err)
+			return
+		}
+
+		slog.InfoContext(c.Request.Context(), "User restored", "target_user_id", targetUser.ID, "reason", req.Reason)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "User restored successfully",
+			"user": gin.H{
+				"id":       targetUser.ID,
+				"username": targetUser.Username,
+				"email":    targetUser.Email,
+				"status":   targetUser.Status,
+			},
+		})
+	}
+}
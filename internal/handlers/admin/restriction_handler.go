@@ -0,0 +1,347 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/middleware"
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+	"ai-backend/internal/service"
+)
+
+type RestrictUserRequest struct {
+	UserID            uint                   `json:"user_id" binding:"required"`
+	Type              models.RestrictionType `json:"type" binding:"required,oneof=shadow_ban rate_limited read_only"`
+	Reason            string                 `json:"reason" binding:"required,min=15"`
+	Duration          string                 `json:"duration" binding:"required"` // number of days or "permanent"
+	PostLimit         *int                   `json:"post_limit" binding:"omitempty,min=1"`
+	PostWindowMinutes *int                   `json:"post_window_minutes" binding:"omitempty,min=1"`
+}
+
+type LiftRestrictionRequest struct {
+	Reason string `json:"reason" binding:"required,min=15"`
+}
+
+type RestrictionHistoryResponse struct {
+	ID                uint                   `json:"id"`
+	UserID            uint                   `json:"user_id"`
+	Type              models.RestrictionType `json:"type"`
+	RestrictedByID    uint                   `json:"restricted_by_id"`
+	RestrictedBy      string                 `json:"restricted_by"`
+	Reason            string                 `json:"reason"`
+	Duration          string                 `json:"duration"`
+	DurationDays      *int                   `json:"duration_days"`
+	PostLimit         *int                   `json:"post_limit"`
+	PostWindowMinutes *int                   `json:"post_window_minutes"`
+	StartDate         string                 `json:"start_date"`
+	EndDate           *string                `json:"end_date"`
+	IsActive          bool                   `json:"is_active"`
+	LiftedAt          *string                `json:"lifted_at"`
+	LiftedBy          *string                `json:"lifted_by"`
+	LiftReason        *string                `json:"lift_reason"`
+	CreatedAt         string                 `json:"created_at"`
+}
+
+// validateRestriction checks whether the current user is allowed to restrict the target user
+func validateRestriction(ctx context.Context, cu *models.User, targetUser *models.User, firstSuperAdminID uint) error {
+	if targetUser.ID == firstSuperAdminID {
+		slog.WarnContext(ctx, "Attempt to restrict first SUPER_ADMIN", "target_user_id", targetUser.ID)
+		return service.NewError(service.KindForbidden, "Cannot restrict first SUPER_ADMIN")
+	}
+
+	if cu.Role == models.RoleAdmin && targetUser.Role == models.RoleSuperAdmin {
+		slog.WarnContext(ctx, "Admin attempted to restrict SUPER_ADMIN", "target_user_id", targetUser.ID)
+		return service.NewError(service.KindForbidden, "Admin cannot restrict SUPER_ADMIN")
+	}
+
+	return nil
+}
+
+// RestrictUser applies a shadow-ban, rate limit or read-only restriction to a user
+func RestrictUser(store repository.Store, moderationService *service.ModerationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RestrictUserRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid request body", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+			return
+		}
+
+		if req.Type == models.RestrictionRateLimited && (req.PostLimit == nil || req.PostWindowMinutes == nil) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "post_limit and post_window_minutes are required for rate_limited restrictions"})
+			return
+		}
+		if req.Type != models.RestrictionRateLimited {
+			req.PostLimit = nil
+			req.PostWindowMinutes = nil
+		}
+
+		// Get current user from context
+		currentUser, exists := c.Get("user")
+		if !exists {
+			slog.WarnContext(c.Request.Context(), "User not found in context")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		cu, ok := currentUser.(*models.User)
+		if !ok {
+			slog.ErrorContext(c.Request.Context(), "Failed to cast user from context")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		restrictionStore := store.WithContext(c.Request.Context())
+
+		// Get target user
+		targetUser, err := restrictionStore.Users().FindByID(req.UserID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				slog.WarnContext(c.Request.Context(), "User not found", "target_user_id", req.UserID)
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Database error while fetching user", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		firstSuperAdminID, err := moderationService.WithContext(c.Request.Context()).FirstSuperAdminID()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		if err := validateRestriction(c.Request.Context(), cu, targetUser, firstSuperAdminID); err != nil {
+			middleware.RespondWithError(c, err)
+			return
+		}
+
+		// A user can only have one active restriction of each type
+		active, err := restrictionStore.Restrictions().HasActive(targetUser.ID, req.Type, time.Now())
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to check active restrictions", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if active {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("User already has an active %s restriction", req.Type)})
+			return
+		}
+
+		endDate, durationDays, err := service.CalculateBanEndDate(req.Duration)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid duration", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		restriction := models.RestrictionHistory{
+			UserID:            targetUser.ID,
+			RestrictedByID:    cu.ID,
+			Type:              req.Type,
+			Reason:            req.Reason,
+			DurationDays:      durationDays,
+			PostLimit:         req.PostLimit,
+			PostWindowMinutes: req.PostWindowMinutes,
+			StartDate:         time.Now(),
+			EndDate:           endDate,
+			IsActive:          true,
+		}
+
+		if err := restrictionStore.Restrictions().Create(&restriction); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to create restriction", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create restriction"})
+			return
+		}
+
+		slog.InfoContext(c.Request.Context(), "User restricted", "target_user_id", targetUser.ID, "type", req.Type)
+		c.JSON(http.StatusOK, gin.H{
+			"message":     "User restricted successfully",
+			"restriction": toRestrictionHistoryResponse(restriction, cu),
+		})
+	}
+}
+
+// LiftRestriction ends an active restriction before its end date
+func LiftRestriction(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid user ID", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		restrictionID, err := strconv.ParseUint(c.Param("restriction_id"), 10, 32)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid restriction ID", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restriction ID"})
+			return
+		}
+
+		var req LiftRestrictionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid request body", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		// Get current user from context
+		currentUser, exists := c.Get("user")
+		if !exists {
+			slog.WarnContext(c.Request.Context(), "User not found in context")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		cu, ok := currentUser.(*models.User)
+		if !ok {
+			slog.ErrorContext(c.Request.Context(), "Failed to cast user from context")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		restrictionStore := store.WithContext(c.Request.Context())
+		restriction, err := restrictionStore.Restrictions().FindActive(uint(userID), uint(restrictionID))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "No active restriction found"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Database error while fetching restriction", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		// Check if ADMIN is trying to lift a restriction applied by SUPER_ADMIN
+		if cu.Role == models.RoleAdmin && restriction.RestrictedBy.Role == models.RoleSuperAdmin {
+			slog.WarnContext(c.Request.Context(), "Admin attempted to lift restriction applied by SUPER_ADMIN", "restriction_id", restriction.ID)
+			c.JSON(http.StatusForbidden, gin.H{"error": "Cannot lift restriction applied by SUPER_ADMIN"})
+			return
+		}
+
+		now := time.Now()
+		restriction.IsActive = false
+		restriction.LiftedAt = &now
+		restriction.LiftedByID = &cu.ID
+		restriction.LiftedBy = *cu
+		restriction.LiftReason = &req.Reason
+
+		if err := restrictionStore.Restrictions().Save(restriction); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to lift restriction", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift restriction"})
+			return
+		}
+
+		slog.InfoContext(c.Request.Context(), "Restriction lifted", "restriction_id", restriction.ID, "target_user_id", userID)
+		c.JSON(http.StatusOK, gin.H{
+			"message":     "Restriction lifted successfully",
+			"restriction": toRestrictionHistoryResponse(*restriction, &restriction.RestrictedBy),
+		})
+	}
+}
+
+// GetUserRestrictionHistory returns the content restriction history for a specific user
+func GetUserRestrictionHistory(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid user ID", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		restrictionStore := store.WithContext(c.Request.Context())
+
+		// Check if user exists
+		user, err := restrictionStore.Users().FindByID(uint(userID))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Database error while fetching user", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		histories, err := restrictionStore.Restrictions().ListByUser(user.ID)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to fetch restriction histories", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch restriction histories"})
+			return
+		}
+
+		response := make([]RestrictionHistoryResponse, len(histories))
+		for i, history := range histories {
+			response[i] = toRestrictionHistoryResponse(history, &history.RestrictedBy)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"user": gin.H{
+				"id":       user.ID,
+				"username": user.Username,
+				"status":   user.Status,
+			},
+			"histories": response,
+		})
+	}
+}
+
+func toRestrictionHistoryResponse(history models.RestrictionHistory, restrictedBy *models.User) RestrictionHistoryResponse {
+	var endDate, liftedAt *string
+	if history.EndDate != nil {
+		formatted := history.EndDate.Format("2006-01-02 15:04:05")
+		endDate = &formatted
+	}
+	if history.LiftedAt != nil {
+		formatted := history.LiftedAt.Format("2006-01-02 15:04:05")
+		liftedAt = &formatted
+	}
+
+	// LiftedBy is empty when the lifting user was deleted
+	var liftedBy *string
+	if history.LiftedByID != nil && history.LiftedBy.Username != nil {
+		username := *history.LiftedBy.Username
+		liftedBy = &username
+	}
+
+	durationText := "permanent"
+	if history.DurationDays != nil {
+		durationText = strconv.Itoa(*history.DurationDays) + " days"
+	}
+
+	restrictedByName := ""
+	if restrictedBy != nil && restrictedBy.Username != nil {
+		restrictedByName = *restrictedBy.Username
+	}
+
+	return RestrictionHistoryResponse{
+		ID:                history.ID,
+		UserID:            history.UserID,
+		Type:              history.Type,
+		RestrictedByID:    history.RestrictedByID,
+		RestrictedBy:      restrictedByName,
+		Reason:            history.Reason,
+		Duration:          durationText,
+		DurationDays:      history.DurationDays,
+		PostLimit:         history.PostLimit,
+		PostWindowMinutes: history.PostWindowMinutes,
+		StartDate:         history.StartDate.Format("2006-01-02 15:04:05"),
+		EndDate:           endDate,
+		IsActive:          history.IsActive,
+		LiftedAt:          liftedAt,
+		LiftedBy:          liftedBy,
+		LiftReason:        history.LiftReason,
+		CreatedAt:         history.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
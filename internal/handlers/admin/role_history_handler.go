@@ -2,14 +2,14 @@ package admin
 
 import (
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 
 	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
 )
 
 type RoleHistoryResponse struct {
@@ -25,33 +25,32 @@ type RoleHistoryResponse struct {
 }
 
 // GetUserRoleHistory returns the role change history for a specific user
-func GetUserRoleHistory(db *gorm.DB) gin.HandlerFunc {
+func GetUserRoleHistory(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
 		if err != nil {
-			log.Printf("Invalid user ID: %v", err)
+			slog.WarnContext(c.Request.Context(), "Invalid user ID", "error", err)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 			return
 		}
 
+		roleStore := store.WithContext(c.Request.Context())
+
 		// Check if user exists
-		var user models.User
-		if err := db.First(&user, userID).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err := roleStore.Users().FindByID(uint(userID))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 				return
 			}
-			log.Printf("Database error while fetching user: %v", err)
+			slog.ErrorContext(c.Request.Context(), "Database error while fetching user", "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 			return
 		}
 
-		var histories []models.RoleHistory
-		if err := db.Preload("User").Preload("ChangedBy").
-			Where("user_id = ?", userID).
-			Order("created_at desc").
-			Find(&histories).Error; err != nil {
-			log.Printf("Failed to fetch role histories: %v", err)
+		histories, err := roleStore.RoleHistories().ListByUser(user.ID)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to fetch role histories", "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch role histories"})
 			return
 		}
@@ -83,7 +82,7 @@ func GetUserRoleHistory(db *gorm.DB) gin.HandlerFunc {
 }
 
 // GetAllRoleHistories returns all role change histories with pagination
-func GetAllRoleHistories(db *gorm.DB) gin.HandlerFunc {
+func GetAllRoleHistories(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
@@ -95,21 +94,9 @@ func GetAllRoleHistories(db *gorm.DB) gin.HandlerFunc {
 			limit = 10
 		}
 
-		offset := (page - 1) * limit
-
-		var total int64
-		if err := db.Model(&models.RoleHistory{}).Count(&total).Error; err != nil {
-			log.Printf("Failed to count role histories: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count role histories"})
-			return
-		}
-
-		var histories []models.RoleHistory
-		if err := db.Preload("User").Preload("ChangedBy").
-			Order("created_at desc").
-			Offset(offset).Limit(limit).
-			Find(&histories).Error; err != nil {
-			log.Printf("Failed to fetch role histories: %v", err)
+		histories, total, err := store.WithContext(c.Request.Context()).RoleHistories().List((page-1)*limit, limit)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to fetch role histories", "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch role histories"})
 			return
 		}
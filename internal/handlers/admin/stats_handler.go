@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/config"
+	"ai-backend/internal/repository"
+	"ai-backend/internal/stats"
+)
+
+const (
+	// maxStatsRangeDays limits how wide a single stats request can be
+	maxStatsRangeDays = 366
+)
+
+// statsCacheTTL returns how long computed stats are cached
+func statsCacheTTL() time.Duration {
+	return config.Get().Moderation.StatsCacheTTL
+}
+
+// parseStatsRange parses the inclusive from/to dates (YYYY-MM-DD), defaulting to the last 30 days
+func parseStatsRange(c *gin.Context) (time.Time, time.Time, bool) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	to := today
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -29)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return time.Time{}, time.Time{}, false
+	}
+	if to.Sub(from) > maxStatsRangeDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date range cannot exceed 366 days"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	// to is inclusive, queries use an exclusive upper bound
+	return from, to.AddDate(0, 0, 1), true
+}
+
+// GetDashboardStats returns aggregated user, moderation and content metrics for a date range
+func GetDashboardStats(store repository.Store) gin.HandlerFunc {
+	cache := stats.NewCache(statsCacheTTL())
+
+	return func(c *gin.Context) {
+		from, to, ok := parseStatsRange(c)
+		if !ok {
+			return
+		}
+
+		refresh := c.Query("refresh") == "true"
+		// Concurrent requests share the computation, so it is not cancelled with this one
+		snapshot, cached, err := cache.Get(store.Stats().Dashboard, from, to, refresh)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to compute dashboard stats", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute stats"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"stats":  snapshot,
+			"cached": cached,
+		})
+	}
+}
@@ -0,0 +1,553 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/config"
+	"ai-backend/internal/events"
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+	"ai-backend/internal/webhook"
+)
+
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	EventTypes  []string `json:"event_types" binding:"required,min=1"`
+	Description string   `json:"description" binding:"max=500"`
+	Active      *bool    `json:"active"` // defaults to true
+}
+
+// UpdateWebhookRequest changes the fields that are set
+type UpdateWebhookRequest struct {
+	URL         *string  `json:"url"`
+	EventTypes  []string `json:"event_types" binding:"omitempty,min=1"`
+	Description *string  `json:"description" binding:"omitempty,max=500"`
+	Active      *bool    `json:"active"`
+}
+
+// WebhookResponse describes a subscription. The secret is only returned when
+// it is created or rotated.
+type WebhookResponse struct {
+	ID          uint     `json:"id"`
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"event_types"`
+	Description string   `json:"description"`
+	Active      bool     `json:"active"`
+	Secret      string   `json:"secret,omitempty"`
+	CreatedByID uint     `json:"created_by_id"`
+	CreatedBy   string   `json:"created_by"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+func toWebhookResponse(subscription models.WebhookSubscription) WebhookResponse {
+	createdBy := ""
+	if subscription.CreatedBy.Username != nil {
+		createdBy = *subscription.CreatedBy.Username
+	}
+	eventTypes := subscription.Types()
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return WebhookResponse{
+		ID:          subscription.ID,
+		URL:         subscription.URL,
+		EventTypes:  eventTypes,
+		Description: subscription.Description,
+		Active:      subscription.Active,
+		CreatedByID: subscription.CreatedByID,
+		CreatedBy:   createdBy,
+		CreatedAt:   subscription.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   subscription.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// WebhookDeliveryResponse describes a delivery attempt of an event
+type WebhookDeliveryResponse struct {
+	ID             uint    `json:"id"`
+	EventID        string  `json:"event_id"`
+	EventType      string  `json:"event_type"`
+	Payload        string  `json:"payload"`
+	Status         string  `json:"status"`
+	Attempts       int     `json:"attempts"`
+	ResponseStatus *int    `json:"response_status"`
+	ResponseBody   *string `json:"response_body"`
+	LastError      *string `json:"last_error"`
+	DurationMs     *int64  `json:"duration_ms"`
+	NextAttemptAt  *string `json:"next_attempt_at"` // only set while pending
+	DeliveredAt    *string `json:"delivered_at"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+func toWebhookDeliveryResponse(delivery models.WebhookDelivery) WebhookDeliveryResponse {
+	var nextAttemptAt, deliveredAt *string
+	if delivery.Status == models.WebhookDeliveryPending {
+		formatted := delivery.NextAttemptAt.Format(time.RFC3339)
+		nextAttemptAt = &formatted
+	}
+	if delivery.DeliveredAt != nil {
+		formatted := delivery.DeliveredAt.Format(time.RFC3339)
+		deliveredAt = &formatted
+	}
+	return WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		ResponseBody:   delivery.ResponseBody,
+		LastError:      delivery.LastError,
+		DurationMs:     delivery.DurationMs,
+		NextAttemptAt:  nextAttemptAt,
+		DeliveredAt:    deliveredAt,
+		CreatedAt:      delivery.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      delivery.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// normalizeEventTypes validates eventTypes and returns them without duplicates,
+// in the order of events.Types, joined for storage
+func normalizeEventTypes(eventTypes []string) (string, error) {
+	requested := make(map[string]bool, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if !events.IsType(eventType) {
+			return "", fmt.Errorf("Unknown event type %q, expected one of %s", eventType, strings.Join(events.Types, ", "))
+		}
+		requested[eventType] = true
+	}
+
+	var normalized []string
+	for _, eventType := range events.Types {
+		if requested[eventType] {
+			normalized = append(normalized, eventType)
+		}
+	}
+	return strings.Join(normalized, ","), nil
+}
+
+// validateWebhookURL checks rawURL and reports a problem as a 400 response.
+// Outside development deliveries must be encrypted.
+func validateWebhookURL(c *gin.Context, rawURL string) bool {
+	if err := webhook.ValidateURL(rawURL, config.Get().Env != config.EnvDevelopment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid url: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// findWebhook loads the subscription named by the webhook_id parameter, writing
+// the error response when it cannot
+func findWebhook(c *gin.Context, store repository.Store) (*models.WebhookSubscription, bool) {
+	webhookID, err := strconv.ParseUint(c.Param("webhook_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return nil, false
+	}
+
+	subscription, err := store.Webhooks().FindSubscription(uint(webhookID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return nil, false
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to fetch webhook", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return subscription, true
+}
+
+// GetWebhookEventTypes lists the event types webhooks can subscribe to
+func GetWebhookEventTypes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"event_types": events.Types})
+	}
+}
+
+// GetWebhooks lists webhook subscriptions with pagination
+func GetWebhooks(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		if page < 1 {
+			page = 1
+		}
+		if limit < 1 || limit > 50 {
+			limit = 10
+		}
+
+		subscriptions, total, err := store.WithContext(c.Request.Context()).Webhooks().ListSubscriptions((page-1)*limit, limit)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to fetch webhooks", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+			return
+		}
+
+		response := make([]WebhookResponse, len(subscriptions))
+		for i, subscription := range subscriptions {
+			response[i] = toWebhookResponse(subscription)
+		}
+
+		totalPages := (int(total) + limit - 1) / limit
+
+		c.JSON(http.StatusOK, gin.H{
+			"webhooks": response,
+			"pagination": gin.H{
+				"current_page": page,
+				"total_pages":  totalPages,
+				"total_items":  total,
+				"per_page":     limit,
+				"has_next":     page < totalPages,
+				"has_prev":     page > 1,
+			},
+		})
+	}
+}
+
+// CreateWebhook subscribes a URL to event types. The response holds the
+// signing secret, which is not shown again.
+func CreateWebhook(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CreateWebhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid request body", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+			return
+		}
+
+		// Get current user from context
+		currentUser, exists := c.Get("user")
+		if !exists {
+			slog.WarnContext(c.Request.Context(), "User not found in context")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		cu, ok := currentUser.(*models.User)
+		if !ok {
+			slog.ErrorContext(c.Request.Context(), "Failed to cast user from context")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		req.URL = strings.TrimSpace(req.URL)
+		if !validateWebhookURL(c, req.URL) {
+			return
+		}
+		eventTypes, err := normalizeEventTypes(req.EventTypes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to generate webhook secret", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate webhook secret"})
+			return
+		}
+
+		subscription := models.WebhookSubscription{
+			URL:         req.URL,
+			Secret:      secret,
+			EventTypes:  eventTypes,
+			Description: strings.TrimSpace(req.Description),
+			Active:      req.Active == nil || *req.Active,
+			CreatedByID: cu.ID,
+		}
+		if err := store.WithContext(c.Request.Context()).Webhooks().CreateSubscription(&subscription); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to create webhook", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+			return
+		}
+		subscription.CreatedBy = *cu
+
+		response := toWebhookResponse(subscription)
+		response.Secret = subscription.Secret
+
+		slog.InfoContext(c.Request.Context(), "Webhook created", "subscription_id", subscription.ID, "events", subscription.EventTypes)
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "Webhook created successfully",
+			"webhook": response,
+		})
+	}
+}
+
+// GetWebhook returns a webhook subscription
+func GetWebhook(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subscription, ok := findWebhook(c, store.WithContext(c.Request.Context()))
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"webhook": toWebhookResponse(*subscription)})
+	}
+}
+
+// UpdateWebhook changes the URL, event types, description or state of a
+// webhook subscription. Disabling it also stops the retries of its pending deliveries.
+func UpdateWebhook(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req UpdateWebhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			slog.WarnContext(c.Request.Context(), "Invalid request body", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+			return
+		}
+
+		webhookStore := store.WithContext(c.Request.Context())
+		subscription, ok := findWebhook(c, webhookStore)
+		if !ok {
+			return
+		}
+
+		fields := map[string]interface{}{}
+		if req.URL != nil {
+			url := strings.TrimSpace(*req.URL)
+			if !validateWebhookURL(c, url) {
+				return
+			}
+			fields["url"] = url
+		}
+		if req.EventTypes != nil {
+			eventTypes, err := normalizeEventTypes(req.EventTypes)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			fields["event_types"] = eventTypes
+		}
+		if req.Description != nil {
+			fields["description"] = strings.TrimSpace(*req.Description)
+		}
+		if req.Active != nil {
+			fields["active"] = *req.Active
+		}
+		if len(fields) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+			return
+		}
+
+		if err := webhookStore.Webhooks().UpdateSubscription(subscription, fields); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to update webhook", "subscription_id", subscription.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+			return
+		}
+		updated, err := webhookStore.Webhooks().FindSubscription(subscription.ID)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to reload webhook", "subscription_id", subscription.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		slog.InfoContext(c.Request.Context(), "Webhook updated", "subscription_id", updated.ID)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Webhook updated successfully",
+			"webhook": toWebhookResponse(*updated),
+		})
+	}
+}
+
+// DeleteWebhook deletes a webhook subscription together with its delivery log
+func DeleteWebhook(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webhookStore := store.WithContext(c.Request.Context())
+		subscription, ok := findWebhook(c, webhookStore)
+		if !ok {
+			return
+		}
+
+		if err := webhookStore.Webhooks().DeleteSubscription(subscription.ID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Failed to delete webhook", "subscription_id", subscription.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+			return
+		}
+
+		slog.InfoContext(c.Request.Context(), "Webhook deleted", "subscription_id", subscription.ID)
+		c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+	}
+}
+
+// RotateWebhookSecret replaces the signing secret of a webhook subscription.
+// Deliveries are signed with the new secret from the next attempt on.
+func RotateWebhookSecret(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webhookStore := store.WithContext(c.Request.Context())
+		subscription, ok := findWebhook(c, webhookStore)
+		if !ok {
+			return
+		}
+
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to generate webhook secret", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate webhook secret"})
+			return
+		}
+		if err := webhookStore.Webhooks().UpdateSubscription(subscription, map[string]interface{}{"secret": secret}); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to rotate webhook secret", "subscription_id", subscription.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate webhook secret"})
+			return
+		}
+
+		response := toWebhookResponse(*subscription)
+		response.Secret = secret
+
+		slog.InfoContext(c.Request.Context(), "Webhook secret rotated", "subscription_id", subscription.ID)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Webhook secret rotated successfully",
+			"webhook": response,
+		})
+	}
+}
+
+// PingWebhook sends a test event to a webhook subscription right away and
+// returns the logged delivery. It answers 200 whether or not the receiver
+// accepted the ping; the delivery status tells.
+func PingWebhook(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subscription, ok := findWebhook(c, store.WithContext(c.Request.Context()))
+		if !ok {
+			return
+		}
+
+		delivery, err := webhook.Ping(c.Request.Context(), store, config.Get().Webhook, subscription)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to ping webhook", "subscription_id", subscription.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ping webhook"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":  delivery.Status == models.WebhookDeliverySucceeded,
+			"delivery": toWebhookDeliveryResponse(*delivery),
+		})
+	}
+}
+
+// GetWebhookDeliveries returns the delivery log of a webhook subscription,
+// newest first, optionally filtered by status
+func GetWebhookDeliveries(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		status := models.WebhookDeliveryStatus(c.Query("status"))
+
+		switch status {
+		case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status, expected pending, succeeded or failed"})
+			return
+		}
+		if page < 1 {
+			page = 1
+		}
+		if limit < 1 || limit > 50 {
+			limit = 10
+		}
+
+		webhookStore := store.WithContext(c.Request.Context())
+		subscription, ok := findWebhook(c, webhookStore)
+		if !ok {
+			return
+		}
+
+		deliveries, total, err := webhookStore.Webhooks().ListDeliveries(repository.WebhookDeliveryFilter{
+			SubscriptionID: subscription.ID,
+			Status:         status,
+			Offset:         (page - 1) * limit,
+			Limit:          limit,
+		})
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to fetch webhook deliveries", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook deliveries"})
+			return
+		}
+
+		response := make([]WebhookDeliveryResponse, len(deliveries))
+		for i, delivery := range deliveries {
+			response[i] = toWebhookDeliveryResponse(delivery)
+		}
+
+		totalPages := (int(total) + limit - 1) / limit
+
+		c.JSON(http.StatusOK, gin.H{
+			"deliveries": response,
+			"pagination": gin.H{
+				"current_page": page,
+				"total_pages":  totalPages,
+				"total_items":  total,
+				"per_page":     limit,
+				"has_next":     page < totalPages,
+				"has_prev":     page > 1,
+			},
+		})
+	}
+}
+
+// RedeliverWebhookDelivery queues a failed delivery again with a fresh set of
+// attempts. It is sent with the subscription's current URL and secret.
+func RedeliverWebhookDelivery(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webhookStore := store.WithContext(c.Request.Context())
+		subscription, ok := findWebhook(c, webhookStore)
+		if !ok {
+			return
+		}
+
+		deliveryID, err := strconv.ParseUint(c.Param("delivery_id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+			return
+		}
+
+		delivery, err := webhookStore.Webhooks().FindDelivery(subscription.ID, uint(deliveryID))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Failed to fetch webhook delivery", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if delivery.Status != models.WebhookDeliveryFailed {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only failed deliveries can be redelivered"})
+			return
+		}
+		if !subscription.Active {
+			c.JSON(http.StatusConflict, gin.H{"error": "Webhook is disabled"})
+			return
+		}
+
+		if err := webhookStore.Webhooks().Redeliver(subscription.ID, delivery.ID, time.Now()); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				// Someone else redelivered it in the meantime
+				c.JSON(http.StatusConflict, gin.H{"error": "Only failed deliveries can be redelivered"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Failed to redeliver webhook delivery", "delivery_id", delivery.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeliver webhook delivery"})
+			return
+		}
+		webhook.Wake()
+
+		slog.InfoContext(c.Request.Context(), "Webhook delivery queued for redelivery", "delivery_id", delivery.ID)
+		c.JSON(http.StatusAccepted, gin.H{"message": "Delivery queued", "id": delivery.ID})
+	}
+}
@@ -1,18 +1,16 @@
 package auth
 
 import (
-	"ai-backend/internal/database"
+	"ai-backend/internal/accountdeletion"
+	"ai-backend/internal/middleware"
 	"ai-backend/internal/models"
-	"ai-backend/pkg/email"
-	"ai-backend/pkg/utils"
-	"crypto/rand"
-	"encoding/hex"
-	"log"
+	"ai-backend/internal/service"
+	"errors"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type LoginRequest struct {
@@ -40,8 +38,17 @@ type ResetPasswordResponse struct {
 }
 
 type UpdatePasswordRequest struct {
-	ResetToken   string `json:"reset_token" binding:"required"`
-	NewPassword  string `json:"new_password" binding:"required,min=6"`
+	ResetToken  string `json:"reset_token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+type RequestReactivationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ReactivateAccountRequest struct {
+	Token    string  `json:"token" binding:"required"`
+	Username *string `json:"username" binding:"omitempty,min=3"` // required only if the old username was taken
 }
 
 type ChangePasswordRequest struct {
@@ -49,164 +56,95 @@ type ChangePasswordRequest struct {
 	NewPassword string `json:"new_password" binding:"required,min=6"`
 }
 
-// Login handles user login with email or username
-func Login(c *gin.Context) {
-	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	var user models.User
-	// Try to find user by email or username
-	result := database.DB.Where("email = ? OR username = ?", req.Identifier, req.Identifier).First(&user)
-	if result.Error != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
-	}
+type AuthHandler struct {
+	auth *service.AuthService
+}
 
-	// Check password
-	if user.Password == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
-	}
+func NewAuthHandler(auth *service.AuthService) *AuthHandler {
+	return &AuthHandler{auth: auth}
+}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
+// clientInfo returns the client's IP and user agent, recorded with the session for moderation tooling
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
 	}
+}
 
-	// Check user status
-	if user.Status == models.StatusBanned {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Account is banned"})
+// Login handles user login with email or username
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if user.Status == models.StatusFrozen {
-		// Aktif dondurma işlemi var mı kontrol et
-		var activeFreeze models.FreezeHistory
-		err := database.DB.Where("user_id = ? AND is_active = ? AND end_date > ?", user.ID, true, time.Now()).
-			First(&activeFreeze).Error
-		
-		if err == nil {
-			// Aktif dondurma işlemi var
-			remainingTime := time.Until(activeFreeze.EndDate).Hours() / 24
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Account is frozen",
-				"details": gin.H{
-					"reason": activeFreeze.Reason,
-					"end_date": activeFreeze.EndDate,
-					"remaining_days": int(remainingTime),
-				},
-			})
-			return
-		} else {
-			// Dondurma süresi dolmuş, hesabı aktif et
-			if err := database.DB.Model(&user).Update("status", models.StatusActive).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user status"})
-				return
-			}
-			
-			// Dondurma kaydını güncelle
-			if err := database.DB.Model(&activeFreeze).Updates(map[string]interface{}{
-				"is_active": false,
-				"unfrozen_at": time.Now(),
-			}).Error; err != nil {
-				log.Printf("Failed to update freeze history: %v", err)
-			}
-		}
-	}
-
-	if user.Status == models.StatusPassive {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Account is passive. Please contact support to reactivate your account."})
+	result, err := h.auth.WithContext(c.Request.Context()).Login(req.Identifier, req.Password, clientInfo(c))
+	var frozenErr *service.FrozenError
+	if errors.As(err, &frozenErr) {
+		// Aktif dondurma işlemi var
+		remainingTime := time.Until(frozenErr.Freeze.EndDate).Hours() / 24
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Account is frozen",
+			"details": gin.H{
+				"reason":         frozenErr.Freeze.Reason,
+				"end_date":       frozenErr.Freeze.EndDate,
+				"remaining_days": int(remainingTime),
+			},
+		})
 		return
 	}
-
-	// Generate JWT token
-	token, err := utils.GenerateToken(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		middleware.RespondWithError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, AuthResponse{
-		Token: token,
-		User:  user,
+		Token: result.Token,
+		User:  *result.User,
 	})
 }
 
 // Register handles user registration
-func Register(c *gin.Context) {
+func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Check if email already exists (excluding soft deleted users)
-	var existingUser models.User
-	if err := database.DB.
-		Where("email = ? AND deleted_at IS NULL", req.Email).
-		First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
-		return
-	}
-
-	// Check if username already exists (excluding soft deleted users)
-	if err := database.DB.
-		Where("username = ? AND deleted_at IS NULL", req.Username).
-		First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
-		return
-	}
-
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	result, err := h.auth.WithContext(c.Request.Context()).Register(service.RegisterInput{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: req.Password,
+	}, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
-		return
-	}
-
-	// Create user
-	now := time.Now()
-	password := string(hashedPassword)
-	user := models.User{
-		Username:      &req.Username,
-		Email:         &req.Email,
-		Password:      &password,
-		Role:         models.RoleUser,
-		Status:       models.StatusActive,
-		EmailVerified: &now,
-	}
-
-	if err := database.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
-		return
-	}
-
-	// Generate JWT token
-	token, err := utils.GenerateToken(user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		middleware.RespondWithError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, AuthResponse{
-		Token: token,
-		User:  user,
+		Token: result.Token,
+		User:  *result.User,
 	})
 }
 
 // RequestPasswordReset handles password reset requests
-func RequestPasswordReset(c *gin.Context) {
+func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
 	var req ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var user models.User
-	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+	sent, err := h.auth.WithContext(c.Request.Context()).RequestPasswordReset(req.Email)
+	if err != nil {
+		middleware.RespondWithError(c, err)
+		return
+	}
+
+	if !sent {
 		// Güvenlik nedeniyle kullanıcıya spesifik hata dönmüyoruz
 		c.JSON(http.StatusOK, ResetPasswordResponse{
 			Message: "If your email is registered, you will receive a password reset link",
@@ -214,93 +152,29 @@ func RequestPasswordReset(c *gin.Context) {
 		return
 	}
 
-	// Generate reset token
-	token := make([]byte, 32)
-	if _, err := rand.Read(token); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate reset token"})
-		return
-	}
-	resetToken := hex.EncodeToString(token)
-
-	// Set expiration time (1 hour from now)
-	expiresAt := time.Now().Add(1 * time.Hour)
-
-	// Save verification token
-	verificationToken := models.VerificationToken{
-		Identifier: *user.Email,
-		Token:      resetToken,
-		Expires:    expiresAt,
-	}
-
-	if err := database.DB.Create(&verificationToken).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reset token"})
-		return
-	}
-
-	// Send reset email
-	if err := email.SendPasswordResetEmail(*user.Email, resetToken); err != nil {
-		// Delete the token if email sending fails
-		database.DB.Delete(&verificationToken)
-		
-		// Log the actual error but don't expose it to the user
-		log.Printf("Failed to send reset email to %s: %v", *user.Email, err)
-		
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send reset email. Please try again later."})
-		return
-	}
-
-	log.Printf("Password reset email sent successfully to: %s", *user.Email)
 	c.JSON(http.StatusOK, ResetPasswordResponse{
 		Message: "Password reset instructions have been sent to your email",
 	})
 }
 
 // UpdatePassword handles password updates using reset token
-func UpdatePassword(c *gin.Context) {
+func (h *AuthHandler) UpdatePassword(c *gin.Context) {
 	var req UpdatePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Find valid token
-	var verificationToken models.VerificationToken
-	if err := database.DB.Where("token = ? AND expires > ?", req.ResetToken, time.Now()).First(&verificationToken).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+	if err := h.auth.WithContext(c.Request.Context()).ResetPassword(req.ResetToken, req.NewPassword); err != nil {
+		middleware.RespondWithError(c, err)
 		return
 	}
 
-	// Find user
-	var user models.User
-	if err := database.DB.Where("email = ?", verificationToken.Identifier).First(&user).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
-
-	// Hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
-		return
-	}
-
-	// Update password
-	password := string(hashedPassword)
-	user.Password = &password
-
-	if err := database.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
-		return
-	}
-
-	// Delete used token
-	database.DB.Delete(&verificationToken)
-
 	c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
 }
 
 // ChangePassword handles password changes for authenticated users
-func ChangePassword(c *gin.Context) {
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	var req ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -313,29 +187,74 @@ func ChangePassword(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
 		return
 	}
-	user := userInterface.(models.User)
+	user := userInterface.(*models.User)
 
-	// Verify old password
-	if err := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(req.OldPassword)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid old password"})
+	if err := h.auth.WithContext(c.Request.Context()).ChangePassword(user, req.OldPassword, req.NewPassword); err != nil {
+		middleware.RespondWithError(c, err)
 		return
 	}
 
-	// Hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
+
+// RequestReactivation emails a reactivation token to a passive user or to a deleted
+// user whose account is still inside the deletion grace period
+func (h *AuthHandler) RequestReactivation(c *gin.Context) {
+	var req RequestReactivationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Update password
-	password := string(hashedPassword)
-	user.Password = &password
+	if err := h.auth.WithContext(c.Request.Context()).RequestReactivation(req.Email); err != nil {
+		middleware.RespondWithError(c, err)
+		return
+	}
+
+	// Güvenlik nedeniyle kullanıcıya spesifik hata dönmüyoruz
+	c.JSON(http.StatusOK, gin.H{"message": "If your account can be reactivated, you will receive a reactivation email"})
+}
+
+// ReactivateAccount restores a passive or deleted account using an emailed reactivation token
+func (h *AuthHandler) ReactivateAccount(c *gin.Context) {
+	var req ReactivateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	if err := database.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+	user, err := h.auth.WithContext(c.Request.Context()).Reactivate(req.Token, req.Username)
+	switch {
+	case errors.Is(err, accountdeletion.ErrUsernameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Username is already taken. Please choose a new username."})
+		return
+	case errors.Is(err, accountdeletion.ErrUsernameReserved):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username is reserved. Please choose a new username."})
+		return
+	case errors.Is(err, accountdeletion.ErrEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Email is already used by another account. Please contact support."})
+		return
+	case errors.Is(err, accountdeletion.ErrErased), errors.Is(err, accountdeletion.ErrGracePeriodEnded), errors.Is(err, accountdeletion.ErrMerged):
+		c.JSON(http.StatusGone, gin.H{"error": "Account can no longer be restored"})
+		return
+	case err != nil:
+		var serviceErr service.Error
+		if !errors.As(err, &serviceErr) {
+			slog.ErrorContext(c.Request.Context(), "Failed to reactivate account", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reactivate account"})
+			return
+		}
+		middleware.RespondWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
-} 
\ No newline at end of file
+	slog.InfoContext(c.Request.Context(), "User reactivated", "target_user_id", user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account reactivated successfully. You can now log in.",
+		"user": gin.H{
+			"id":       user.ID,
+			"username": user.Username,
+			"status":   user.Status,
+		},
+	})
+}
@@ -0,0 +1,128 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/database"
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+)
+
+// commentTarget is accepted by the binding only to be rejected with a clear
+// error: the API has no comments to flag
+const commentTarget models.FlagTargetType = "comment"
+
+type CreateFlagRequest struct {
+	TargetType models.FlagTargetType `json:"target_type" binding:"required,oneof=question answer comment user"`
+	TargetID   uint                  `json:"target_id" binding:"required"`
+	Reason     models.FlagReason     `json:"reason" binding:"required,oneof=spam abuse harassment off_topic other"`
+	Details    string                `json:"details" binding:"max=1000"`
+}
+
+// CreateFlag reports a question, answer or user to the moderation queue
+func CreateFlag(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CreateFlagRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+			return
+		}
+
+		if req.TargetType == commentTarget {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Comments cannot be flagged; only questions, answers and users can"})
+			return
+		}
+
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		reporterID := userID.(uint)
+
+		if req.Reason == models.FlagReasonOther && req.Details == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Details are required when reason is other"})
+			return
+		}
+
+		flagStore := store.WithContext(c.Request.Context())
+
+		ownerID, err := flagStore.Flags().TargetOwner(req.TargetType, req.TargetID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Flag target not found"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Database error while fetching flag target", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		if ownerID == reporterID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot flag yourself or your own content"})
+			return
+		}
+
+		// Each user can flag the same target only once
+		flagged, err := flagStore.Flags().Exists(req.TargetType, req.TargetID, reporterID)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to check existing flag", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if flagged {
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already flagged this"})
+			return
+		}
+
+		flag := models.Flag{
+			TargetType: req.TargetType,
+			TargetID:   req.TargetID,
+			ReporterID: reporterID,
+			Reason:     req.Reason,
+			Details:    req.Details,
+			Status:     models.FlagStatusOpen,
+		}
+
+		hidden := false
+		if err := flagStore.Transaction(func(tx repository.Store) error {
+			if err := tx.Flags().Create(&flag); err != nil {
+				return err
+			}
+
+			var err error
+			hidden, err = tx.Flags().ApplyAutoHide(req.TargetType, req.TargetID)
+			return err
+		}); err != nil {
+			// A concurrent request from the same user inserted the flag first
+			if database.IsUniqueViolation(err) {
+				c.JSON(http.StatusConflict, gin.H{"error": "You have already flagged this"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Failed to create flag", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create flag"})
+			return
+		}
+
+		if hidden {
+			slog.InfoContext(c.Request.Context(), "Flag target hidden automatically", "target_type", req.TargetType, "target_id", req.TargetID)
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "Thank you, your report has been submitted",
+			"flag": gin.H{
+				"id":          flag.ID,
+				"target_type": flag.TargetType,
+				"target_id":   flag.TargetID,
+				"reason":      flag.Reason,
+				"status":      flag.Status,
+				"created_at":  flag.CreatedAt,
+			},
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package report
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateFlagRejectsComments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// The request is rejected before the store is used
+	router.POST("/flags", CreateFlag(nil))
+
+	body := `{"target_type": "comment", "target_id": 1, "reason": "spam"}`
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/flags", strings.NewReader(body)))
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+	if !strings.Contains(recorder.Body.String(), "Comments cannot be flagged") {
+		t.Errorf("got body %s, want the comment error", recorder.Body)
+	}
+}
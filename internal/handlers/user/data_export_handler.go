@@ -0,0 +1,134 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/dataexport"
+	"ai-backend/internal/export"
+	"ai-backend/internal/locale"
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+	"ai-backend/pkg/storage"
+)
+
+type DataExportHandler struct {
+	store    repository.Store
+	exporter *dataexport.Exporter
+	files    storage.Storage
+}
+
+func NewDataExportHandler(store repository.Store, exporter *dataexport.Exporter, files storage.Storage) *DataExportHandler {
+	return &DataExportHandler{store: store, exporter: exporter, files: files}
+}
+
+// RequestDataExport kullanıcının kişisel verilerinin dışa aktarımını başlatır
+func (h *DataExportHandler) RequestDataExport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Devam eden bir dışa aktarım var mı kontrol et (takılı kalanları arka plan işi başarısız sayar)
+	exports := h.store.WithContext(c.Request.Context()).DataExports()
+	inProgress, err := exports.HasInProgress(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check data exports"})
+		return
+	}
+	if inProgress {
+		c.JSON(http.StatusConflict, gin.H{"error": "A data export is already in progress"})
+		return
+	}
+
+	export := models.DataExport{
+		UserID: userID.(uint),
+		Status: models.DataExportPending,
+	}
+	if err := exports.Create(&export); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create data export"})
+		return
+	}
+
+	// The email is sent after the request is done, in the language it was made in
+	emailCtx := locale.WithContext(context.Background(), locale.FromContext(c.Request.Context()))
+	h.exporter.Start(emailCtx, export.ID)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Your data export is being prepared. You will receive an email with a download link.",
+		"export": gin.H{
+			"id":         export.ID,
+			"status":     export.Status,
+			"created_at": export.CreatedAt,
+		},
+	})
+}
+
+// GetDataExports kullanıcının dışa aktarım isteklerini listeler
+func (h *DataExportHandler) GetDataExports(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	exports, err := h.store.WithContext(c.Request.Context()).DataExports().ListByUser(userID.(uint), 20)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data exports"})
+		return
+	}
+
+	response := make([]gin.H, len(exports))
+	for i, export := range exports {
+		response[i] = gin.H{
+			"id":           export.ID,
+			"status":       export.Status,
+			"expires_at":   export.ExpiresAt,
+			"completed_at": export.CompletedAt,
+			"created_at":   export.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exports": response,
+	})
+}
+
+// DownloadDataExport e-posta ile gönderilen bağlantı üzerinden arşivi indirir
+func (h *DataExportHandler) DownloadDataExport(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Download token is required"})
+		return
+	}
+
+	archive, err := h.store.WithContext(c.Request.Context()).DataExports().FindDownloadable(dataexport.HashToken(token), time.Now())
+	if err != nil || archive.StorageKey == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid or expired download link"})
+		return
+	}
+
+	file, err := h.files.Open(*archive.StorageKey)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to open data export archive", "export_id", archive.ID, "error", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid or expired download link"})
+		return
+	}
+	defer file.Close()
+
+	filename := fmt.Sprintf("my-data-%s.zip", archive.CreatedAt.Format("20060102"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+	// Large archives can outlive the server's write timeout
+	if _, err := io.Copy(export.DeadlineWriter{W: c.Writer}, file); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to stream data export archive", "export_id", archive.ID, "error", err)
+	}
+}
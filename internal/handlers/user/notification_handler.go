@@ -0,0 +1,239 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/middleware"
+	"ai-backend/internal/models"
+	"ai-backend/internal/service"
+)
+
+type NotificationHandler struct {
+	notifications *service.NotificationService
+}
+
+func NewNotificationHandler(notifications *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notifications: notifications}
+}
+
+// NotificationResponse is a notification as returned by the API. Data holds
+// the type specific details, e.g. the question title of a new answer.
+type NotificationResponse struct {
+	ID        uint                    `json:"id"`
+	Type      models.NotificationType `json:"type"`
+	Data      json.RawMessage         `json:"data"`
+	Read      bool                    `json:"read"`
+	ReadAt    *string                 `json:"read_at"`
+	CreatedAt string                  `json:"created_at"`
+}
+
+func toNotificationResponse(n models.Notification) NotificationResponse {
+	var readAt *string
+	if n.ReadAt != nil {
+		formatted := n.ReadAt.Format(time.RFC3339)
+		readAt = &formatted
+	}
+	data := json.RawMessage(n.Data)
+	if !json.Valid(data) {
+		data = json.RawMessage("{}")
+	}
+	return NotificationResponse{
+		ID:        n.ID,
+		Type:      n.Type,
+		Data:      data,
+		Read:      n.ReadAt != nil,
+		ReadAt:    readAt,
+		CreatedAt: n.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// GetNotifications lists the current user's notifications with pagination,
+// optionally only the unread ones, along with the unread count
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	unreadOnly := c.Query("unread") == "true"
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	notifications := h.notifications.WithContext(c.Request.Context())
+	items, total, err := notifications.List(userID.(uint), service.ListNotificationsInput{
+		Page:       page,
+		Limit:      limit,
+		UnreadOnly: unreadOnly,
+	})
+	if err != nil {
+		middleware.RespondWithError(c, err)
+		return
+	}
+	unread, err := notifications.UnreadCount(userID.(uint))
+	if err != nil {
+		middleware.RespondWithError(c, err)
+		return
+	}
+
+	response := make([]NotificationResponse, len(items))
+	for i, n := range items {
+		response[i] = toNotificationResponse(n)
+	}
+
+	totalPages := (int(total) + limit - 1) / limit
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": response,
+		"unread_count":  unread,
+		"pagination": gin.H{
+			"current_page": page,
+			"total_pages":  totalPages,
+			"total_items":  total,
+			"per_page":     limit,
+			"has_next":     page < totalPages,
+			"has_prev":     page > 1,
+		},
+	})
+}
+
+// GetUnreadCount returns how many of the current user's notifications are unread
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	unread, err := h.notifications.WithContext(c.Request.Context()).UnreadCount(userID.(uint))
+	if err != nil {
+		middleware.RespondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread_count": unread})
+}
+
+// MarkRead marks one of the current user's notifications as read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	notificationID, err := strconv.ParseUint(c.Param("notification_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	notifications := h.notifications.WithContext(c.Request.Context())
+	if err := notifications.MarkRead(userID.(uint), uint(notificationID)); err != nil {
+		middleware.RespondWithError(c, err)
+		return
+	}
+	unread, err := notifications.UnreadCount(userID.(uint))
+	if err != nil {
+		middleware.RespondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Notification marked as read",
+		"unread_count": unread,
+	})
+}
+
+// MarkAllRead marks every unread notification of the current user as read
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	marked, err := h.notifications.WithContext(c.Request.Context()).MarkAllRead(userID.(uint))
+	if err != nil {
+		middleware.RespondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Notifications marked as read",
+		"marked":       marked,
+		"unread_count": 0,
+	})
+}
+
+// GetPreferences returns how the current user is told about each notification type
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	preferences, err := h.notifications.WithContext(c.Request.Context()).Preferences(userID.(uint))
+	if err != nil {
+		middleware.RespondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": preferences})
+}
+
+type PreferenceRequest struct {
+	Type   models.NotificationType `json:"type" binding:"required"`
+	InApp  *bool                   `json:"in_app" binding:"required"`
+	Email  *bool                   `json:"email" binding:"required"`
+	Digest *bool                   `json:"digest" binding:"required"`
+}
+
+type UpdatePreferencesRequest struct {
+	Preferences []PreferenceRequest `json:"preferences" binding:"required,min=1,dive"`
+}
+
+// UpdatePreferences changes how the current user is told about the given
+// notification types
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input := make([]service.NotificationPreference, len(req.Preferences))
+	for i, p := range req.Preferences {
+		input[i] = service.NotificationPreference{Type: p.Type}
+		input[i].InApp, input[i].Email, input[i].Digest = *p.InApp, *p.Email, *p.Digest
+	}
+
+	preferences, err := h.notifications.WithContext(c.Request.Context()).UpdatePreferences(userID.(uint), input)
+	if err != nil {
+		middleware.RespondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Notification preferences updated",
+		"preferences": preferences,
+	})
+}
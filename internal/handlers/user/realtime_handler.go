@@ -0,0 +1,279 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+
+	"ai-backend/internal/config"
+	"ai-backend/internal/metrics"
+	"ai-backend/internal/middleware"
+	"ai-backend/internal/models"
+	"ai-backend/internal/realtime"
+	"ai-backend/internal/repository"
+	"ai-backend/internal/service"
+)
+
+// writeTimeout bounds a single write to a stream, so a client that went away
+// is noticed at the next heartbeat at the latest
+const writeTimeout = 10 * time.Second
+
+type RealtimeHandler struct {
+	hub     *realtime.Hub
+	store   repository.Store
+	cfg     config.RealtimeConfig
+	metrics *metrics.Metrics
+}
+
+func NewRealtimeHandler(hub *realtime.Hub, store repository.Store, cfg config.RealtimeConfig, m *metrics.Metrics) *RealtimeHandler {
+	return &RealtimeHandler{hub: hub, store: store, cfg: cfg, metrics: m}
+}
+
+// follow subscribes to the events of a question the user can see. Hidden
+// questions can only be followed by their author and moderators.
+func (h *RealtimeHandler) follow(ctx context.Context, sub *realtime.Subscription, cu *models.User, questionID uint) error {
+	question, err := h.store.WithContext(ctx).Questions().FindByID(questionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return service.NewError(service.KindNotFound, fmt.Sprintf("Question %d not found", questionID))
+		}
+		slog.ErrorContext(ctx, "Failed to fetch question", "error", err)
+		return service.NewError(service.KindInternal, "Database error")
+	}
+	if question.IsHidden && question.UserID != cu.ID && cu.Role == models.RoleUser {
+		return service.NewError(service.KindNotFound, fmt.Sprintf("Question %d not found", questionID))
+	}
+
+	// The user's own topic does not count towards the limit
+	if !sub.Add(realtime.QuestionTopic(questionID), h.cfg.MaxSubscriptions+1) {
+		return service.NewError(service.KindInvalid, fmt.Sprintf("Cannot follow more than %d questions", h.cfg.MaxSubscriptions))
+	}
+	return nil
+}
+
+// parseQuestionIDs parses a comma separated list of question IDs
+func parseQuestionIDs(value string) ([]uint, error) {
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || id == 0 {
+			return nil, service.NewError(service.KindInvalid, "Invalid question ID: "+part)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// StreamEvents streams the current user's events and those of the questions
+// listed in ?questions=1,2 as server-sent events
+func (h *RealtimeHandler) StreamEvents(c *gin.Context) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	cu := userInterface.(*models.User)
+
+	questionIDs, err := parseQuestionIDs(c.Query("questions"))
+	if err != nil {
+		middleware.RespondWithError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	sub := h.hub.Subscribe(h.cfg.BufferSize, realtime.UserTopic(cu.ID))
+	defer sub.Close()
+	for _, id := range questionIDs {
+		if err := h.follow(ctx, sub, cu, id); err != nil {
+			middleware.RespondWithError(c, err)
+			return
+		}
+	}
+
+	h.metrics.ObserveRealtimeConnection("sse", true)
+	defer h.metrics.ObserveRealtimeConnection("sse", false)
+
+	// The stream outlives the server's write timeout, so deadlines are set per write
+	rc := http.NewResponseController(c.Writer)
+	write := func(chunk string) bool {
+		if err := rc.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return false
+		}
+		if _, err := c.Writer.WriteString(chunk); err != nil {
+			return false
+		}
+		c.Writer.Flush()
+		return true
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Keeps reverse proxies such as nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if !write(": connected\n\n") {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.Done():
+			return
+		case event := <-sub.Events():
+			payload, _ := json.Marshal(event)
+			if !write("event: " + event.Type + "\ndata: " + string(payload) + "\n\n") {
+				return
+			}
+		case <-heartbeat.C:
+			if !write(": ping\n\n") {
+				return
+			}
+		}
+	}
+}
+
+// realtimeCommand is a message from a WebSocket client
+type realtimeCommand struct {
+	Action     string `json:"action"` // subscribe or unsubscribe
+	QuestionID uint   `json:"question_id"`
+}
+
+// realtimeReply answers a command or keeps the connection alive
+type realtimeReply struct {
+	Type string `json:"type"` // subscribed, unsubscribed, error or ping
+	Data any    `json:"data,omitempty"`
+}
+
+// ServeWebSocket streams the same events as StreamEvents over a WebSocket. The
+// client follows and unfollows questions by sending commands.
+func (h *RealtimeHandler) ServeWebSocket(c *gin.Context) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	cu := userInterface.(*models.User)
+
+	questionIDs, err := parseQuestionIDs(c.Query("questions"))
+	if err != nil {
+		middleware.RespondWithError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	sub := h.hub.Subscribe(h.cfg.BufferSize, realtime.UserTopic(cu.ID))
+	defer sub.Close()
+	for _, id := range questionIDs {
+		if err := h.follow(ctx, sub, cu, id); err != nil {
+			middleware.RespondWithError(c, err)
+			return
+		}
+	}
+
+	server := websocket.Server{
+		// The token, not a cookie, authenticates the connection, so pages on other
+		// origins cannot use it on a user's behalf and the origin is not checked
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			h.serveWebSocket(ctx, ws, sub, cu)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *RealtimeHandler) serveWebSocket(ctx context.Context, ws *websocket.Conn, sub *realtime.Subscription, cu *models.User) {
+	defer ws.Close()
+	h.metrics.ObserveRealtimeConnection("websocket", true)
+	defer h.metrics.ObserveRealtimeConnection("websocket", false)
+
+	// The hijacked connection keeps the deadlines of the server's timeouts
+	ws.SetDeadline(time.Time{})
+	ws.MaxPayloadBytes = 1024
+
+	send := func(message any) bool {
+		ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+		return websocket.JSON.Send(ws, message) == nil
+	}
+
+	commands := make(chan realtimeCommand)
+	go func() {
+		defer close(commands)
+		for {
+			var command realtimeCommand
+			if err := websocket.JSON.Receive(ws, &command); err != nil {
+				return
+			}
+			select {
+			case commands <- command:
+			case <-sub.Done():
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(h.cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.Done():
+			return
+		case command, ok := <-commands:
+			if !ok {
+				return
+			}
+			if !send(h.handleCommand(ctx, sub, cu, command)) {
+				return
+			}
+		case event := <-sub.Events():
+			if !send(event) {
+				return
+			}
+		case <-heartbeat.C:
+			if !send(realtimeReply{Type: "ping"}) {
+				return
+			}
+		}
+	}
+}
+
+func (h *RealtimeHandler) handleCommand(ctx context.Context, sub *realtime.Subscription, cu *models.User, command realtimeCommand) realtimeReply {
+	if command.QuestionID == 0 {
+		return realtimeReply{Type: "error", Data: gin.H{"error": "question_id is required"}}
+	}
+
+	switch command.Action {
+	case "subscribe":
+		if err := h.follow(ctx, sub, cu, command.QuestionID); err != nil {
+			var serviceErr service.Error
+			if errors.As(err, &serviceErr) {
+				return realtimeReply{Type: "error", Data: gin.H{"error": serviceErr.Message, "question_id": command.QuestionID}}
+			}
+			return realtimeReply{Type: "error", Data: gin.H{"error": "Failed to subscribe", "question_id": command.QuestionID}}
+		}
+		return realtimeReply{Type: "subscribed", Data: gin.H{"question_id": command.QuestionID}}
+	case "unsubscribe":
+		sub.Remove(realtime.QuestionTopic(command.QuestionID))
+		return realtimeReply{Type: "unsubscribed", Data: gin.H{"question_id": command.QuestionID}}
+	}
+	return realtimeReply{Type: "error", Data: gin.H{"error": "Unknown action, expected subscribe or unsubscribe"}}
+}
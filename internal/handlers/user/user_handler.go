@@ -1,20 +1,15 @@
 package user
 
 import (
-	"ai-backend/internal/database"
+	"ai-backend/internal/middleware"
 	"ai-backend/internal/models"
-	"net/http"
-
-	"ai-backend/pkg/utils"
-	"log"
-
-	"time"
-
-	"fmt"
+	"ai-backend/internal/service"
+	"errors"
+	"log/slog"
 	"math"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type UpdateStatusRequest struct {
@@ -22,31 +17,23 @@ type UpdateStatusRequest struct {
 	Status models.UserStatus `json:"status" binding:"required"`
 }
 
-// isUserAllowedStatus checks if the status is allowed for regular users
-func isUserAllowedStatus(status models.UserStatus) bool {
-	allowedStatuses := []models.UserStatus{
-		models.StatusActive,
-		models.StatusPassive,
-		models.StatusFrozen,
-	}
+type UserHandler struct {
+	users *service.UserService
+}
 
-	for _, s := range allowedStatuses {
-		if status == s {
-			return true
-		}
-	}
-	return false
+func NewUserHandler(users *service.UserService) *UserHandler {
+	return &UserHandler{users: users}
 }
 
 // UpdateUserStatus handles user status updates
-func UpdateUserStatus(c *gin.Context) {
+func (h *UserHandler) UpdateUserStatus(c *gin.Context) {
 	// Get current user from context
 	userInterface, exists := c.Get("user")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
 		return
 	}
-	currentUser := userInterface.(models.User)
+	currentUser := userInterface.(*models.User)
 
 	var req UpdateStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -54,45 +41,9 @@ func UpdateUserStatus(c *gin.Context) {
 		return
 	}
 
-	// Find target user
-	var targetUser models.User
-	if err := database.DB.First(&targetUser, req.UserID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
-
-	// Check permissions and validate status
-	isAdmin := currentUser.Role == models.RoleAdmin || currentUser.Role == models.RoleSuperAdmin
-	isSelfUpdate := currentUser.ID == targetUser.ID
-
-	// Regular users can only update their own status
-	if !isAdmin && !isSelfUpdate {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only update your own status"})
-		return
-	}
-
-	// Regular users can't set banned status
-	if !isAdmin && req.Status == models.StatusBanned {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only administrators can set banned status"})
-		return
-	}
-
-	// Regular users can only set allowed statuses
-	if !isAdmin && !isUserAllowedStatus(req.Status) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status for user"})
-		return
-	}
-
-	// Prevent status update of SUPER_ADMIN by ADMIN
-	if targetUser.Role == models.RoleSuperAdmin && currentUser.Role == models.RoleAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot modify SUPER_ADMIN status"})
-		return
-	}
-
-	// Update user status
-	targetUser.Status = req.Status
-	if err := database.DB.Save(&targetUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user status"})
+	targetUser, err := h.users.WithContext(c.Request.Context()).UpdateStatus(currentUser, req.UserID, req.Status)
+	if err != nil {
+		middleware.RespondWithError(c, err)
 		return
 	}
 
@@ -105,20 +56,13 @@ func UpdateUserStatus(c *gin.Context) {
 	})
 }
 
-type UserHandler struct {
-	db *gorm.DB
-}
-
-func NewUserHandler(db *gorm.DB) *UserHandler {
-	return &UserHandler{db: db}
-}
-
 type UpdateProfileRequest struct {
 	Username  *string `json:"username" binding:"omitempty,min=3"`
 	Email     *string `json:"email" binding:"omitempty,email"`
 	FullName  *string `json:"full_name" binding:"omitempty,max=100"`
 	Bio       *string `json:"bio" binding:"omitempty,max=500"`
 	AvatarURL *string `json:"avatar_url" binding:"omitempty,url"`
+	Locale    *string `json:"locale" binding:"omitempty,max=10"`
 }
 
 // UpdateProfile güncelleme işlemini gerçekleştirir
@@ -136,64 +80,16 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	// Mevcut kullanıcıyı bul
-	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
-
-	// Email veya kullanıcı adı değişikliği varsa, benzersizlik kontrolü yap
-	if req.Email != nil && *req.Email != *user.Email {
-		var count int64
-		h.db.Model(&models.User{}).
-			Where("email = ? AND deleted_at IS NULL", req.Email).
-			Count(&count)
-		if count > 0 {
-			c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
-			return
-		}
-	}
-
-	if req.Username != nil && *req.Username != *user.Username {
-		var count int64
-		h.db.Model(&models.User{}).
-			Where("username = ? AND deleted_at IS NULL", req.Username).
-			Count(&count)
-		if count > 0 {
-			c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
-			return
-		}
-	}
-
-	// Güncelleme işlemi
-	updates := map[string]interface{}{}
-	
-	if req.Username != nil {
-		updates["username"] = req.Username
-	}
-	if req.Email != nil {
-		updates["email"] = req.Email
-	}
-	if req.FullName != nil {
-		updates["name"] = req.FullName
-	}
-	if req.Bio != nil {
-		updates["bio"] = req.Bio
-	}
-	if req.AvatarURL != nil {
-		updates["image"] = req.AvatarURL
-	}
-
-	// Güncelleme işlemini gerçekleştir
-	if err := h.db.Model(&user).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
-		return
-	}
-
-	// Güncellenmiş kullanıcı bilgilerini getir
-	if err := h.db.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated user"})
+	user, err := h.users.WithContext(c.Request.Context()).UpdateProfile(userID.(uint), service.ProfileInput{
+		Username:  req.Username,
+		Email:     req.Email,
+		FullName:  req.FullName,
+		Bio:       req.Bio,
+		AvatarURL: req.AvatarURL,
+		Locale:    req.Locale,
+	})
+	if err != nil {
+		middleware.RespondWithError(c, err)
 		return
 	}
 
@@ -206,7 +102,7 @@ type DeleteAccountRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-// DeleteAccount kullanıcı hesabını soft delete yapar
+// DeleteAccount kullanıcı hesabını siler ve kalıcı silme işlemini zamanlar
 func (h *UserHandler) DeleteAccount(c *gin.Context) {
 	// Kullanıcı kimliğini al
 	userID, exists := c.Get("userID")
@@ -221,32 +117,17 @@ func (h *UserHandler) DeleteAccount(c *gin.Context) {
 		return
 	}
 
-	// Mevcut kullanıcıyı bul
-	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
-
-	// Şifre kontrolü yap
-	if !utils.CheckPasswordHash(req.Password, *user.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
-		return
-	}
-
-	// Kullanıcıyı soft delete yap
-	if err := h.db.Delete(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+	// Şifreyi kontrol et, hesabı soft delete yap ve kalıcı silmeyi zamanla
+	deletion, err := h.users.WithContext(c.Request.Context()).DeleteAccount(userID.(uint), req.Password)
+	if err != nil {
+		middleware.RespondWithError(c, err)
 		return
 	}
 
-	// Kullanıcının tüm oturumlarını sonlandır
-	if err := h.db.Where("user_id = ?", userID).Delete(&models.Session{}).Error; err != nil {
-		log.Printf("Failed to delete user sessions: %v", err)
-	}
-
+	slog.InfoContext(c.Request.Context(), "Account deletion scheduled", "erase_at", deletion.ScheduledFor)
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Account deleted successfully",
+		"message":       "Account deleted successfully. It can be restored until the scheduled erasure date.",
+		"scheduled_for": deletion.ScheduledFor,
 	})
 }
 
@@ -270,60 +151,14 @@ func (h *UserHandler) FreezeAccount(c *gin.Context) {
 		return
 	}
 
-	// Mevcut kullanıcıyı bul
-	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
-
-	// Aktif dondurma işlemi var mı kontrol et
-	var activeFreeze models.FreezeHistory
-	err := h.db.Where("user_id = ? AND is_active = ? AND end_date > ?", userID, true, time.Now()).
-		First(&activeFreeze).Error
-	if err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Account is already frozen"})
-		return
-	}
-
-	// Yeni dondurma işlemi oluştur
-	startDate := time.Now()
-	endDate := startDate.AddDate(0, 0, req.Duration)
-
-	freezeHistory := models.FreezeHistory{
-		UserID:    uint(userID.(uint)),
-		Reason:    req.Reason,
-		Duration:  req.Duration,
-		StartDate: startDate,
-		EndDate:   endDate,
-		IsActive:  true,
-	}
-
-	// Transaction başlat
-	tx := h.db.Begin()
-
-	// Dondurma kaydını oluştur
-	if err := tx.Create(&freezeHistory).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create freeze record"})
-		return
-	}
-
-	// Kullanıcı durumunu güncelle
-	if err := tx.Model(&user).Update("status", models.StatusFrozen).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user status"})
-		return
-	}
-
-	// Transaction'ı tamamla
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete the freeze process"})
+	freezeHistory, err := h.users.WithContext(c.Request.Context()).Freeze(userID.(uint), req.Duration, req.Reason)
+	if err != nil {
+		middleware.RespondWithError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Account frozen successfully",
+		"message":        "Account frozen successfully",
 		"freeze_details": freezeHistory,
 	})
 }
@@ -337,8 +172,8 @@ func (h *UserHandler) GetFreezeHistory(c *gin.Context) {
 		return
 	}
 
-	var freezeHistory []models.FreezeHistory
-	if err := h.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&freezeHistory).Error; err != nil {
+	freezeHistory, err := h.users.WithContext(c.Request.Context()).FreezeHistory(userID.(uint))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch freeze history"})
 		return
 	}
@@ -359,11 +194,11 @@ type ListUsersQuery struct {
 }
 
 type PaginationResponse struct {
-	CurrentPage int  `json:"current_page"`
-	TotalPages  int  `json:"total_pages"`
-	TotalItems  int64  `json:"total_items"`
-	HasNext     bool `json:"has_next"`
-	HasPrev     bool `json:"has_prev"`
+	CurrentPage int   `json:"current_page"`
+	TotalPages  int   `json:"total_pages"`
+	TotalItems  int64 `json:"total_items"`
+	HasNext     bool  `json:"has_next"`
+	HasPrev     bool  `json:"has_prev"`
 }
 
 // ListUsers kullanıcıları listeler
@@ -374,62 +209,28 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		return
 	}
 
-	// Validate sort field
-	allowedSortFields := map[string]bool{
-		"created_at": true,
-		"username":   true,
-		"email":     true,
-	}
-	if !allowedSortFields[query.Sort] {
-		query.Sort = "created_at"
-	}
-
-	// Validate order
-	if query.Order != "asc" && query.Order != "desc" {
-		query.Order = "desc"
-	}
-
-	// Base query
-	db := h.db.Model(&models.User{})
-
-	// Apply search filter
-	if query.Search != "" {
-		searchTerm := "%" + query.Search + "%"
-		db = db.Where("username ILIKE ? OR email ILIKE ?", searchTerm, searchTerm)
-	}
-
-	// Apply role filter
-	if query.Role != "" {
-		db = db.Where("role = ?", query.Role)
-	}
-
-	// Apply status filter
-	if query.Status != "" {
-		db = db.Where("status = ?", query.Status)
-	}
-
-	// Count total items
-	var totalItems int64
-	if err := db.Count(&totalItems).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count users"})
+	users, totalItems, err := h.users.WithContext(c.Request.Context()).List(service.ListUsersInput{
+		Page:   query.Page,
+		Limit:  query.Limit,
+		Search: query.Search,
+		Role:   query.Role,
+		Status: query.Status,
+		Sort:   query.Sort,
+		Order:  query.Order,
+	})
+	if err != nil {
+		var serviceErr service.Error
+		if errors.As(err, &serviceErr) {
+			middleware.RespondWithError(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
 		return
 	}
 
 	// Calculate pagination
-	offset := (query.Page - 1) * query.Limit
 	totalPages := int(math.Ceil(float64(totalItems) / float64(query.Limit)))
 
-	// Get paginated users
-	var users []models.User
-	if err := db.
-		Order(fmt.Sprintf("%s %s", query.Sort, query.Order)).
-		Limit(query.Limit).
-		Offset(offset).
-		Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
-		return
-	}
-
 	// Prepare pagination response
 	pagination := PaginationResponse{
 		CurrentPage: query.Page,
@@ -443,4 +244,4 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		"users":      users,
 		"pagination": pagination,
 	})
-} 
\ No newline at end of file
+}
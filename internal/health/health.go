@@ -0,0 +1,150 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultTimeout bounds a check that does not set its own timeout
+const defaultTimeout = 2 * time.Second
+
+// CheckFunc reports a problem with a dependency by returning an error
+type CheckFunc func(ctx context.Context) error
+
+// Check is a named dependency check
+type Check struct {
+	Name    string
+	Check   CheckFunc
+	Timeout time.Duration
+	// Optional checks are reported but do not make the probe fail
+	Optional bool
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the JSON body of a probe
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Healthy reports whether the probe passed
+func (r Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Health runs the liveness and readiness checks of the application
+type Health struct {
+	mu           sync.RWMutex
+	liveness     []Check
+	readiness    []Check
+	shuttingDown atomic.Bool
+}
+
+func New() *Health {
+	return &Health{}
+}
+
+// AddLiveness adds a check that fails only when restarting the process would help
+func (h *Health) AddLiveness(check Check) {
+	h.mu.Lock()
+	h.liveness = append(h.liveness, check)
+	h.mu.Unlock()
+}
+
+// AddReadiness adds a check that must pass before the instance receives traffic
+func (h *Health) AddReadiness(check Check) {
+	h.mu.Lock()
+	h.readiness = append(h.readiness, check)
+	h.mu.Unlock()
+}
+
+// SetShuttingDown makes readiness fail so load balancers stop sending requests
+// while in-flight ones drain
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Liveness runs the liveness checks
+func (h *Health) Liveness(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := h.liveness
+	h.mu.RUnlock()
+	return run(ctx, checks)
+}
+
+// Readiness runs the readiness checks. It fails without running them once
+// shutdown has begun.
+func (h *Health) Readiness(ctx context.Context) Report {
+	if h.shuttingDown.Load() {
+		return Report{
+			Status: StatusFail,
+			Checks: map[string]Result{"shutdown": {Status: StatusFail, Error: "shutting down", Duration: "0s"}},
+		}
+	}
+
+	h.mu.RLock()
+	checks := h.readiness
+	h.mu.RUnlock()
+	return run(ctx, checks)
+}
+
+// run executes checks concurrently, each within its own timeout
+func run(ctx context.Context, checks []Check) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			timeout := check.Timeout
+			if timeout <= 0 {
+				timeout = defaultTimeout
+			}
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Check(checkCtx)
+			result := Result{Status: StatusOK, Optional: check.Optional, Duration: time.Since(start).Round(time.Microsecond).String()}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			report.Checks[check.Name] = result
+			if err != nil && !check.Optional {
+				report.Status = StatusFail
+			}
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// PingDB checks that the database accepts connections
+func PingDB(db *sql.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
@@ -0,0 +1,77 @@
+package locale
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is used when the client prefers no supported locale
+const Default = "en"
+
+// Supported lists the locales user-facing texts are translated to
+var Supported = []string{"en", "tr"}
+
+// IsSupported reports whether locale is one of Supported
+func IsSupported(locale string) bool {
+	for _, supported := range Supported {
+		if supported == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the supported locale the client prefers most according to an
+// Accept-Language header, e.g. "tr-TR,tr;q=0.9,en;q=0.8", or Default
+func Match(acceptLanguage string) string {
+	type preference struct {
+		locale string
+		q      float64
+	}
+
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		// Only the language matters, so tr-TR and tr both select tr
+		language, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if q > 0 && IsSupported(language) {
+			preferences = append(preferences, preference{language, q})
+		}
+	}
+	if len(preferences) == 0 {
+		return Default
+	}
+
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].q > preferences[j].q
+	})
+	return preferences[0].locale
+}
+
+type contextKey struct{}
+
+// WithContext returns a context carrying locale
+func WithContext(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext returns the locale stored in ctx, or Default
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(contextKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return Default
+}
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"ai-backend/internal/config"
+)
+
+// Setup makes a structured, redacting slog logger the default. Existing log.Printf
+// calls are routed through it as well, so they are redacted and formatted the same way.
+func Setup(cfg config.LogConfig) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return fmt.Errorf("invalid LOG_LEVEL %q: %w", cfg.Level, err)
+	}
+
+	options := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, options)
+	default:
+		return fmt.Errorf("invalid LOG_FORMAT %q, expected text or json", cfg.Format)
+	}
+
+	slog.SetDefault(slog.New(&contextHandler{Handler: handler}))
+	return nil
+}
+
+type contextKey struct{}
+
+// requestInfo describes the request a context belongs to. The user is only known
+// after authentication, so it is filled in later by the auth middleware.
+type requestInfo struct {
+	mu        sync.Mutex
+	requestID string
+	route     string
+	userID    uint
+}
+
+// WithRequest returns a context whose log records carry the request ID and route
+func WithRequest(ctx context.Context, requestID, route string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestInfo{requestID: requestID, route: route})
+}
+
+// RequestID returns the ID of the request ctx belongs to, or ""
+func RequestID(ctx context.Context) string {
+	info, ok := ctx.Value(contextKey{}).(*requestInfo)
+	if !ok {
+		return ""
+	}
+	return info.requestID
+}
+
+// SetUserID records the authenticated user on the request ctx belongs to
+func SetUserID(ctx context.Context, userID uint) {
+	if info, ok := ctx.Value(contextKey{}).(*requestInfo); ok {
+		info.mu.Lock()
+		info.userID = userID
+		info.mu.Unlock()
+	}
+}
+
+// contextHandler adds the request attributes of the record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info, ok := ctx.Value(contextKey{}).(*requestInfo); ok {
+		info.mu.Lock()
+		record.AddAttrs(slog.String("request_id", info.requestID))
+		if info.route != "" {
+			record.AddAttrs(slog.String("route", info.route))
+		}
+		if info.userID != 0 {
+			record.AddAttrs(slog.Uint64("user_id", uint64(info.userID)))
+		}
+		info.mu.Unlock()
+	}
+	// Link log records to the trace of the request
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", span.TraceID().String()),
+			slog.String("span_id", span.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+	jwtPattern   = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)
+	// Reset, reactivation and session tokens are 64 hex characters; shorter IDs such
+	// as deletion certificates stay readable
+	tokenPattern = regexp.MustCompile(`\b[0-9a-fA-F]{40,}\b`)
+)
+
+// sensitiveKeys are attribute key suffixes whose values are never logged, so
+// "new_password" is hidden but "password_reset_ttl" is not
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "api_key", "apikey", "cookie"}
+
+// Redact masks email addresses and hides tokens in s
+func Redact(s string) string {
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = tokenPattern.ReplaceAllString(s, redacted)
+	return emailPattern.ReplaceAllString(s, "$1***@$2")
+}
+
+// redactAttr is the ReplaceAttr hook of the default handler. It covers the message
+// too, so emails and tokens formatted into log.Printf strings are hidden as well.
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	for _, suffix := range sensitiveKeys {
+		if strings.HasSuffix(key, suffix) {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Redact(value.String()))
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return slog.String(attr.Key, Redact(err.Error()))
+		}
+	}
+	return attr
+}
@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "ai_backend"
+
+// Metrics holds the application's Prometheus collectors and the registry they are
+// registered with. Tests create their own with New and a fresh registry.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	logins       *prometheus.CounterVec
+	moderation   *prometheus.CounterVec
+	emails       *prometheus.CounterVec
+	deadLetters  *prometheus.CounterVec
+	jobDuration  *prometheus.HistogramVec
+	realtime     *prometheus.GaugeVec
+	realtimeDrop prometheus.Counter
+	webhooks     *prometheus.CounterVec
+}
+
+// New creates the application collectors and registers them, together with the
+// Go runtime and process collectors, with registry
+func New(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		Registry: registry,
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts by result (success or failure).",
+		}, []string{"result"}),
+		moderation: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "moderation_actions_total",
+			Help:      "Moderation actions by action (ban or unban).",
+		}, []string{"action"}),
+		emails: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "emails_sent_total",
+			Help:      "Email sends by kind and outcome (success or failure).",
+		}, []string{"kind", "outcome"}),
+		deadLetters: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "email_dead_letters_total",
+			Help:      "Queued emails given up on after the maximum number of attempts, by kind.",
+		}, []string{"kind"}),
+		jobDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "background_job_duration_seconds",
+			Help:      "Duration of background jobs and scheduler runs by job.",
+			Buckets:   []float64{0.01, 0.1, 0.5, 1, 5, 15, 60, 300, 900},
+		}, []string{"job"}),
+		realtime: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "realtime_connections",
+			Help:      "Open real-time connections by transport (sse or websocket).",
+		}, []string{"transport"}),
+		realtimeDrop: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "realtime_dropped_connections_total",
+			Help:      "Real-time connections closed because they did not keep up with their events.",
+		}),
+		webhooks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_deliveries_total",
+			Help:      "Webhook delivery attempts by event type and outcome (success, retry or failure).",
+		}, []string{"event_type", "outcome"}),
+	}
+
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.logins,
+		m.moderation,
+		m.emails,
+		m.deadLetters,
+		m.jobDuration,
+		m.realtime,
+		m.realtimeDrop,
+		m.webhooks,
+	)
+	return m
+}
+
+// RegisterDB exports the connection pool statistics of db
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	return m.Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveRequest records a finished HTTP request
+func (m *Metrics) ObserveRequest(method, route, status string, duration time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, status).Inc()
+	m.httpDuration.WithLabelValues(method, route, status).Observe(duration.Seconds())
+}
+
+// ObserveLogin records a login attempt
+func (m *Metrics) ObserveLogin(success bool) {
+	m.logins.WithLabelValues(result(success)).Inc()
+}
+
+// ObserveModeration records a completed moderation action such as "ban" or "unban"
+func (m *Metrics) ObserveModeration(action string) {
+	m.moderation.WithLabelValues(action).Inc()
+}
+
+// ObserveEmail records an email send and whether it failed
+func (m *Metrics) ObserveEmail(kind string, err error) {
+	m.emails.WithLabelValues(kind, result(err == nil)).Inc()
+}
+
+// ObserveDeadLetter records a queued email that will not be retried anymore
+func (m *Metrics) ObserveDeadLetter(kind string) {
+	m.deadLetters.WithLabelValues(kind).Inc()
+}
+
+// ObserveJob records how long a background job ran
+func (m *Metrics) ObserveJob(job string, duration time.Duration) {
+	m.jobDuration.WithLabelValues(job).Observe(duration.Seconds())
+}
+
+// ObserveRealtimeConnection records a real-time connection being opened or closed
+func (m *Metrics) ObserveRealtimeConnection(transport string, open bool) {
+	if open {
+		m.realtime.WithLabelValues(transport).Inc()
+	} else {
+		m.realtime.WithLabelValues(transport).Dec()
+	}
+}
+
+// ObserveRealtimeDrop records a real-time connection closed for falling behind
+func (m *Metrics) ObserveRealtimeDrop() {
+	m.realtimeDrop.Inc()
+}
+
+// ObserveWebhookDelivery records a webhook delivery attempt. outcome is success,
+// retry when it will be tried again, or failure when it was given up on.
+func (m *Metrics) ObserveWebhookDelivery(eventType, outcome string) {
+	m.webhooks.WithLabelValues(eventType, outcome).Inc()
+}
+
+func result(success bool) string {
+	if success {
+		return "success"
+	}
+	return "failure"
+}
@@ -1,7 +1,8 @@
 package middleware
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -15,7 +16,7 @@ func AdminRoleMiddleware(allowedRoles []models.UserRole) gin.HandlerFunc {
 		// Get user from context (set by AuthMiddleware)
 		user, exists := c.Get("user")
 		if !exists {
-			log.Print("User not found in context")
+			slog.WarnContext(c.Request.Context(), "User not found in context")
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			c.Abort()
 			return
@@ -23,7 +24,7 @@ func AdminRoleMiddleware(allowedRoles []models.UserRole) gin.HandlerFunc {
 
 		u, ok := user.(*models.User)
 		if !ok {
-			log.Printf("Failed to cast user from context. Type: %T", user)
+			slog.ErrorContext(c.Request.Context(), "Failed to cast user from context", "type", fmt.Sprintf("%T", user))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 			c.Abort()
 			return
@@ -38,13 +39,13 @@ func AdminRoleMiddleware(allowedRoles []models.UserRole) gin.HandlerFunc {
 		}
 
 		if !hasRole {
-			log.Printf("Insufficient permissions. User Role: %s, Required Roles: %v", u.Role, allowedRoles)
+			slog.WarnContext(c.Request.Context(), "Insufficient permissions", "role", u.Role, "required_roles", allowedRoles)
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 			c.Abort()
 			return
 		}
 
-		log.Printf("Access granted for user ID: %d with role: %s", u.ID, u.Role)
+		slog.DebugContext(c.Request.Context(), "Access granted", "role", u.Role)
 		c.Next()
 	}
 } 
\ No newline at end of file
@@ -1,18 +1,20 @@
 package middleware
 
 import (
-	"ai-backend/internal/database"
+	"ai-backend/internal/logging"
 	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
 	"ai-backend/pkg/utils"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware verifies the JWT token and sets the user in the context
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware verifies the JWT token and sets the user, loaded from store, in the context
+func AuthMiddleware(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -29,44 +31,71 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Validate token
-		claims, err := utils.ValidateToken(parts[1])
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
-
-		// Get user from database
-		var user models.User
-		if err := database.DB.First(&user, claims.UserID).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
-			c.Abort()
-			return
-		}
+		authenticate(c, store, parts[1])
+	}
+}
 
-		// Check user status
-		if user.Status == models.StatusBanned {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Account is banned"})
-			c.Abort()
+// StreamAuthMiddleware works like AuthMiddleware but also accepts the token in
+// the access_token query parameter, because browsers cannot set headers on
+// EventSource and WebSocket requests
+func StreamAuthMiddleware(store repository.Store) gin.HandlerFunc {
+	auth := AuthMiddleware(store)
+	return func(c *gin.Context) {
+		token := c.Query("access_token")
+		if token == "" || c.GetHeader("Authorization") != "" {
+			auth(c)
 			return
 		}
+		authenticate(c, store, token)
+	}
+}
 
-		if user.Status == models.StatusFrozen {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Account is frozen"})
-			c.Abort()
-			return
-		}
+// authenticate loads the user the token was issued to and sets it in the context
+func authenticate(c *gin.Context, store repository.Store, token string) {
+	// Validate token
+	claims, err := utils.ValidateToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Abort()
+		return
+	}
 
-		log.Printf("User authenticated - ID: %d, Role: %s", user.ID, user.Role)
+	// Get user from database
+	user, err := store.WithContext(c.Request.Context()).Users().FindByID(claims.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		c.Abort()
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load authenticated user", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		c.Abort()
+		return
+	}
 
-		// Set user in context as pointer
-		c.Set("user", &user)
-		c.Set("userID", user.ID)
-		c.Set("userRole", user.Role)
+	// Check user status
+	if user.Status == models.StatusBanned {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is banned"})
+		c.Abort()
+		return
+	}
 
-		c.Next()
+	if user.Status == models.StatusFrozen {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is frozen"})
+		c.Abort()
+		return
 	}
+
+	logging.SetUserID(c.Request.Context(), user.ID)
+	slog.DebugContext(c.Request.Context(), "User authenticated", "role", user.Role)
+
+	// Set user in context as pointer
+	c.Set("user", user)
+	c.Set("userID", user.ID)
+	c.Set("userRole", user.Role)
+
+	c.Next()
 }
 
 // RoleMiddleware checks if the user has the required role
@@ -74,24 +103,24 @@ func RoleMiddleware(roles ...models.UserRole) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("userRole")
 		if !exists {
-			log.Printf("User role not found in context")
+			slog.WarnContext(c.Request.Context(), "User role not found in context")
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "User role not found"})
 			c.Abort()
 			return
 		}
 
 		role := userRole.(models.UserRole)
-		log.Printf("Checking role access - User Role: %s, Required Roles: %v", role, roles)
+		slog.DebugContext(c.Request.Context(), "Checking role access", "role", role, "required_roles", roles)
 
 		for _, allowedRole := range roles {
 			if role == allowedRole {
-				log.Printf("Role access granted for role: %s", role)
+				slog.DebugContext(c.Request.Context(), "Role access granted", "role", role)
 				c.Next()
 				return
 			}
 		}
 
-		log.Printf("Access denied - User Role: %s does not match required roles: %v", role, roles)
+		slog.WarnContext(c.Request.Context(), "Access denied", "role", role, "required_roles", roles)
 		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 		c.Abort()
 	}
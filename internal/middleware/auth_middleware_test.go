@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+	"ai-backend/pkg/utils"
+)
+
+// userStore holds the users the middleware can load; only the user repository is implemented
+type userStore struct {
+	repository.Store
+	users map[uint]models.User
+	err   error
+}
+
+func (s *userStore) WithContext(context.Context) repository.Store { return s }
+func (s *userStore) Users() repository.UserRepository             { return userRepository{s: s} }
+
+type userRepository struct {
+	repository.UserRepository
+	s *userStore
+}
+
+func (r userRepository) FindByID(id uint) (*models.User, error) {
+	if r.s.err != nil {
+		return nil, r.s.err
+	}
+	user, ok := r.s.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &user, nil
+}
+
+func TestAuthMiddlewareLoadsUserFromStore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	username, email := "alice", "alice@example.com"
+	user := models.User{Username: &username, Email: &email, Role: models.RoleUser, Status: models.StatusActive}
+	user.ID = 7
+	token, err := utils.GenerateToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	banned := user
+	banned.Status = models.StatusBanned
+
+	tests := []struct {
+		name  string
+		store *userStore
+		want  int
+	}{
+		{"active user", &userStore{users: map[uint]models.User{user.ID: user}}, http.StatusOK},
+		{"banned user", &userStore{users: map[uint]models.User{user.ID: banned}}, http.StatusForbidden},
+		{"unknown user", &userStore{users: map[uint]models.User{}}, http.StatusUnauthorized},
+		{"database error", &userStore{err: errors.New("connection refused")}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/me", AuthMiddleware(tt.store), func(c *gin.Context) {
+				if c.MustGet("userID").(uint) != user.ID {
+					t.Errorf("user ID in context is %v, want %d", c.MustGet("userID"), user.ID)
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/models"
+	"ai-backend/internal/moderation"
+	"ai-backend/internal/repository"
+)
+
+// ContentRestrictionMiddleware enforces read-only and rate-limited restrictions on content write routes.
+// It must run after AuthMiddleware. Shadow-banned users are allowed through with "shadowBanned" set in the context.
+// No route mounts it yet because the API has no content write routes; mount it on them when they are added.
+func ContentRestrictionMiddleware(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			slog.WarnContext(c.Request.Context(), "User ID not found in context")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		restrictions := store.WithContext(c.Request.Context()).Restrictions()
+		active, err := restrictions.ListActive(userID.(uint))
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to fetch content restrictions", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			c.Abort()
+			return
+		}
+
+		if moderation.FindRestriction(active, models.RestrictionReadOnly) != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account is in read-only mode"})
+			c.Abort()
+			return
+		}
+
+		if limited := moderation.FindRestriction(active, models.RestrictionRateLimited); limited != nil &&
+			limited.PostLimit != nil && limited.PostWindowMinutes != nil {
+			window := time.Duration(*limited.PostWindowMinutes) * time.Minute
+			count, err := restrictions.CountRecentPosts(userID.(uint), time.Now().Add(-window))
+			if err != nil {
+				slog.ErrorContext(c.Request.Context(), "Failed to count recent posts", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				c.Abort()
+				return
+			}
+			if count >= int64(*limited.PostLimit) {
+				c.JSON(http.StatusTooManyRequests, gin.H{
+					"error": fmt.Sprintf("Post limit reached: %d posts per %d minutes", *limited.PostLimit, *limited.PostWindowMinutes),
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set("shadowBanned", moderation.FindRestriction(active, models.RestrictionShadowBan) != nil)
+		c.Next()
+	}
+}
@@ -1,23 +1,21 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/service"
 )
 
-// AppError represents a custom error type
+// AppError is the error body written by ErrorHandler
 type AppError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 }
 
-// Error implements the error interface
-func (e AppError) Error() string {
-	return fmt.Sprintf("error code: %d, message: %s", e.Code, e.Message)
-}
-
 // ErrorResponse represents the structure of the error response
 type ErrorResponse struct {
 	Error AppError `json:"error"`
@@ -33,8 +31,10 @@ func ErrorHandler() gin.HandlerFunc {
 			err := c.Errors.Last()
 			
 			// Check if it's our custom error type
-			if appErr, ok := err.Err.(AppError); ok {
-				c.JSON(appErr.Code, ErrorResponse{Error: appErr})
+			var serviceErr service.Error
+			if errors.As(err.Err, &serviceErr) {
+				code := StatusCode(serviceErr.Kind)
+				c.JSON(code, ErrorResponse{Error: AppError{Code: code, Message: serviceErr.Message}})
 				return
 			}
 
@@ -50,10 +50,32 @@ func ErrorHandler() gin.HandlerFunc {
 	}
 }
 
-// NewAppError creates a new AppError instance
-func NewAppError(code int, message string) AppError {
-	return AppError{
-		Code:    code,
-		Message: message,
+// statusCodes maps the kinds of service errors to response status codes
+var statusCodes = map[service.ErrorKind]int{
+	service.KindInternal:     http.StatusInternalServerError,
+	service.KindInvalid:      http.StatusBadRequest,
+	service.KindUnauthorized: http.StatusUnauthorized,
+	service.KindForbidden:    http.StatusForbidden,
+	service.KindNotFound:     http.StatusNotFound,
+	service.KindConflict:     http.StatusConflict,
+}
+
+// StatusCode returns the response status of a service error of kind
+func StatusCode(kind service.ErrorKind) int {
+	if code, ok := statusCodes[kind]; ok {
+		return code
 	}
-} 
\ No newline at end of file
+	return http.StatusInternalServerError
+}
+
+// RespondWithError writes err as a JSON error. A service.Error is answered with
+// the status of its kind and its message; anything else is logged and answered with 500.
+func RespondWithError(c *gin.Context, err error) {
+	var serviceErr service.Error
+	if errors.As(err, &serviceErr) {
+		c.JSON(StatusCode(serviceErr.Kind), gin.H{"error": serviceErr.Message})
+		return
+	}
+	slog.ErrorContext(c.Request.Context(), "Request failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/locale"
+)
+
+// Locale stores the locale the client prefers, from its Accept-Language
+// header, in the request context, so emails sent while handling the request
+// are written in the user's language
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		preferred := locale.Match(c.GetHeader("Accept-Language"))
+		c.Request = c.Request.WithContext(locale.WithContext(c.Request.Context(), preferred))
+		c.Next()
+	}
+}
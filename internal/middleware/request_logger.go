@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/logging"
+)
+
+// RequestIDHeader carries the request ID between the client, proxies and this service
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits incoming IDs so clients cannot inject arbitrary text into logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._\-]{1,128}$`)
+
+// newRequestID returns 16 random bytes, hex encoded
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(id)
+}
+
+// RequestLogger assigns every request an ID, taken from X-Request-ID when the
+// client sent a valid one, returns it in the response and adds it to every log
+// record written with the request context. It logs one line per request.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		// The route template keeps IDs and tokens in the path out of the logs
+		route := c.FullPath()
+		c.Request = c.Request.WithContext(logging.WithRequest(c.Request.Context(), requestID, route))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		slog.Log(c.Request.Context(), level, "Request completed",
+			"method", c.Request.Method,
+			"status", status,
+			"duration", time.Since(start),
+			"ip", c.ClientIP(),
+			"bytes", c.Writer.Size(),
+		)
+	}
+}
@@ -0,0 +1,123 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// openTestSchema connects to MIGRATIONS_TEST_DATABASE_URL with a new empty schema
+// on the search path, dropping it when the test ends. The test is skipped when
+// the variable is not set.
+func openTestSchema(t *testing.T) *sql.DB {
+	t.Helper()
+	databaseURL := os.Getenv("MIGRATIONS_TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("MIGRATIONS_TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("migrations_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(`CREATE SCHEMA "` + schema + `"`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA "` + schema + `" CASCADE`); err != nil {
+			t.Errorf("failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		t.Fatalf("MIGRATIONS_TEST_DATABASE_URL must be a URL: %v", err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	db, err := sql.Open("pgx", u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestUpAdoptsAutoMigrateBaseline(t *testing.T) {
+	db := openTestSchema(t)
+	ctx := context.Background()
+
+	baseline, err := os.ReadFile("testdata/automigrate_baseline.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, string(baseline)); err != nil {
+		t.Fatalf("failed to create baseline schema: %v", err)
+	}
+
+	migrator, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("migrate up on a baseline database failed: %v", err)
+	}
+	if err := migrator.Verify(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, column := range []struct{ table, name string }{
+		{"sessions", "ip_address"},
+		{"sessions", "user_agent"},
+		{"questions", "is_hidden"},
+		{"answers", "is_hidden"},
+		{"users", "locale"},
+		{"users", "is_placeholder"},
+	} {
+		var count int
+		if err := db.QueryRowContext(ctx, `SELECT count(*) FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2`,
+			column.table, column.name).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("column %s.%s is missing after migrate up", column.table, column.name)
+		}
+	}
+}
+
+func TestUpAndDownOnEmptyDatabase(t *testing.T) {
+	db := openTestSchema(t)
+	ctx := context.Background()
+
+	migrator, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("migrate up failed: %v", err)
+	}
+	if applied != len(migrator.migrations) {
+		t.Errorf("applied %d migrations, want %d", applied, len(migrator.migrations))
+	}
+
+	reverted, err := migrator.Down(ctx, applied)
+	if err != nil {
+		t.Fatalf("migrate down failed: %v", err)
+	}
+	if reverted != applied {
+		t.Errorf("reverted %d migrations, want %d", reverted, applied)
+	}
+}
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS "user_merges";
+DROP TABLE IF EXISTS "account_deletions";
+DROP TABLE IF EXISTS "data_exports";
+DROP TABLE IF EXISTS "flags";
+DROP TABLE IF EXISTS "restriction_histories";
+DROP TABLE IF EXISTS "blocklist_entries";
+DROP TABLE IF EXISTS "bulk_jobs";
+DROP TABLE IF EXISTS "ban_histories";
+DROP TABLE IF EXISTS "role_histories";
+DROP TABLE IF EXISTS "freeze_histories";
+DROP TABLE IF EXISTS "votes";
+DROP TABLE IF EXISTS "answers";
+DROP TABLE IF EXISTS "questions";
+DROP TABLE IF EXISTS "verification_tokens";
+DROP TABLE IF EXISTS "sessions";
+DROP TABLE IF EXISTS "accounts";
+DROP TABLE IF EXISTS "users";
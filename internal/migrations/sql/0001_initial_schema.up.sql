@@ -0,0 +1,346 @@
+-- Schema of the models when versioned migrations were introduced. Every statement
+-- is idempotent. Databases created by AutoMigrate from the original models lack
+-- columns added later to sessions, questions and answers; the ALTER TABLE
+-- statements after those tables add them, so such databases can adopt
+-- versioned migrations.
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" varchar(255),
+    "username" varchar(255),
+    "email" varchar(255),
+    "emailVerified" timestamptz,
+    "password" text,
+    "image" text,
+    "role" varchar(50) NOT NULL DEFAULT 'USER',
+    "status" varchar(50) NOT NULL DEFAULT 'active',
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email") WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_username" ON "users" ("username") WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "accounts" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "type" varchar(255) NOT NULL,
+    "provider" varchar(255) NOT NULL,
+    "providerAccountId" varchar(255) NOT NULL,
+    "refresh_token" text,
+    "access_token" text,
+    "expires_at" bigint,
+    "id_token" text,
+    "scope" text,
+    "session_state" text,
+    "token_type" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_accounts" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_accounts_deleted_at" ON "accounts" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "sessions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "expires" timestamptz NOT NULL,
+    "sessionToken" varchar(255) NOT NULL,
+    "ip_address" varchar(45),
+    "user_agent" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_sessions" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+ALTER TABLE "sessions" ADD COLUMN IF NOT EXISTS "ip_address" varchar(45);
+ALTER TABLE "sessions" ADD COLUMN IF NOT EXISTS "user_agent" text;
+CREATE INDEX IF NOT EXISTS "idx_sessions_ip_address" ON "sessions" ("ip_address");
+CREATE INDEX IF NOT EXISTS "idx_sessions_deleted_at" ON "sessions" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "verification_tokens" (
+    "identifier" text,
+    "token" text,
+    "expires" timestamptz NOT NULL,
+    PRIMARY KEY ("identifier","token")
+);
+
+CREATE TABLE IF NOT EXISTS "questions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "title" varchar(255) NOT NULL,
+    "content" text NOT NULL,
+    "user_id" bigint NOT NULL,
+    "view_count" bigint DEFAULT 0,
+    "vote_count" bigint DEFAULT 0,
+    "is_resolved" boolean DEFAULT false,
+    "is_hidden" boolean DEFAULT false,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_questions" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+ALTER TABLE "questions" ADD COLUMN IF NOT EXISTS "is_hidden" boolean DEFAULT false;
+CREATE INDEX IF NOT EXISTS "idx_questions_deleted_at" ON "questions" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "answers" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "content" text NOT NULL,
+    "user_id" bigint NOT NULL,
+    "question_id" bigint NOT NULL,
+    "vote_count" bigint DEFAULT 0,
+    "is_accepted" boolean DEFAULT false,
+    "is_hidden" boolean DEFAULT false,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_questions_answers" FOREIGN KEY ("question_id") REFERENCES "questions"("id"),
+    CONSTRAINT "fk_users_answers" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+ALTER TABLE "answers" ADD COLUMN IF NOT EXISTS "is_hidden" boolean DEFAULT false;
+CREATE INDEX IF NOT EXISTS "idx_answers_deleted_at" ON "answers" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "votes" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "question_id" bigint DEFAULT null,
+    "answer_id" bigint DEFAULT null,
+    "vote_type" varchar(10) NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_answers_votes" FOREIGN KEY ("answer_id") REFERENCES "answers"("id"),
+    CONSTRAINT "fk_questions_votes" FOREIGN KEY ("question_id") REFERENCES "questions"("id"),
+    CONSTRAINT "fk_users_votes" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_votes_deleted_at" ON "votes" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "freeze_histories" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "reason" text NOT NULL,
+    "duration" bigint NOT NULL,
+    "start_date" timestamptz NOT NULL,
+    "end_date" timestamptz NOT NULL,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "unfrozen_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_freeze_histories_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_freeze_histories_user_id" ON "freeze_histories" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_freeze_histories_deleted_at" ON "freeze_histories" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "role_histories" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "changed_by_id" bigint NOT NULL,
+    "old_role" varchar(50) NOT NULL,
+    "new_role" varchar(50) NOT NULL,
+    "reason" text NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_role_histories_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_role_histories_changed_by" FOREIGN KEY ("changed_by_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_role_histories_changed_by_id" ON "role_histories" ("changed_by_id");
+CREATE INDEX IF NOT EXISTS "idx_role_histories_user_id" ON "role_histories" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_role_histories_deleted_at" ON "role_histories" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "ban_histories" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "banned_by_id" bigint NOT NULL,
+    "reason" text NOT NULL,
+    "duration" varchar(20) NOT NULL,
+    "duration_days" bigint DEFAULT null,
+    "start_date" timestamptz NOT NULL,
+    "end_date" timestamptz DEFAULT null,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "unbanned_at" timestamptz DEFAULT null,
+    "unbanned_by" bigint DEFAULT null,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_ban_histories_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_ban_histories_banned_by" FOREIGN KEY ("banned_by_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_ban_histories_unbanner" FOREIGN KEY ("unbanned_by") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_ban_histories_banned_by_id" ON "ban_histories" ("banned_by_id");
+CREATE INDEX IF NOT EXISTS "idx_ban_histories_user_id" ON "ban_histories" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_ban_histories_deleted_at" ON "ban_histories" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "bulk_jobs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "action" varchar(20) NOT NULL,
+    "created_by_id" bigint NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "total" bigint NOT NULL DEFAULT 0,
+    "processed" bigint NOT NULL DEFAULT 0,
+    "succeeded" bigint NOT NULL DEFAULT 0,
+    "failed" bigint NOT NULL DEFAULT 0,
+    "results" text,
+    "completed_at" timestamptz DEFAULT null,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_bulk_jobs_created_by" FOREIGN KEY ("created_by_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_bulk_jobs_created_by_id" ON "bulk_jobs" ("created_by_id");
+CREATE INDEX IF NOT EXISTS "idx_bulk_jobs_deleted_at" ON "bulk_jobs" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "blocklist_entries" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "type" varchar(20) NOT NULL,
+    "value" varchar(255) NOT NULL,
+    "reason" text NOT NULL,
+    "created_by_id" bigint NOT NULL,
+    "source_user_id" bigint DEFAULT null,
+    "expires_at" timestamptz DEFAULT null,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_blocklist_entries_created_by" FOREIGN KEY ("created_by_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_blocklist_entries_source_user_id" ON "blocklist_entries" ("source_user_id");
+CREATE INDEX IF NOT EXISTS "idx_blocklist_entries_created_by_id" ON "blocklist_entries" ("created_by_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_blocklist_type_value" ON "blocklist_entries" ("type","value") WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_blocklist_entries_deleted_at" ON "blocklist_entries" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "restriction_histories" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "restricted_by_id" bigint NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "reason" text NOT NULL,
+    "duration_days" bigint DEFAULT null,
+    "post_limit" bigint DEFAULT null,
+    "post_window_minutes" bigint DEFAULT null,
+    "start_date" timestamptz NOT NULL,
+    "end_date" timestamptz DEFAULT null,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "lifted_at" timestamptz DEFAULT null,
+    "lifted_by_id" bigint DEFAULT null,
+    "lift_reason" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_restriction_histories_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_restriction_histories_restricted_by" FOREIGN KEY ("restricted_by_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_restriction_histories_lifted_by" FOREIGN KEY ("lifted_by_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_restriction_histories_type" ON "restriction_histories" ("type");
+CREATE INDEX IF NOT EXISTS "idx_restriction_histories_restricted_by_id" ON "restriction_histories" ("restricted_by_id");
+CREATE INDEX IF NOT EXISTS "idx_restriction_histories_user_id" ON "restriction_histories" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_restriction_histories_deleted_at" ON "restriction_histories" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "flags" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "target_type" varchar(20) NOT NULL,
+    "target_id" bigint NOT NULL,
+    "reporter_id" bigint NOT NULL,
+    "reason" varchar(20) NOT NULL,
+    "details" text,
+    "status" varchar(20) NOT NULL DEFAULT 'open',
+    "resolved_by_id" bigint DEFAULT null,
+    "resolved_at" timestamptz DEFAULT null,
+    "resolution_note" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_flags_reporter" FOREIGN KEY ("reporter_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_flags_resolved_by" FOREIGN KEY ("resolved_by_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_flags_status" ON "flags" ("status");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_flags_reporter_target" ON "flags" ("target_type","target_id","reporter_id") WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_flags_target" ON "flags" ("target_type","target_id");
+CREATE INDEX IF NOT EXISTS "idx_flags_deleted_at" ON "flags" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "data_exports" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "storage_key" varchar(255),
+    "token_hash" varchar(64),
+    "expires_at" timestamptz DEFAULT null,
+    "completed_at" timestamptz DEFAULT null,
+    "error" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_data_exports_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_data_exports_token_hash" ON "data_exports" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_data_exports_user_id" ON "data_exports" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_data_exports_deleted_at" ON "data_exports" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "account_deletions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'scheduled',
+    "scheduled_for" timestamptz NOT NULL,
+    "cancelled_at" timestamptz DEFAULT null,
+    "completed_at" timestamptz DEFAULT null,
+    "certificate_id" varchar(32),
+    "email_hash" varchar(64),
+    "questions_reassigned" bigint NOT NULL DEFAULT 0,
+    "answers_reassigned" bigint NOT NULL DEFAULT 0,
+    "votes_reassigned" bigint NOT NULL DEFAULT 0,
+    "accounts_removed" bigint NOT NULL DEFAULT 0,
+    "sessions_removed" bigint NOT NULL DEFAULT 0,
+    "tokens_removed" bigint NOT NULL DEFAULT 0,
+    "exports_removed" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_account_deletions_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_account_deletions_certificate_id" ON "account_deletions" ("certificate_id");
+CREATE INDEX IF NOT EXISTS "idx_account_deletions_scheduled_for" ON "account_deletions" ("scheduled_for");
+CREATE INDEX IF NOT EXISTS "idx_account_deletions_status" ON "account_deletions" ("status");
+CREATE INDEX IF NOT EXISTS "idx_account_deletions_user_id" ON "account_deletions" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_account_deletions_deleted_at" ON "account_deletions" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "user_merges" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "source_user_id" bigint NOT NULL,
+    "target_user_id" bigint NOT NULL,
+    "merged_by_id" bigint NOT NULL,
+    "reason" text NOT NULL,
+    "questions_moved" bigint NOT NULL DEFAULT 0,
+    "answers_moved" bigint NOT NULL DEFAULT 0,
+    "votes_moved" bigint NOT NULL DEFAULT 0,
+    "votes_dropped" bigint NOT NULL DEFAULT 0,
+    "accounts_moved" bigint NOT NULL DEFAULT 0,
+    "histories_moved" bigint NOT NULL DEFAULT 0,
+    "flags_moved" bigint NOT NULL DEFAULT 0,
+    "flags_dropped" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_merges_target_user" FOREIGN KEY ("target_user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_user_merges_merged_by" FOREIGN KEY ("merged_by_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_user_merges_source_user" FOREIGN KEY ("source_user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_user_merges_merged_by_id" ON "user_merges" ("merged_by_id");
+CREATE INDEX IF NOT EXISTS "idx_user_merges_target_user_id" ON "user_merges" ("target_user_id");
+CREATE INDEX IF NOT EXISTS "idx_user_merges_source_user_id" ON "user_merges" ("source_user_id");
+CREATE INDEX IF NOT EXISTS "idx_user_merges_deleted_at" ON "user_merges" ("deleted_at");
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS "outbox_messages" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "kind" varchar(50) NOT NULL,
+    "recipient" varchar(255) NOT NULL,
+    "subject" text NOT NULL,
+    "html_body" text NOT NULL,
+    "text_body" text NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL,
+    "last_error" text,
+    "sent_at" timestamptz DEFAULT null,
+    PRIMARY KEY ("id")
+);
+-- The dispatcher polls for due pending messages
+CREATE INDEX IF NOT EXISTS "idx_outbox_messages_due" ON "outbox_messages" ("next_attempt_at") WHERE status = 'pending';
+-- The admin view lists by status and the cleanup deletes old sent messages
+CREATE INDEX IF NOT EXISTS "idx_outbox_messages_status_updated_at" ON "outbox_messages" ("status","updated_at");
@@ -0,0 +1,33 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "locale" varchar(10) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS "notifications" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "type" varchar(50) NOT NULL,
+    "data" text NOT NULL,
+    "in_app" boolean NOT NULL DEFAULT true,
+    "digest_pending" boolean NOT NULL DEFAULT false,
+    "read_at" timestamptz DEFAULT null,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_notifications_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+-- Lists and unread counts of a user
+CREATE INDEX IF NOT EXISTS "idx_notifications_user_id_created_at" ON "notifications" ("user_id","created_at" DESC);
+CREATE INDEX IF NOT EXISTS "idx_notifications_unread" ON "notifications" ("user_id") WHERE read_at IS NULL AND in_app;
+-- The digest job looks for pending entries
+CREATE INDEX IF NOT EXISTS "idx_notifications_digest_pending" ON "notifications" ("user_id") WHERE digest_pending;
+
+CREATE TABLE IF NOT EXISTS "notification_preferences" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "type" varchar(50) NOT NULL,
+    "in_app" boolean NOT NULL,
+    "email" boolean NOT NULL,
+    "digest" boolean NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_notification_preferences_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_notification_preferences_user_type" ON "notification_preferences" ("user_id","type");
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS "webhook_subscriptions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "url" text NOT NULL,
+    "secret" varchar(100) NOT NULL,
+    "event_types" text NOT NULL,
+    "description" text NOT NULL DEFAULT '',
+    "active" boolean NOT NULL DEFAULT true,
+    "created_by_id" bigint NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhook_subscriptions_created_by" FOREIGN KEY ("created_by_id") REFERENCES "users"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "subscription_id" bigint NOT NULL,
+    "event_id" varchar(64) NOT NULL,
+    "event_type" varchar(50) NOT NULL,
+    "payload" text NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL,
+    "response_status" bigint DEFAULT null,
+    "response_body" text,
+    "last_error" text,
+    "duration_ms" bigint DEFAULT null,
+    "delivered_at" timestamptz DEFAULT null,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhook_deliveries_subscription" FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions"("id")
+);
+-- The dispatcher polls for due pending deliveries
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_due" ON "webhook_deliveries" ("next_attempt_at") WHERE status = 'pending';
+-- The delivery log of a subscription, newest first
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_subscription_id_created_at" ON "webhook_deliveries" ("subscription_id","created_at" DESC);
+-- The cleanup deletes old finished deliveries
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_status_updated_at" ON "webhook_deliveries" ("status","updated_at");
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_users_placeholder";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "is_placeholder";
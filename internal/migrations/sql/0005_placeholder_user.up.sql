@@ -0,0 +1,12 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "is_placeholder" boolean NOT NULL DEFAULT false;
+
+-- The placeholder used to be found by its username. Only the account created
+-- for it, which has no email or password, is adopted.
+UPDATE "users" SET "is_placeholder" = true
+WHERE "id" = (
+    SELECT "id" FROM "users"
+    WHERE "username" = 'deleted-user' AND "email" IS NULL AND "password" IS NULL
+    ORDER BY "id" LIMIT 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_placeholder" ON "users" ("is_placeholder") WHERE is_placeholder;
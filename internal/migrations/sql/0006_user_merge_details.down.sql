@@ -0,0 +1,5 @@
+ALTER TABLE "user_merges" DROP COLUMN IF EXISTS "restrictions_lifted";
+ALTER TABLE "user_merges" DROP COLUMN IF EXISTS "carry_restrictions";
+ALTER TABLE "user_merges" DROP COLUMN IF EXISTS "exports_moved";
+ALTER TABLE "user_merges" DROP COLUMN IF EXISTS "notifications_moved";
+ALTER TABLE "user_merges" DROP COLUMN IF EXISTS "flags_dismissed";
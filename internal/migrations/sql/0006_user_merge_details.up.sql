@@ -0,0 +1,5 @@
+ALTER TABLE "user_merges" ADD COLUMN IF NOT EXISTS "flags_dismissed" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "user_merges" ADD COLUMN IF NOT EXISTS "notifications_moved" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "user_merges" ADD COLUMN IF NOT EXISTS "exports_moved" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "user_merges" ADD COLUMN IF NOT EXISTS "carry_restrictions" boolean NOT NULL DEFAULT false;
+ALTER TABLE "user_merges" ADD COLUMN IF NOT EXISTS "restrictions_lifted" bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "restore_histories" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "restored_by_id" bigint NOT NULL,
+    "reason" text NOT NULL,
+    "old_status" varchar(50) NOT NULL,
+    "was_deleted" boolean NOT NULL DEFAULT false,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_restore_histories_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_restore_histories_restored_by" FOREIGN KEY ("restored_by_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_restore_histories_restored_by_id" ON "restore_histories" ("restored_by_id");
+CREATE INDEX IF NOT EXISTS "idx_restore_histories_user_id" ON "restore_histories" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_restore_histories_deleted_at" ON "restore_histories" ("deleted_at");
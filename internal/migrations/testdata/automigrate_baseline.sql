@@ -0,0 +1,171 @@
+-- Schema AutoMigrate created from the models before versioned migrations and
+-- the features that came with them. Used to test that such databases can run
+-- migrate up.
+
+CREATE TABLE "users" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" varchar(255),
+    "username" varchar(255),
+    "email" varchar(255),
+    "emailVerified" timestamptz,
+    "password" text,
+    "image" text,
+    "role" varchar(50) NOT NULL DEFAULT 'USER',
+    "status" varchar(50) NOT NULL DEFAULT 'active',
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_users_email" ON "users" ("email") WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX "idx_users_username" ON "users" ("username") WHERE deleted_at IS NULL;
+CREATE INDEX "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE "accounts" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "type" varchar(255) NOT NULL,
+    "provider" varchar(255) NOT NULL,
+    "providerAccountId" varchar(255) NOT NULL,
+    "refresh_token" text,
+    "access_token" text,
+    "expires_at" bigint,
+    "id_token" text,
+    "scope" text,
+    "session_state" text,
+    "token_type" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_accounts" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX "idx_accounts_deleted_at" ON "accounts" ("deleted_at");
+
+CREATE TABLE "sessions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "expires" timestamptz NOT NULL,
+    "sessionToken" varchar(255) NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_sessions" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX "idx_sessions_deleted_at" ON "sessions" ("deleted_at");
+
+CREATE TABLE "verification_tokens" (
+    "identifier" text,
+    "token" text,
+    "expires" timestamptz NOT NULL,
+    PRIMARY KEY ("identifier","token")
+);
+
+CREATE TABLE "questions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "title" varchar(255) NOT NULL,
+    "content" text NOT NULL,
+    "user_id" bigint NOT NULL,
+    "view_count" bigint DEFAULT 0,
+    "vote_count" bigint DEFAULT 0,
+    "is_resolved" boolean DEFAULT false,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_questions" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX "idx_questions_deleted_at" ON "questions" ("deleted_at");
+
+CREATE TABLE "answers" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "content" text NOT NULL,
+    "user_id" bigint NOT NULL,
+    "question_id" bigint NOT NULL,
+    "vote_count" bigint DEFAULT 0,
+    "is_accepted" boolean DEFAULT false,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_questions_answers" FOREIGN KEY ("question_id") REFERENCES "questions"("id"),
+    CONSTRAINT "fk_users_answers" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX "idx_answers_deleted_at" ON "answers" ("deleted_at");
+
+CREATE TABLE "votes" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "question_id" bigint DEFAULT null,
+    "answer_id" bigint DEFAULT null,
+    "vote_type" varchar(10) NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_answers_votes" FOREIGN KEY ("answer_id") REFERENCES "answers"("id"),
+    CONSTRAINT "fk_questions_votes" FOREIGN KEY ("question_id") REFERENCES "questions"("id"),
+    CONSTRAINT "fk_users_votes" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX "idx_votes_deleted_at" ON "votes" ("deleted_at");
+
+CREATE TABLE "freeze_histories" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "reason" text NOT NULL,
+    "duration" bigint NOT NULL,
+    "start_date" timestamptz NOT NULL,
+    "end_date" timestamptz NOT NULL,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "unfrozen_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_freeze_histories_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX "idx_freeze_histories_user_id" ON "freeze_histories" ("user_id");
+CREATE INDEX "idx_freeze_histories_deleted_at" ON "freeze_histories" ("deleted_at");
+
+CREATE TABLE "role_histories" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "changed_by_id" bigint NOT NULL,
+    "old_role" varchar(50) NOT NULL,
+    "new_role" varchar(50) NOT NULL,
+    "reason" text NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_role_histories_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_role_histories_changed_by" FOREIGN KEY ("changed_by_id") REFERENCES "users"("id")
+);
+CREATE INDEX "idx_role_histories_changed_by_id" ON "role_histories" ("changed_by_id");
+CREATE INDEX "idx_role_histories_user_id" ON "role_histories" ("user_id");
+CREATE INDEX "idx_role_histories_deleted_at" ON "role_histories" ("deleted_at");
+
+CREATE TABLE "ban_histories" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "banned_by_id" bigint NOT NULL,
+    "reason" text NOT NULL,
+    "duration" varchar(20) NOT NULL,
+    "duration_days" bigint DEFAULT null,
+    "start_date" timestamptz NOT NULL,
+    "end_date" timestamptz DEFAULT null,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "unbanned_at" timestamptz DEFAULT null,
+    "unbanned_by" bigint DEFAULT null,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_ban_histories_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_ban_histories_banned_by" FOREIGN KEY ("banned_by_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_ban_histories_unbanner" FOREIGN KEY ("unbanned_by") REFERENCES "users"("id")
+);
+CREATE INDEX "idx_ban_histories_banned_by_id" ON "ban_histories" ("banned_by_id");
+CREATE INDEX "idx_ban_histories_user_id" ON "ban_histories" ("user_id");
+CREATE INDEX "idx_ban_histories_deleted_at" ON "ban_histories" ("deleted_at");
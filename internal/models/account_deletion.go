@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AccountDeletionStatus string
+
+const (
+	AccountDeletionScheduled AccountDeletionStatus = "scheduled"
+	AccountDeletionCancelled AccountDeletionStatus = "cancelled"
+	AccountDeletionCompleted AccountDeletionStatus = "completed"
+)
+
+// AccountDeletion schedules the erasure of a deleted account. Once completed it
+// doubles as the deletion certificate: only counts and a hash of the email are kept.
+type AccountDeletion struct {
+	gorm.Model
+	UserID       uint                  `gorm:"not null;index"`
+	Status       AccountDeletionStatus `gorm:"type:varchar(20);not null;default:'scheduled';index"`
+	ScheduledFor time.Time             `gorm:"not null;index"`
+	CancelledAt  *time.Time            `gorm:"default:null"`
+	CompletedAt  *time.Time            `gorm:"default:null"`
+
+	// Deletion certificate
+	CertificateID       *string `gorm:"type:varchar(32);uniqueIndex"`
+	EmailHash           *string `gorm:"type:varchar(64)"` // sha256 of the lowercased email
+	QuestionsReassigned int64   `gorm:"not null;default:0"`
+	AnswersReassigned   int64   `gorm:"not null;default:0"`
+	VotesReassigned     int64   `gorm:"not null;default:0"`
+	AccountsRemoved     int64   `gorm:"not null;default:0"`
+	SessionsRemoved     int64   `gorm:"not null;default:0"`
+	TokensRemoved       int64   `gorm:"not null;default:0"`
+	ExportsRemoved      int64   `gorm:"not null;default:0"`
+
+	// Relations
+	User User `gorm:"foreignKey:UserID"`
+}
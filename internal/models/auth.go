@@ -33,5 +33,7 @@ type Session struct {
 	UserID       uint      `gorm:"not null"`
 	Expires      time.Time `gorm:"not null"`
 	SessionToken string    `gorm:"type:varchar(255);not null;column:sessionToken"`
+	IPAddress    *string   `gorm:"type:varchar(45);index"`
+	UserAgent    *string   `gorm:"type:text"`
 	User         User      `gorm:"foreignKey:UserID"`
 } 
\ No newline at end of file
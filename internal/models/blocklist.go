@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BlocklistType string
+
+const (
+	BlocklistIP          BlocklistType = "ip"
+	BlocklistEmailDomain BlocklistType = "email_domain"
+)
+
+// BlocklistEntry is an admin managed block on an IP/CIDR range or an email domain
+type BlocklistEntry struct {
+	gorm.Model
+	Type         BlocklistType `gorm:"type:varchar(20);not null;index:idx_blocklist_type_value,unique,where:deleted_at IS NULL"`
+	Value        string        `gorm:"type:varchar(255);not null;index:idx_blocklist_type_value,unique,where:deleted_at IS NULL"` // CIDR for IPs, lowercase domain for emails
+	Reason       string        `gorm:"type:text;not null"`
+	CreatedByID  uint          `gorm:"not null;index"`
+	SourceUserID *uint         `gorm:"default:null;index"` // set when recorded automatically from a banned user's sessions
+	ExpiresAt    *time.Time    `gorm:"default:null"`       // null for permanent blocks
+
+	// Relations
+	CreatedBy User `gorm:"foreignKey:CreatedByID"`
+}
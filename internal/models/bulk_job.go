@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BulkJobStatus string
+
+const (
+	BulkJobPending   BulkJobStatus = "pending"
+	BulkJobRunning   BulkJobStatus = "running"
+	BulkJobCompleted BulkJobStatus = "completed"
+	BulkJobFailed    BulkJobStatus = "failed"
+)
+
+// BulkJob tracks a bulk admin operation that runs in the background
+type BulkJob struct {
+	gorm.Model
+	Action      string        `gorm:"type:varchar(20);not null"`
+	CreatedByID uint          `gorm:"not null;index"`
+	Status      BulkJobStatus `gorm:"type:varchar(20);not null;default:'pending'"`
+	Total       int           `gorm:"not null;default:0"`
+	Processed   int           `gorm:"not null;default:0"`
+	Succeeded   int           `gorm:"not null;default:0"`
+	Failed      int           `gorm:"not null;default:0"`
+	Results     string        `gorm:"type:text"` // JSON encoded per-user results
+	CompletedAt *time.Time    `gorm:"default:null"`
+
+	// Relations
+	CreatedBy User `gorm:"foreignKey:CreatedByID"`
+}
@@ -12,6 +12,7 @@ type Question struct {
 	ViewCount   uint   `gorm:"default:0"`
 	VoteCount   int    `gorm:"default:0"`
 	IsResolved  bool   `gorm:"default:false"`
+	IsHidden    bool   `gorm:"default:false"` // hidden automatically after too many flags
 	
 	// Relations
 	User    User     `gorm:"foreignKey:UserID"`
@@ -26,6 +27,7 @@ type Answer struct {
 	QuestionID uint   `gorm:"not null"`
 	VoteCount  int    `gorm:"default:0"`
 	IsAccepted bool   `gorm:"default:false"`
+	IsHidden   bool   `gorm:"default:false"` // hidden automatically after too many flags
 	
 	// Relations
 	User     User   `gorm:"foreignKey:UserID"`
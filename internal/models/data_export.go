@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportPending    DataExportStatus = "pending"
+	DataExportProcessing DataExportStatus = "processing"
+	DataExportReady      DataExportStatus = "ready"
+	DataExportFailed     DataExportStatus = "failed"
+	DataExportExpired    DataExportStatus = "expired"
+)
+
+// DataExport is a user's request for a copy of their personal data
+type DataExport struct {
+	gorm.Model
+	UserID      uint             `gorm:"not null;index"`
+	Status      DataExportStatus `gorm:"type:varchar(20);not null;default:'pending'"`
+	StorageKey  *string          `gorm:"type:varchar(255)"`
+	TokenHash   *string          `gorm:"type:varchar(64);uniqueIndex"` // sha256 of the download token
+	ExpiresAt   *time.Time       `gorm:"default:null"`
+	CompletedAt *time.Time       `gorm:"default:null"`
+	Error       *string          `gorm:"type:text"`
+
+	// Relations
+	User User `gorm:"foreignKey:UserID"`
+}
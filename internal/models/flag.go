@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FlagTargetType string
+type FlagReason string
+type FlagStatus string
+
+const (
+	FlagTargetQuestion FlagTargetType = "question"
+	FlagTargetAnswer   FlagTargetType = "answer"
+	FlagTargetUser     FlagTargetType = "user"
+
+	FlagReasonSpam       FlagReason = "spam"
+	FlagReasonAbuse      FlagReason = "abuse"
+	FlagReasonHarassment FlagReason = "harassment"
+	FlagReasonOffTopic   FlagReason = "off_topic"
+	FlagReasonOther      FlagReason = "other"
+
+	FlagStatusOpen      FlagStatus = "open"
+	FlagStatusDismissed FlagStatus = "dismissed"
+	FlagStatusDeleted   FlagStatus = "content_deleted"
+	FlagStatusEscalated FlagStatus = "escalated"
+	FlagStatusBanned    FlagStatus = "user_banned"
+)
+
+// Flag is a user report about a question, answer or user
+type Flag struct {
+	gorm.Model
+	TargetType     FlagTargetType `gorm:"type:varchar(20);not null;index:idx_flags_target;index:idx_flags_reporter_target,unique,where:deleted_at IS NULL"`
+	TargetID       uint           `gorm:"not null;index:idx_flags_target;index:idx_flags_reporter_target,unique,where:deleted_at IS NULL"`
+	ReporterID     uint           `gorm:"not null;index:idx_flags_reporter_target,unique,where:deleted_at IS NULL"`
+	Reason         FlagReason     `gorm:"type:varchar(20);not null"`
+	Details        string         `gorm:"type:text"`
+	Status         FlagStatus     `gorm:"type:varchar(20);not null;default:'open';index"`
+	ResolvedByID   *uint          `gorm:"default:null"`
+	ResolvedAt     *time.Time     `gorm:"default:null"`
+	ResolutionNote *string        `gorm:"type:text"`
+
+	// Relations
+	Reporter   User `gorm:"foreignKey:ReporterID"`
+	ResolvedBy User `gorm:"foreignKey:ResolvedByID"`
+}
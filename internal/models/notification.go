@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+type NotificationType string
+
+const (
+	NotificationNewAnswer      NotificationType = "new_answer"
+	NotificationAnswerAccepted NotificationType = "answer_accepted"
+	NotificationBanned         NotificationType = "banned"
+	NotificationUnbanned       NotificationType = "unbanned"
+	NotificationRoleChanged    NotificationType = "role_changed"
+)
+
+// NotificationTypes lists every notification type
+var NotificationTypes = []NotificationType{
+	NotificationNewAnswer,
+	NotificationAnswerAccepted,
+	NotificationBanned,
+	NotificationUnbanned,
+	NotificationRoleChanged,
+}
+
+// Notification tells a user about something that happened to them or their content
+type Notification struct {
+	ID            uint `gorm:"primarykey"`
+	CreatedAt     time.Time
+	UserID        uint             `gorm:"not null"`
+	Type          NotificationType `gorm:"type:varchar(50);not null"`
+	Data          string           `gorm:"type:text;not null"`     // JSON encoded details, e.g. the question title
+	InApp         bool             `gorm:"not null;default:true"`  // shown in the user's notification list
+	DigestPending bool             `gorm:"not null;default:false"` // included in the next digest email if still unread
+	ReadAt        *time.Time       `gorm:"default:null"`
+}
+
+// NotificationPreference stores how a user wants to be told about one type of
+// notification. Types without a stored preference use the defaults.
+type NotificationPreference struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint             `gorm:"not null"`
+	Type      NotificationType `gorm:"type:varchar(50);not null"`
+	InApp     bool             `gorm:"not null"`
+	Email     bool             `gorm:"not null"`
+	Digest    bool             `gorm:"not null"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "pending"
+	OutboxSent    OutboxStatus = "sent"
+	OutboxDead    OutboxStatus = "dead" // gave up after the maximum number of attempts
+)
+
+// OutboxMessage is a rendered email waiting for delivery. It is written in the
+// same transaction as the change it reports, so the email is sent if and only
+// if the change is committed. Delivered messages are deleted after a retention
+// period instead of being soft deleted.
+type OutboxMessage struct {
+	ID            uint `gorm:"primarykey"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Kind          string       `gorm:"type:varchar(50);not null"` // email template name
+	Recipient     string       `gorm:"type:varchar(255);not null"`
+	Subject       string       `gorm:"type:text;not null"`
+	HTMLBody      string       `gorm:"column:html_body;type:text;not null"`
+	TextBody      string       `gorm:"type:text;not null"`
+	Status        OutboxStatus `gorm:"type:varchar(20);not null;default:'pending'"`
+	Attempts      int          `gorm:"not null;default:0"`
+	NextAttemptAt time.Time    `gorm:"not null"`
+	LastError     *string      `gorm:"type:text"`
+	SentAt        *time.Time   `gorm:"default:null"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// RestoreHistory records an admin bringing back a soft deleted or passive user
+type RestoreHistory struct {
+	gorm.Model
+	UserID       uint       `gorm:"not null;index"`
+	RestoredByID uint       `gorm:"not null;index"`
+	Reason       string     `gorm:"type:text;not null"`
+	OldStatus    UserStatus `gorm:"type:varchar(50);not null"`
+	WasDeleted   bool       `gorm:"not null;default:false"`
+
+	// Relations
+	User       User `gorm:"foreignKey:UserID"`
+	RestoredBy User `gorm:"foreignKey:RestoredByID"`
+}
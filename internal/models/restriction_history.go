@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RestrictionType string
+
+const (
+	// RestrictionShadowBan hides the user's content from everyone except the user
+	RestrictionShadowBan RestrictionType = "shadow_ban"
+	// RestrictionRateLimited limits how many posts the user can create in a time window
+	RestrictionRateLimited RestrictionType = "rate_limited"
+	// RestrictionReadOnly prevents the user from creating or changing content
+	RestrictionReadOnly RestrictionType = "read_only"
+)
+
+// RestrictionHistory represents the history of content restrictions applied to users
+type RestrictionHistory struct {
+	gorm.Model
+	UserID            uint            `gorm:"not null;index"`
+	RestrictedByID    uint            `gorm:"not null;index"`
+	Type              RestrictionType `gorm:"type:varchar(20);not null;index"`
+	Reason            string          `gorm:"type:text;not null"`
+	DurationDays      *int            `gorm:"default:null"` // null for permanent restrictions
+	PostLimit         *int            `gorm:"default:null"` // rate_limited only
+	PostWindowMinutes *int            `gorm:"default:null"` // rate_limited only
+	StartDate         time.Time       `gorm:"not null"`
+	EndDate           *time.Time      `gorm:"default:null"` // null for permanent restrictions
+	IsActive          bool            `gorm:"not null;default:true"`
+	LiftedAt          *time.Time      `gorm:"default:null"`
+	LiftedByID        *uint           `gorm:"default:null"`
+	LiftReason        *string         `gorm:"type:text"`
+
+	// Relations
+	User         User `gorm:"foreignKey:UserID"`
+	RestrictedBy User `gorm:"foreignKey:RestrictedByID"`
+	LiftedBy     User `gorm:"foreignKey:LiftedByID"`
+}
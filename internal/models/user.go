@@ -21,6 +21,24 @@ const (
 	RoleSuperAdmin UserRole = "SUPER_ADMIN"
 )
 
+// IsValid reports whether r is one of the known roles
+func (r UserRole) IsValid() bool {
+	switch r {
+	case RoleUser, RoleEditor, RoleAdmin, RoleSuperAdmin:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether s is one of the known statuses
+func (s UserStatus) IsValid() bool {
+	switch s {
+	case StatusActive, StatusPassive, StatusBanned, StatusFrozen:
+		return true
+	}
+	return false
+}
+
 type User struct {
 	gorm.Model
 	Name          *string    `gorm:"type:varchar(255)"`
@@ -31,6 +49,9 @@ type User struct {
 	Image         *string    `gorm:"type:text"`
 	Role          UserRole   `gorm:"type:varchar(50);not null;default:'USER'"`
 	Status        UserStatus `gorm:"type:varchar(50);not null;default:'active'"`
+	Locale        string     `gorm:"type:varchar(10);not null;default:''"` // language of emails, empty for the default
+	// IsPlaceholder marks the single account that erased users' content is reassigned to
+	IsPlaceholder bool `gorm:"not null;default:false;index:idx_users_placeholder,unique,where:is_placeholder"`
 	
 	// Relations
 	Accounts  []Account  `gorm:"foreignKey:UserID"`
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// UserMerge is the audit record of a duplicate account merged into another user
+type UserMerge struct {
+	gorm.Model
+	SourceUserID   uint   `gorm:"not null;index"`
+	TargetUserID   uint   `gorm:"not null;index"`
+	MergedByID     uint   `gorm:"not null;index"`
+	Reason         string `gorm:"type:text;not null"`
+	QuestionsMoved int64  `gorm:"not null;default:0"`
+	AnswersMoved   int64  `gorm:"not null;default:0"`
+	VotesMoved     int64  `gorm:"not null;default:0"`
+	VotesDropped   int64  `gorm:"not null;default:0"` // source votes on content the target had already voted on
+	AccountsMoved  int64  `gorm:"not null;default:0"`
+	HistoriesMoved int64  `gorm:"not null;default:0"` // ban, role, restore, freeze and restriction histories
+	FlagsMoved     int64  `gorm:"not null;default:0"`
+	FlagsDropped   int64  `gorm:"not null;default:0"`
+	// FlagsDismissed counts open flags the two users filed against each other or each other's content
+	FlagsDismissed     int64 `gorm:"not null;default:0"`
+	NotificationsMoved int64 `gorm:"not null;default:0"` // notifications and notification preferences
+	ExportsMoved       int64 `gorm:"not null;default:0"`
+	// CarryRestrictions is set when the source's active content restrictions were kept active on the target
+	CarryRestrictions  bool  `gorm:"not null;default:false"`
+	RestrictionsLifted int64 `gorm:"not null;default:0"` // active source restrictions closed by the merge
+
+	// Relations
+	SourceUser User `gorm:"foreignKey:SourceUserID"`
+	TargetUser User `gorm:"foreignKey:TargetUserID"`
+	MergedBy   User `gorm:"foreignKey:MergedByID"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed" // gave up after the maximum number of attempts
+)
+
+// WebhookSubscription sends the events of EventTypes to URL. The secret signs
+// every delivery, so it is stored as is and only shown when it is created.
+type WebhookSubscription struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	URL         string `gorm:"column:url;type:text;not null"`
+	Secret      string `gorm:"type:varchar(100);not null"`
+	EventTypes  string `gorm:"type:text;not null"` // comma separated event types
+	Description string `gorm:"type:text;not null;default:''"`
+	Active      bool   `gorm:"not null;default:true"`
+	CreatedByID uint   `gorm:"not null"`
+	CreatedBy   User   `gorm:"foreignKey:CreatedByID"`
+}
+
+// Types returns the event types the subscription receives
+func (s *WebhookSubscription) Types() []string {
+	if s.EventTypes == "" {
+		return nil
+	}
+	return strings.Split(s.EventTypes, ",")
+}
+
+// Receives reports whether the subscription receives events of eventType
+func (s *WebhookSubscription) Receives(eventType string) bool {
+	for _, t := range s.Types() {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event sent, or waiting to be sent, to a subscription.
+// It is written in the same transaction as the change the event reports and
+// doubles as the delivery log. Deliveries are deleted after a retention period.
+type WebhookDelivery struct {
+	ID             uint `gorm:"primarykey"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	SubscriptionID uint                  `gorm:"not null"`
+	EventID        string                `gorm:"type:varchar(64);not null"` // the same for every subscription receiving the event
+	EventType      string                `gorm:"type:varchar(50);not null"`
+	Payload        string                `gorm:"type:text;not null"` // the JSON request body
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(20);not null;default:'pending'"`
+	Attempts       int                   `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `gorm:"not null"`
+	ResponseStatus *int                  `gorm:"default:null"`
+	ResponseBody   *string               `gorm:"type:text"` // truncated
+	LastError      *string               `gorm:"type:text"`
+	DurationMs     *int64                `gorm:"default:null"` // of the last attempt
+	DeliveredAt    *time.Time            `gorm:"default:null"`
+}
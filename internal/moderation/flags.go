@@ -0,0 +1,87 @@
+package moderation
+
+import (
+	"gorm.io/gorm"
+
+	"ai-backend/internal/config"
+	"ai-backend/internal/models"
+)
+
+// FlagAutoHideThreshold returns how many distinct reporters hide a question or answer automatically
+func FlagAutoHideThreshold() int {
+	return config.Get().Moderation.FlagAutoHideThreshold
+}
+
+// contentModel returns the model backing a content flag target, or nil for user targets
+func contentModel(targetType models.FlagTargetType) interface{} {
+	switch targetType {
+	case models.FlagTargetQuestion:
+		return &models.Question{}
+	case models.FlagTargetAnswer:
+		return &models.Answer{}
+	}
+	return nil
+}
+
+// FlagTargetOwner returns the ID of the user responsible for a flag target.
+// It returns gorm.ErrRecordNotFound if the target does not exist.
+func FlagTargetOwner(db *gorm.DB, targetType models.FlagTargetType, targetID uint) (uint, error) {
+	if targetType == models.FlagTargetUser {
+		var user models.User
+		if err := db.Select("id").First(&user, targetID).Error; err != nil {
+			return 0, err
+		}
+		return user.ID, nil
+	}
+
+	var ownerIDs []uint
+	if err := db.Model(contentModel(targetType)).Where("id = ?", targetID).Pluck("user_id", &ownerIDs).Error; err != nil {
+		return 0, err
+	}
+	if len(ownerIDs) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return ownerIDs[0], nil
+}
+
+// SetContentHidden hides or shows a flagged question or answer; user targets are ignored
+func SetContentHidden(db *gorm.DB, targetType models.FlagTargetType, targetID uint, hidden bool) error {
+	model := contentModel(targetType)
+	if model == nil {
+		return nil
+	}
+	return db.Model(model).Where("id = ?", targetID).Update("is_hidden", hidden).Error
+}
+
+// DeleteFlaggedContent soft deletes a flagged question or answer
+func DeleteFlaggedContent(db *gorm.DB, targetType models.FlagTargetType, targetID uint) error {
+	model := contentModel(targetType)
+	if model == nil {
+		return nil
+	}
+	return db.Where("id = ?", targetID).Delete(model).Error
+}
+
+// ApplyAutoHide hides the target once enough distinct users have open flags on it
+func ApplyAutoHide(db *gorm.DB, targetType models.FlagTargetType, targetID uint) (bool, error) {
+	if contentModel(targetType) == nil {
+		return false, nil
+	}
+
+	var reporters int64
+	if err := db.Model(&models.Flag{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.FlagStatusOpen).
+		Distinct("reporter_id").
+		Count(&reporters).Error; err != nil {
+		return false, err
+	}
+
+	if reporters < int64(FlagAutoHideThreshold()) {
+		return false, nil
+	}
+
+	if err := SetContentHidden(db, targetType, targetID, true); err != nil {
+		return false, err
+	}
+	return true, nil
+}
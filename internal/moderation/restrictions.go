@@ -0,0 +1,85 @@
+package moderation
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"ai-backend/internal/models"
+)
+
+// ActiveRestrictions returns the user's active content restrictions, closing the ones that have expired
+func ActiveRestrictions(db *gorm.DB, userID uint) ([]models.RestrictionHistory, error) {
+	now := time.Now()
+
+	// Expired restrictions are closed lazily, the same way frozen accounts are reactivated on login
+	if err := db.Model(&models.RestrictionHistory{}).
+		Where("user_id = ? AND is_active = ? AND end_date IS NOT NULL AND end_date <= ?", userID, true, now).
+		Updates(map[string]interface{}{
+			"is_active": false,
+			"lifted_at": now,
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	var restrictions []models.RestrictionHistory
+	if err := db.Where("user_id = ? AND is_active = ?", userID, true).
+		Order("created_at desc").
+		Find(&restrictions).Error; err != nil {
+		return nil, err
+	}
+	return restrictions, nil
+}
+
+// ExpireRestrictions closes every active restriction whose end date has passed and returns how many were closed
+func ExpireRestrictions(db *gorm.DB) (int64, error) {
+	now := time.Now()
+	result := db.Model(&models.RestrictionHistory{}).
+		Where("is_active = ? AND end_date IS NOT NULL AND end_date <= ?", true, now).
+		Updates(map[string]interface{}{
+			"is_active": false,
+			"lifted_at": now,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// FindRestriction returns the first restriction of the given type, or nil
+func FindRestriction(restrictions []models.RestrictionHistory, restrictionType models.RestrictionType) *models.RestrictionHistory {
+	for i := range restrictions {
+		if restrictions[i].Type == restrictionType {
+			return &restrictions[i]
+		}
+	}
+	return nil
+}
+
+// CountRecentPosts counts the questions and answers the user created since the given time
+func CountRecentPosts(db *gorm.DB, userID uint, since time.Time) (int64, error) {
+	var questions, answers int64
+	if err := db.Model(&models.Question{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Count(&questions).Error; err != nil {
+		return 0, err
+	}
+	if err := db.Model(&models.Answer{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Count(&answers).Error; err != nil {
+		return 0, err
+	}
+	return questions + answers, nil
+}
+
+// VisibleContent is a query scope for content listings that hides rows authored by
+// shadow-banned users, and rows hidden by flags, from everyone except the author.
+// It expects user_id and is_hidden columns. The API has no content listings yet;
+// apply it to them when they are added.
+func VisibleContent(viewerID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		shadowBanned := db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.RestrictionHistory{}).
+			Select("user_id").
+			Where("type = ? AND is_active = ? AND (end_date IS NULL OR end_date > ?)", models.RestrictionShadowBan, true, time.Now())
+
+		return db.Where("user_id = ? OR (is_hidden = ? AND user_id NOT IN (?))", viewerID, false, shadowBanned)
+	}
+}
@@ -0,0 +1,117 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"ai-backend/internal/background"
+	"ai-backend/internal/config"
+	"ai-backend/internal/locale"
+	"ai-backend/internal/metrics"
+	"ai-backend/internal/models"
+	"ai-backend/internal/outbox"
+	"ai-backend/internal/repository"
+	"ai-backend/pkg/email"
+)
+
+const (
+	// DigestName identifies the digest job in background job metrics and health checks
+	DigestName = "notification_digest"
+
+	// digestBatchSize is how many users are loaded at once
+	digestBatchSize = 100
+	// digestFetchLimit caps the notifications loaded per user; digestItems of
+	// them are listed and the rest are only counted
+	digestFetchLimit = 100
+	digestItems      = 10
+)
+
+// StartDigest emails every cfg.DigestInterval a digest of the unread
+// notifications users chose to receive that way
+func StartDigest(store repository.Store, cfg config.NotificationConfig, m *metrics.Metrics) {
+	background.Every(DigestName, cfg.DigestInterval, m, func(ctx context.Context) {
+		SendDigests(ctx, store)
+	})
+}
+
+// SendDigests queues a digest email for every user with notifications waiting
+// for one, until all are done or ctx is done
+func SendDigests(ctx context.Context, store repository.Store) {
+	var afterID uint
+	queued := 0
+	for ctx.Err() == nil {
+		userIDs, err := store.Notifications().DigestUserIDs(afterID, digestBatchSize)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to find users waiting for a digest", "error", err)
+			return
+		}
+		for _, userID := range userIDs {
+			sent, err := sendDigest(ctx, store, userID)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to queue notification digest", "user_id", userID, "error", err)
+				continue
+			}
+			if sent {
+				queued++
+			}
+		}
+		if len(userIDs) < digestBatchSize {
+			break
+		}
+		afterID = userIDs[len(userIDs)-1]
+	}
+
+	if queued > 0 {
+		slog.InfoContext(ctx, "Queued notification digests", "count", queued)
+		outbox.Wake()
+	}
+}
+
+// sendDigest queues the digest of one user and takes its notifications out of
+// the next one. Notifications read in the meantime are dropped without an email.
+func sendDigest(ctx context.Context, store repository.Store, userID uint) (bool, error) {
+	sent := false
+	err := store.Transaction(func(tx repository.Store) error {
+		notifications, err := tx.Notifications().PendingDigest(userID, digestFetchLimit)
+		if err != nil {
+			return err
+		}
+		if err := tx.Notifications().ClearDigest(userID); err != nil {
+			return err
+		}
+		if len(notifications) == 0 {
+			return nil
+		}
+
+		user, err := tx.Users().FindByID(userID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+		if user.Email == nil || user.Status == models.StatusBanned {
+			return nil
+		}
+
+		data := email.NotificationDigestData{More: max(len(notifications)-digestItems, 0)}
+		for _, n := range notifications[:min(len(notifications), digestItems)] {
+			var details struct {
+				QuestionTitle string `json:"question_title"`
+			}
+			// The title is optional, so undecodable data still makes a line
+			_ = json.Unmarshal([]byte(n.Data), &details)
+			data.Items = append(data.Items, email.DigestItem{
+				Type:          string(n.Type),
+				QuestionTitle: details.QuestionTitle,
+				At:            n.CreatedAt,
+			})
+		}
+
+		sent = true
+		return outbox.Enqueue(locale.WithContext(ctx, user.Locale), tx, *user.Email, email.TemplateNotificationDigest, data)
+	})
+	return sent, err
+}
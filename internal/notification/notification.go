@@ -0,0 +1,175 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"ai-backend/internal/locale"
+	"ai-backend/internal/models"
+	"ai-backend/internal/outbox"
+	"ai-backend/internal/realtime"
+	"ai-backend/internal/repository"
+	"ai-backend/pkg/email"
+)
+
+// Event is something a user is told about. Build one with the constructors below.
+type Event struct {
+	UserID uint
+	// ActorID is the user who caused the event; users are not told about their own actions
+	ActorID uint
+	Type    models.NotificationType
+	// Data is stored with the notification and returned by the API as is
+	Data map[string]any
+
+	template  string
+	emailData any
+}
+
+// Banned tells a user about their ban
+func Banned(ban *models.BanHistory) Event {
+	return Event{
+		UserID:  ban.UserID,
+		ActorID: ban.BannedByID,
+		Type:    models.NotificationBanned,
+		Data: map[string]any{
+			"reason":    ban.Reason,
+			"permanent": ban.EndDate == nil,
+			"until":     ban.EndDate,
+		},
+		template:  email.TemplateBanNotice,
+		emailData: email.BanNoticeData{Reason: ban.Reason, Until: ban.EndDate},
+	}
+}
+
+// Unbanned tells a user that unbannedBy lifted their ban
+func Unbanned(userID, unbannedBy uint, reason string) Event {
+	return Event{
+		UserID:    userID,
+		ActorID:   unbannedBy,
+		Type:      models.NotificationUnbanned,
+		Data:      map[string]any{"reason": reason},
+		template:  email.TemplateUnbanNotice,
+		emailData: email.UnbanNoticeData{Reason: reason},
+	}
+}
+
+// RoleChanged tells a user about a change of their role
+func RoleChanged(history *models.RoleHistory) Event {
+	return Event{
+		UserID:  history.UserID,
+		ActorID: history.ChangedByID,
+		Type:    models.NotificationRoleChanged,
+		Data: map[string]any{
+			"old_role": history.OldRole,
+			"new_role": history.NewRole,
+			"reason":   history.Reason,
+		},
+		template: email.TemplateRoleChanged,
+		emailData: email.RoleChangedData{
+			OldRole: string(history.OldRole),
+			NewRole: string(history.NewRole),
+			Reason:  history.Reason,
+		},
+	}
+}
+
+// Preference is how a user wants to be told about one type of notification:
+// in the app, with an email each, and/or in the periodic digest email
+type Preference struct {
+	InApp  bool `json:"in_app"`
+	Email  bool `json:"email"`
+	Digest bool `json:"digest"`
+}
+
+// Default returns the preference of users who did not choose one. Activity on
+// content is collected in the digest; account changes are emailed right away.
+func Default(notificationType models.NotificationType) Preference {
+	switch notificationType {
+	case models.NotificationNewAnswer, models.NotificationAnswerAccepted:
+		return Preference{InApp: true, Digest: true}
+	default:
+		return Preference{InApp: true, Email: true}
+	}
+}
+
+// IsType reports whether notificationType is one of models.NotificationTypes
+func IsType(notificationType models.NotificationType) bool {
+	for _, known := range models.NotificationTypes {
+		if known == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// PreferenceOf returns the user's preference for notificationType
+func PreferenceOf(store repository.Store, userID uint, notificationType models.NotificationType) (Preference, error) {
+	stored, err := store.Notifications().Preference(userID, notificationType)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return Default(notificationType), nil
+		}
+		return Preference{}, err
+	}
+	return Preference{InApp: stored.InApp, Email: stored.Email, Digest: stored.Digest}, nil
+}
+
+// Notify records event according to the preference of its user, pushes it to
+// the user's open real-time streams and queues its email. Pass the Store of the
+// transaction that makes the change the event reports, and call outbox.Wake
+// once it has committed. The email is written in the user's locale; ctx is only
+// used for its other values.
+func Notify(ctx context.Context, store repository.Store, event Event) error {
+	if event.UserID == 0 || event.UserID == event.ActorID {
+		return nil
+	}
+
+	preference, err := PreferenceOf(store, event.UserID, event.Type)
+	if err != nil {
+		return fmt.Errorf("failed to load notification preference: %w", err)
+	}
+
+	if preference.InApp || preference.Digest {
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			return fmt.Errorf("failed to encode notification data: %w", err)
+		}
+		n := &models.Notification{
+			UserID:        event.UserID,
+			Type:          event.Type,
+			Data:          string(data),
+			InApp:         preference.InApp,
+			DigestPending: preference.Digest,
+		}
+		if err := store.Notifications().Create(n); err != nil {
+			return fmt.Errorf("failed to create notification: %w", err)
+		}
+
+		if preference.InApp {
+			unread, err := store.Notifications().CountUnread(event.UserID)
+			if err != nil {
+				return fmt.Errorf("failed to count unread notifications: %w", err)
+			}
+			if err := realtime.Publish(store, realtime.NotificationCreated(n, unread)); err != nil {
+				return fmt.Errorf("failed to publish notification: %w", err)
+			}
+		}
+	}
+
+	if !preference.Email || event.template == "" {
+		return nil
+	}
+	user, err := store.Users().FindByID(event.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.Email == nil {
+		return nil
+	}
+	return outbox.Enqueue(locale.WithContext(ctx, user.Locale), store, *user.Email, event.template, event.emailData)
+}
@@ -0,0 +1,196 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"ai-backend/internal/background"
+	"ai-backend/internal/config"
+	"ai-backend/internal/locale"
+	"ai-backend/internal/metrics"
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+	"ai-backend/pkg/email"
+)
+
+const (
+	// DispatcherName identifies the dispatcher in background job metrics and health checks
+	DispatcherName = "email_outbox"
+	cleanupName    = "email_outbox_cleanup"
+
+	// lease hides a claimed message from other claims while it is delivered. It
+	// must be longer than sendTimeout, or a slow delivery could be sent twice.
+	lease       = 2 * time.Minute
+	sendTimeout = 30 * time.Second
+)
+
+// Enqueue renders the named email template in the locale of ctx and queues it
+// for delivery to to. Pass the Store of the transaction that makes the change
+// the email reports, and call Wake once it has committed.
+func Enqueue(ctx context.Context, store repository.Store, to, name string, data any) error {
+	msg, err := email.Render(name, locale.FromContext(ctx), data)
+	if err != nil {
+		return err
+	}
+	return store.Outbox().Create(&models.OutboxMessage{
+		Kind:          name,
+		Recipient:     to,
+		Subject:       msg.Subject,
+		HTMLBody:      msg.HTML,
+		TextBody:      msg.Text,
+		Status:        models.OutboxPending,
+		NextAttemptAt: time.Now(),
+	})
+}
+
+// Wake asks the dispatcher to deliver queued messages now instead of at its next poll
+func Wake() {
+	background.Trigger(DispatcherName)
+}
+
+// Dispatcher delivers queued messages. Deliveries are at least once: a message
+// whose delivery succeeded but could not be marked as sent is delivered again.
+type Dispatcher struct {
+	store   repository.Store
+	cfg     config.OutboxConfig
+	metrics *metrics.Metrics
+}
+
+func NewDispatcher(store repository.Store, cfg config.OutboxConfig, m *metrics.Metrics) *Dispatcher {
+	return &Dispatcher{store: store, cfg: cfg, metrics: m}
+}
+
+var (
+	mu      sync.Mutex
+	current *Dispatcher
+)
+
+// Start polls for due messages every cfg.PollInterval, and whenever Wake is
+// called, and deletes delivered messages older than cfg.Retention once an hour
+func Start(store repository.Store, cfg config.OutboxConfig, m *metrics.Metrics) {
+	d := NewDispatcher(store, cfg, m)
+	mu.Lock()
+	current = d
+	mu.Unlock()
+
+	background.Every(DispatcherName, cfg.PollInterval, m, d.Dispatch)
+	background.Every(cleanupName, time.Hour, m, func(context.Context) {
+		d.cleanup()
+	})
+}
+
+// Flush delivers the messages that are due, such as those queued by requests
+// that finished during shutdown, until none are left or ctx is done. It is
+// meant to run after background.Stop has stopped the dispatcher.
+func Flush(ctx context.Context) error {
+	mu.Lock()
+	d := current
+	mu.Unlock()
+	if d == nil {
+		return nil
+	}
+	d.Dispatch(ctx)
+	return ctx.Err()
+}
+
+// Dispatch claims due messages in batches and delivers each batch with
+// cfg.Workers concurrent workers, until no due message is left or ctx is done
+func (d *Dispatcher) Dispatch(ctx context.Context) {
+	batchSize := d.cfg.Workers * 4
+	for ctx.Err() == nil {
+		messages, err := d.store.Outbox().ClaimDue(time.Now(), batchSize, lease)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to claim queued emails", "error", err)
+			return
+		}
+		if len(messages) == 0 {
+			return
+		}
+
+		// A claimed batch is always delivered completely; each delivery has its own
+		// timeout, so shutdown waits for at most one batch
+		jobs := make(chan models.OutboxMessage)
+		var wg sync.WaitGroup
+		for i := 0; i < min(d.cfg.Workers, len(messages)); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range jobs {
+					d.deliver(msg)
+				}
+			}()
+		}
+		for _, msg := range messages {
+			jobs <- msg
+		}
+		close(jobs)
+		wg.Wait()
+
+		if len(messages) < batchSize {
+			return
+		}
+	}
+}
+
+// deliver sends a claimed message and records the outcome, scheduling a retry
+// or dead-lettering the message when it failed
+func (d *Dispatcher) deliver(msg models.OutboxMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	err := email.Deliver(ctx, msg.Kind, email.Message{
+		To:      []string{msg.Recipient},
+		Subject: msg.Subject,
+		HTML:    msg.HTMLBody,
+		Text:    msg.TextBody,
+	})
+	if err == nil {
+		if err := d.store.Outbox().MarkSent(msg.ID, time.Now()); err != nil {
+			slog.Error("Failed to mark queued email as sent", "outbox_id", msg.ID, "error", err)
+		}
+		return
+	}
+
+	if msg.Attempts >= d.cfg.MaxAttempts {
+		slog.Error("Giving up on queued email", "outbox_id", msg.ID, "kind", msg.Kind, "attempts", msg.Attempts, "error", err)
+		d.metrics.ObserveDeadLetter(msg.Kind)
+		if err := d.store.Outbox().MarkDead(msg.ID, err.Error()); err != nil {
+			slog.Error("Failed to dead-letter queued email", "outbox_id", msg.ID, "error", err)
+		}
+		return
+	}
+
+	retryAt := time.Now().Add(Backoff(d.cfg, msg.Attempts))
+	slog.Warn("Queued email will be retried", "outbox_id", msg.ID, "kind", msg.Kind, "attempts", msg.Attempts, "retry_at", retryAt)
+	if err := d.store.Outbox().Retry(msg.ID, err.Error(), retryAt); err != nil {
+		slog.Error("Failed to schedule queued email retry", "outbox_id", msg.ID, "error", err)
+	}
+}
+
+// Backoff returns how long to wait after the given number of failed attempts:
+// BackoffBase doubled per attempt up to BackoffMax, with jitter so messages
+// that failed together are not retried together
+func Backoff(cfg config.OutboxConfig, attempts int) time.Duration {
+	delay := cfg.BackoffBase
+	for i := 1; i < attempts && delay < cfg.BackoffMax; i++ {
+		delay *= 2
+	}
+	delay = min(delay, cfg.BackoffMax)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// cleanup deletes delivered messages older than the retention period. They
+// can hold tokens, so they are not kept longer than needed.
+func (d *Dispatcher) cleanup() {
+	deleted, err := d.store.Outbox().DeleteSentBefore(time.Now().Add(-d.cfg.Retention))
+	if err != nil {
+		slog.Error("Failed to delete delivered emails", "error", err)
+		return
+	}
+	if deleted > 0 {
+		slog.Info("Deleted delivered emails", "count", deleted)
+	}
+}
@@ -0,0 +1,80 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+)
+
+// Channel is the Postgres channel events are published on
+const Channel = "realtime_events"
+
+// maxPayload stays below the 8000 byte limit of a Postgres notification
+const maxPayload = 7900
+
+// EventNotification is the type of the events that push new notifications
+const EventNotification = "notification"
+
+// Event is sent to the subscribers of Topic. Data is truncated, and should be
+// reloaded through the API, when it does not fit in a notification.
+type Event struct {
+	Topic     string          `json:"topic"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+// QuestionTopic is followed by clients showing the question
+func QuestionTopic(questionID uint) string {
+	return fmt.Sprintf("question:%d", questionID)
+}
+
+// UserTopic carries the events meant for one user only
+func UserTopic(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func newEvent(topic, eventType string, data any) Event {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		// Only maps of plain values and valid JSON are passed, so this cannot happen
+		panic(err)
+	}
+	return Event{Topic: topic, Type: eventType, Data: encoded}
+}
+
+// NotificationCreated pushes a new in-app notification to its user together
+// with their unread count
+func NotificationCreated(n *models.Notification, unreadCount int64) Event {
+	data := json.RawMessage(n.Data)
+	if !json.Valid(data) {
+		data = json.RawMessage("{}")
+	}
+	return newEvent(UserTopic(n.UserID), EventNotification, map[string]any{
+		"id":           n.ID,
+		"type":         n.Type,
+		"data":         data,
+		"created_at":   n.CreatedAt.Format(time.RFC3339),
+		"unread_count": unreadCount,
+	})
+}
+
+// Publish sends event to its subscribers on every replica. Pass the Store of
+// the transaction that makes the change the event reports: the event is sent
+// when it commits, so clients never see changes that were rolled back.
+func Publish(store repository.Store, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxPayload {
+		event.Data, event.Truncated = nil, true
+		if payload, err = json.Marshal(event); err != nil {
+			return err
+		}
+	}
+	return store.Events().Publish(Channel, string(payload))
+}
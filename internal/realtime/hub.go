@@ -0,0 +1,176 @@
+package realtime
+
+import (
+	"sync"
+
+	"ai-backend/internal/metrics"
+)
+
+// Hub delivers events to the subscriptions of this process. Events reach it
+// through the Postgres listener, so every replica sees every event.
+type Hub struct {
+	mu      sync.RWMutex
+	topics  map[string]map[*Subscription]struct{}
+	closed  bool
+	metrics *metrics.Metrics
+}
+
+func NewHub(m *metrics.Metrics) *Hub {
+	return &Hub{topics: make(map[string]map[*Subscription]struct{}), metrics: m}
+}
+
+// Subscription receives the events of its topics until it is closed
+type Subscription struct {
+	hub    *Hub
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+	// topics is guarded by hub.mu
+	topics map[string]struct{}
+}
+
+// Subscribe returns a subscription to topics that buffers up to bufferSize
+// events. A subscription that falls further behind is closed.
+func (h *Hub) Subscribe(bufferSize int, topics ...string) *Subscription {
+	s := &Subscription{
+		hub:    h,
+		events: make(chan Event, bufferSize),
+		done:   make(chan struct{}),
+		topics: make(map[string]struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		s.close()
+		return s
+	}
+	for _, topic := range topics {
+		h.add(s, topic)
+	}
+	return s
+}
+
+func (h *Hub) add(s *Subscription, topic string) {
+	subscribers, ok := h.topics[topic]
+	if !ok {
+		subscribers = make(map[*Subscription]struct{})
+		h.topics[topic] = subscribers
+	}
+	subscribers[s] = struct{}{}
+	s.topics[topic] = struct{}{}
+}
+
+func (h *Hub) remove(s *Subscription, topic string) {
+	delete(s.topics, topic)
+	if subscribers, ok := h.topics[topic]; ok {
+		delete(subscribers, s)
+		if len(subscribers) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// Broadcast hands event to the subscriptions of its topic without waiting for them
+func (h *Hub) Broadcast(event Event) {
+	var slow []*Subscription
+
+	h.mu.RLock()
+	for s := range h.topics[event.Topic] {
+		select {
+		case s.events <- event:
+		default:
+			slow = append(slow, s)
+		}
+	}
+	h.mu.RUnlock()
+
+	// A client that cannot keep up would otherwise silently miss events; closing
+	// the stream makes it reconnect and reload
+	for _, s := range slow {
+		h.metrics.ObserveRealtimeDrop()
+		s.Close()
+	}
+}
+
+// Close closes every subscription and rejects new ones. It is called on
+// shutdown so open streams do not hold up the HTTP server.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	var all []*Subscription
+	seen := make(map[*Subscription]bool)
+	for _, subscribers := range h.topics {
+		for s := range subscribers {
+			if !seen[s] {
+				seen[s] = true
+				all = append(all, s)
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	for _, s := range all {
+		s.Close()
+	}
+}
+
+// Add subscribes to topic as well. It reports false when the subscription
+// already follows limit topics or is closed.
+func (s *Subscription) Add(topic string, limit int) bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if _, ok := s.topics[topic]; ok {
+		return true
+	}
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+	if len(s.topics) >= limit {
+		return false
+	}
+	s.hub.add(s, topic)
+	return true
+}
+
+// Remove stops receiving the events of topic
+func (s *Subscription) Remove(topic string) {
+	s.hub.mu.Lock()
+	s.hub.remove(s, topic)
+	s.hub.mu.Unlock()
+}
+
+// Topics returns the number of topics followed
+func (s *Subscription) Topics() int {
+	s.hub.mu.RLock()
+	defer s.hub.mu.RUnlock()
+	return len(s.topics)
+}
+
+// Events delivers the events of the subscribed topics
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed when the subscription is closed
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close unsubscribes from every topic. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	for topic := range s.topics {
+		s.hub.remove(s, topic)
+	}
+	s.hub.mu.Unlock()
+	s.close()
+}
+
+func (s *Subscription) close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
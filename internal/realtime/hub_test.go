@@ -0,0 +1,42 @@
+package realtime
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"ai-backend/internal/metrics"
+)
+
+func TestBroadcastClosesSlowSubscriptions(t *testing.T) {
+	m := metrics.New(prometheus.NewRegistry())
+	hub := NewHub(m)
+	topic := QuestionTopic(1)
+	slow := hub.Subscribe(1, topic)
+	fast := hub.Subscribe(2, topic)
+
+	hub.Broadcast(Event{Topic: topic, Type: "test"})
+	hub.Broadcast(Event{Topic: topic, Type: "test"})
+
+	select {
+	case <-slow.Done():
+	default:
+		t.Error("a subscription that fell behind was not closed")
+	}
+	select {
+	case <-fast.Done():
+		t.Error("a subscription that kept up was closed")
+	default:
+	}
+
+	want := `
+# HELP ai_backend_realtime_dropped_connections_total Real-time connections closed because they did not keep up with their events.
+# TYPE ai_backend_realtime_dropped_connections_total counter
+ai_backend_realtime_dropped_connections_total 1
+`
+	if err := testutil.GatherAndCompare(m.Registry, strings.NewReader(want), "ai_backend_realtime_dropped_connections_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,93 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"ai-backend/internal/background"
+)
+
+// ListenerName identifies the listener in background job metrics
+const ListenerName = "realtime_listener"
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+var listening atomic.Bool
+
+// Listen feeds the events published on Channel by every replica into hub. It
+// holds one database connection of its own, outside the GORM pool, and
+// reconnects with backoff when the connection is lost. Events published while
+// it is disconnected are not delivered.
+func Listen(hub *Hub, databaseURL string) {
+	background.Go(ListenerName, hub.metrics, func(ctx context.Context) {
+		delay := minReconnectDelay
+		for {
+			connected, err := listen(ctx, hub, databaseURL)
+			listening.Store(false)
+			if ctx.Err() != nil {
+				return
+			}
+			if connected {
+				delay = minReconnectDelay
+			}
+			slog.Error("Real-time listener disconnected", "error", err, "retry_in", delay)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, maxReconnectDelay)
+		}
+	})
+}
+
+// listen broadcasts notifications until the connection fails or ctx is done.
+// It reports whether the connection was established.
+func listen(ctx context.Context, hub *Hub, databaseURL string) (bool, error) {
+	conn, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn.Close(closeCtx)
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{Channel}.Sanitize()); err != nil {
+		return true, err
+	}
+	listening.Store(true)
+	slog.Info("Real-time listener connected", "channel", Channel)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			slog.Warn("Ignoring malformed real-time event", "error", err)
+			continue
+		}
+		hub.Broadcast(event)
+	}
+}
+
+// CheckListener returns an error unless the listener is connected
+func CheckListener(context.Context) error {
+	if !listening.Load() {
+		return errors.New("real-time listener is not connected")
+	}
+	return nil
+}
@@ -0,0 +1,1448 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ai-backend/internal/blocklist"
+	"ai-backend/internal/models"
+	"ai-backend/internal/moderation"
+	"ai-backend/internal/stats"
+	"ai-backend/internal/votes"
+)
+
+// notFound maps gorm's missing record error to ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// unscopedUsers preloads related users including soft deleted ones, so exports keep their usernames
+func unscopedUsers(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// historyFiltered returns a query on the rows of model matching filter
+func historyFiltered(db *gorm.DB, model interface{}, filter HistoryFilter) *gorm.DB {
+	query := db.Model(model)
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
+
+// eachBatch walks query in primary key order, size rows at a time
+func eachBatch[T any](query *gorm.DB, size int, fn func([]T) error) error {
+	var batch []T
+	return query.FindInBatches(&batch, size, func(*gorm.DB, int) error {
+		return fn(batch)
+	}).Error
+}
+
+// count counts the rows matching query
+func count(query *gorm.DB) (int64, error) {
+	var total int64
+	err := query.Count(&total).Error
+	return total, err
+}
+
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore returns a Store backed by db. When db is a transaction every
+// repository of the store runs inside it.
+func NewGormStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+func (s *gormStore) Users() UserRepository {
+	return &gormUserRepository{db: s.db}
+}
+
+func (s *gormStore) Freezes() FreezeRepository {
+	return &gormFreezeRepository{db: s.db}
+}
+
+func (s *gormStore) Bans() BanRepository {
+	return &gormBanRepository{db: s.db}
+}
+
+func (s *gormStore) RoleHistories() RoleHistoryRepository {
+	return &gormRoleHistoryRepository{db: s.db}
+}
+
+func (s *gormStore) RestoreHistories() RestoreHistoryRepository {
+	return &gormRestoreHistoryRepository{db: s.db}
+}
+
+func (s *gormStore) Restrictions() RestrictionRepository {
+	return &gormRestrictionRepository{db: s.db}
+}
+
+func (s *gormStore) Sessions() SessionRepository {
+	return &gormSessionRepository{db: s.db}
+}
+
+func (s *gormStore) Tokens() TokenRepository {
+	return &gormTokenRepository{db: s.db}
+}
+
+func (s *gormStore) Blocklist() BlocklistRepository {
+	return &gormBlocklistRepository{db: s.db}
+}
+
+func (s *gormStore) Flags() FlagRepository {
+	return &gormFlagRepository{db: s.db}
+}
+
+func (s *gormStore) Questions() QuestionRepository {
+	return &gormQuestionRepository{db: s.db}
+}
+
+func (s *gormStore) Deletions() DeletionRepository {
+	return &gormDeletionRepository{db: s.db}
+}
+
+func (s *gormStore) Merges() MergeRepository {
+	return &gormMergeRepository{db: s.db}
+}
+
+func (s *gormStore) BulkJobs() BulkJobRepository {
+	return &gormBulkJobRepository{db: s.db}
+}
+
+func (s *gormStore) DataExports() DataExportRepository {
+	return &gormDataExportRepository{db: s.db}
+}
+
+func (s *gormStore) Stats() StatsRepository {
+	return &gormStatsRepository{db: s.db}
+}
+
+func (s *gormStore) Outbox() OutboxRepository {
+	return &gormOutboxRepository{db: s.db}
+}
+
+func (s *gormStore) Notifications() NotificationRepository {
+	return &gormNotificationRepository{db: s.db}
+}
+
+func (s *gormStore) Events() EventRepository {
+	return &gormEventRepository{db: s.db}
+}
+
+func (s *gormStore) Webhooks() WebhookRepository {
+	return &gormWebhookRepository{db: s.db}
+}
+
+func (s *gormStore) WithContext(ctx context.Context) Store {
+	return &gormStore{db: s.db.WithContext(ctx)}
+}
+
+func (s *gormStore) Transaction(fn func(tx Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx})
+	})
+}
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormUserRepository) first(query *gorm.DB) (*models.User, error) {
+	var user models.User
+	if err := query.First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) FindByID(id uint) (*models.User, error) {
+	return r.first(r.db.Where("id = ?", id))
+}
+
+func (r *gormUserRepository) FindByIDUnscoped(id uint) (*models.User, error) {
+	return r.first(r.db.Unscoped().Where("id = ?", id))
+}
+
+func (r *gormUserRepository) FindByLogin(identifier string) (*models.User, error) {
+	return r.first(r.db.Where("email = ? OR username = ?", identifier, identifier))
+}
+
+func (r *gormUserRepository) FindByEmail(email string) (*models.User, error) {
+	return r.first(r.db.Where("email = ?", email))
+}
+
+func (r *gormUserRepository) FindReactivatable(email string, now time.Time) (*models.User, error) {
+	return r.first(r.db.Unscoped().
+		Where("email = ?", email).
+		Where("(deleted_at IS NULL AND status = ?) OR (deleted_at IS NOT NULL AND EXISTS ("+
+			"SELECT 1 FROM account_deletions WHERE account_deletions.user_id = users.id "+
+			"AND account_deletions.status = ? AND account_deletions.scheduled_for > ? AND account_deletions.deleted_at IS NULL))",
+			models.StatusPassive, models.AccountDeletionScheduled, now).
+		Order("deleted_at DESC NULLS FIRST"))
+}
+
+func (r *gormUserRepository) FindFirstSuperAdmin() (*models.User, error) {
+	return r.first(r.db.Where("role = ?", models.RoleSuperAdmin).Order("created_at asc"))
+}
+
+func (r *gormUserRepository) taken(column string, value string, exceptID uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.User{}).
+		Where(column+" = ? AND id <> ?", value, exceptID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *gormUserRepository) EmailTaken(email string, exceptID uint) (bool, error) {
+	return r.taken("email", email, exceptID)
+}
+
+func (r *gormUserRepository) UsernameTaken(username string, exceptID uint) (bool, error) {
+	return r.taken("username", username, exceptID)
+}
+
+// filtered returns a query on the users matching the search, role and status of filter
+func (r *gormUserRepository) filtered(filter UserFilter) *gorm.DB {
+	query := r.db.Model(&models.User{})
+	if filter.Search != "" {
+		searchTerm := "%" + filter.Search + "%"
+		query = query.Where("username ILIKE ? OR email ILIKE ?", searchTerm, searchTerm)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	return query
+}
+
+func (r *gormUserRepository) List(filter UserFilter) ([]models.User, int64, error) {
+	query := r.filtered(filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	if err := query.
+		Order(fmt.Sprintf("%s %s", filter.Sort, filter.Order)).
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *gormUserRepository) ListIDs(filter UserFilter) ([]uint, error) {
+	var ids []uint
+	if err := r.filtered(filter).Order("id asc").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *gormUserRepository) EachBatch(filter UserFilter, size int, fn func([]models.User) error) error {
+	return eachBatch(r.filtered(filter), size, fn)
+}
+
+func (r *gormUserRepository) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r *gormUserRepository) Save(user *models.User) error {
+	return r.db.Save(user).Error
+}
+
+func (r *gormUserRepository) Update(user *models.User, fields map[string]interface{}) error {
+	return r.db.Model(user).Updates(fields).Error
+}
+
+func (r *gormUserRepository) Delete(user *models.User) error {
+	return r.db.Delete(user).Error
+}
+
+func (r *gormUserRepository) Restore(user *models.User, fields map[string]interface{}) error {
+	updates := map[string]interface{}{"deleted_at": nil}
+	for column, value := range fields {
+		updates[column] = value
+	}
+	if err := r.db.Unscoped().Model(user).Updates(updates).Error; err != nil {
+		return err
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+type gormFreezeRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormFreezeRepository) FindActive(userID uint, now time.Time) (*models.FreezeHistory, error) {
+	var freeze models.FreezeHistory
+	if err := r.db.Where("user_id = ? AND is_active = ? AND end_date > ?", userID, true, now).
+		First(&freeze).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &freeze, nil
+}
+
+func (r *gormFreezeRepository) ListByUser(userID uint) ([]models.FreezeHistory, error) {
+	var freezes []models.FreezeHistory
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&freezes).Error; err != nil {
+		return nil, err
+	}
+	return freezes, nil
+}
+
+func (r *gormFreezeRepository) Create(freeze *models.FreezeHistory) error {
+	return r.db.Create(freeze).Error
+}
+
+func (r *gormFreezeRepository) CloseExpired(userID uint, now time.Time) error {
+	return r.db.Model(&models.FreezeHistory{}).
+		Where("user_id = ? AND is_active = ? AND end_date <= ?", userID, true, now).
+		Updates(map[string]interface{}{
+			"is_active":   false,
+			"unfrozen_at": now,
+		}).Error
+}
+
+func (r *gormFreezeRepository) ExpireDue(now time.Time) (int64, error) {
+	var expired int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var userIDs []uint
+		if err := tx.Model(&models.FreezeHistory{}).
+			Where("is_active = ? AND end_date <= ?", true, now).
+			Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+
+		result := tx.Model(&models.FreezeHistory{}).
+			Where("is_active = ? AND end_date <= ?", true, now).
+			Updates(map[string]interface{}{
+				"is_active":   false,
+				"unfrozen_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		expired = result.RowsAffected
+
+		return tx.Model(&models.User{}).
+			Where("id IN ? AND status = ?", userIDs, models.StatusFrozen).
+			Where("NOT EXISTS (SELECT 1 FROM freeze_histories WHERE freeze_histories.user_id = users.id AND freeze_histories.is_active = ? AND freeze_histories.deleted_at IS NULL)", true).
+			Update("status", models.StatusActive).Error
+	})
+	return expired, err
+}
+
+func (r *gormFreezeRepository) EachBatch(filter HistoryFilter, size int, fn func([]models.FreezeHistory) error) error {
+	query := historyFiltered(r.db, &models.FreezeHistory{}, filter).Preload("User", unscopedUsers)
+	return eachBatch(query, size, fn)
+}
+
+type gormBanRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormBanRepository) FindActive(userID uint) (*models.BanHistory, error) {
+	var ban models.BanHistory
+	if err := r.db.Preload("BannedBy").
+		Where("user_id = ? AND is_active = ?", userID, true).
+		First(&ban).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &ban, nil
+}
+
+func (r *gormBanRepository) Create(ban *models.BanHistory) error {
+	return r.db.Create(ban).Error
+}
+
+func (r *gormBanRepository) Save(ban *models.BanHistory) error {
+	return r.db.Save(ban).Error
+}
+
+func (r *gormBanRepository) ExpireDue(now time.Time) (int64, error) {
+	var expired int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var userIDs []uint
+		if err := tx.Model(&models.BanHistory{}).
+			Where("is_active = ? AND end_date IS NOT NULL AND end_date <= ?", true, now).
+			Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+
+		result := tx.Model(&models.BanHistory{}).
+			Where("is_active = ? AND end_date IS NOT NULL AND end_date <= ?", true, now).
+			Updates(map[string]interface{}{
+				"is_active":   false,
+				"unbanned_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		expired = result.RowsAffected
+
+		return tx.Model(&models.User{}).
+			Where("id IN ? AND status = ?", userIDs, models.StatusBanned).
+			Where("NOT EXISTS (SELECT 1 FROM ban_histories WHERE ban_histories.user_id = users.id AND ban_histories.is_active = ? AND ban_histories.deleted_at IS NULL)", true).
+			Update("status", models.StatusActive).Error
+	})
+	return expired, err
+}
+
+func (r *gormBanRepository) withUsers() *gorm.DB {
+	return r.db.Preload("User").Preload("BannedBy").Preload("Unbanner")
+}
+
+func (r *gormBanRepository) ListByUser(userID uint) ([]models.BanHistory, error) {
+	var bans []models.BanHistory
+	if err := r.withUsers().Where("user_id = ?", userID).Order("created_at desc").Find(&bans).Error; err != nil {
+		return nil, err
+	}
+	return bans, nil
+}
+
+func (r *gormBanRepository) List(filter BanFilter) ([]models.BanHistory, int64, error) {
+	query := r.db.Model(&models.BanHistory{})
+	if filter.Active != nil {
+		query = query.Where("is_active = ?", *filter.Active)
+	}
+	if filter.Permanent != nil {
+		if *filter.Permanent {
+			query = query.Where("duration_days IS NULL")
+		} else {
+			query = query.Where("duration_days IS NOT NULL")
+		}
+	}
+
+	total, err := count(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var bans []models.BanHistory
+	if err := query.Preload("User").Preload("BannedBy").Preload("Unbanner").
+		Order("created_at desc").
+		Offset(filter.Offset).Limit(filter.Limit).
+		Find(&bans).Error; err != nil {
+		return nil, 0, err
+	}
+	return bans, total, nil
+}
+
+func (r *gormBanRepository) EachBatch(filter HistoryFilter, size int, fn func([]models.BanHistory) error) error {
+	query := historyFiltered(r.db, &models.BanHistory{}, filter).
+		Preload("User", unscopedUsers).Preload("BannedBy", unscopedUsers)
+	return eachBatch(query, size, fn)
+}
+
+type gormRoleHistoryRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormRoleHistoryRepository) Create(history *models.RoleHistory) error {
+	return r.db.Create(history).Error
+}
+
+func (r *gormRoleHistoryRepository) ListByUser(userID uint) ([]models.RoleHistory, error) {
+	var histories []models.RoleHistory
+	if err := r.db.Preload("User").Preload("ChangedBy").
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
+
+func (r *gormRoleHistoryRepository) List(offset, limit int) ([]models.RoleHistory, int64, error) {
+	total, err := count(r.db.Model(&models.RoleHistory{}))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var histories []models.RoleHistory
+	if err := r.db.Preload("User").Preload("ChangedBy").
+		Order("created_at desc").
+		Offset(offset).Limit(limit).
+		Find(&histories).Error; err != nil {
+		return nil, 0, err
+	}
+	return histories, total, nil
+}
+
+func (r *gormRoleHistoryRepository) EachBatch(filter HistoryFilter, size int, fn func([]models.RoleHistory) error) error {
+	query := historyFiltered(r.db, &models.RoleHistory{}, filter).
+		Preload("User", unscopedUsers).Preload("ChangedBy", unscopedUsers)
+	return eachBatch(query, size, fn)
+}
+
+type gormRestoreHistoryRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormRestoreHistoryRepository) Create(history *models.RestoreHistory) error {
+	return r.db.Create(history).Error
+}
+
+type gormRestrictionRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormRestrictionRepository) HasActive(userID uint, restrictionType models.RestrictionType, now time.Time) (bool, error) {
+	total, err := count(r.db.Model(&models.RestrictionHistory{}).
+		Where("user_id = ? AND type = ? AND is_active = ? AND (end_date IS NULL OR end_date > ?)", userID, restrictionType, true, now))
+	return total > 0, err
+}
+
+func (r *gormRestrictionRepository) FindActive(userID, id uint) (*models.RestrictionHistory, error) {
+	var restriction models.RestrictionHistory
+	if err := r.db.Preload("RestrictedBy").
+		Where("id = ? AND user_id = ? AND is_active = ?", id, userID, true).
+		First(&restriction).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &restriction, nil
+}
+
+func (r *gormRestrictionRepository) ListByUser(userID uint) ([]models.RestrictionHistory, error) {
+	var restrictions []models.RestrictionHistory
+	if err := r.db.Preload("RestrictedBy").Preload("LiftedBy").
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Find(&restrictions).Error; err != nil {
+		return nil, err
+	}
+	return restrictions, nil
+}
+
+func (r *gormRestrictionRepository) ListActive(userID uint) ([]models.RestrictionHistory, error) {
+	return moderation.ActiveRestrictions(r.db, userID)
+}
+
+func (r *gormRestrictionRepository) CountRecentPosts(userID uint, since time.Time) (int64, error) {
+	return moderation.CountRecentPosts(r.db, userID, since)
+}
+
+func (r *gormRestrictionRepository) Create(restriction *models.RestrictionHistory) error {
+	return r.db.Create(restriction).Error
+}
+
+func (r *gormRestrictionRepository) Save(restriction *models.RestrictionHistory) error {
+	return r.db.Save(restriction).Error
+}
+
+type gormSessionRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormSessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *gormSessionRepository) DeleteByUser(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.Session{}).Error
+}
+
+type gormTokenRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormTokenRepository) Create(token *models.VerificationToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *gormTokenRepository) FindValid(token string, now time.Time) (*models.VerificationToken, error) {
+	var verificationToken models.VerificationToken
+	if err := r.db.Where("token = ? AND expires > ?", token, now).First(&verificationToken).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &verificationToken, nil
+}
+
+func (r *gormTokenRepository) Delete(token *models.VerificationToken) error {
+	return r.db.Delete(token).Error
+}
+
+type gormBlocklistRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormBlocklistRepository) IsIPBlocked(ip string) (bool, error) {
+	return blocklist.IsIPBlocked(r.db, ip)
+}
+
+func (r *gormBlocklistRepository) IsEmailDomainBlocked(email string, includeDisposable bool) (bool, error) {
+	return blocklist.IsEmailDomainBlocked(r.db, email, includeDisposable)
+}
+
+func (r *gormBlocklistRepository) RecordUserIPs(userID uint, createdByID uint, reason string, sinceDays int, expiresAt *time.Time) ([]string, error) {
+	return blocklist.RecordUserIPs(r.db, userID, createdByID, reason, sinceDays, expiresAt)
+}
+
+func (r *gormBlocklistRepository) List(entryType models.BlocklistType, offset, limit int) ([]models.BlocklistEntry, int64, error) {
+	query := r.db.Model(&models.BlocklistEntry{})
+	if entryType != "" {
+		query = query.Where("type = ?", entryType)
+	}
+
+	total, err := count(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.BlocklistEntry
+	if err := query.Preload("CreatedBy").
+		Order("created_at desc").
+		Offset(offset).Limit(limit).
+		Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (r *gormBlocklistRepository) ActiveEntry(entryType models.BlocklistType, value string) (*models.BlocklistEntry, error) {
+	entry, err := blocklist.ActiveEntry(r.db, entryType, value)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrNotFound
+	}
+	return entry, nil
+}
+
+func (r *gormBlocklistRepository) FindByID(id uint) (*models.BlocklistEntry, error) {
+	var entry models.BlocklistEntry
+	if err := r.db.First(&entry, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &entry, nil
+}
+
+func (r *gormBlocklistRepository) Create(entry *models.BlocklistEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *gormBlocklistRepository) Delete(entry *models.BlocklistEntry) error {
+	return r.db.Delete(entry).Error
+}
+
+// pendingFlagStatuses are the statuses of flags waiting for a moderator
+var pendingFlagStatuses = []models.FlagStatus{models.FlagStatusOpen, models.FlagStatusEscalated}
+
+type gormFlagRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormFlagRepository) TargetOwner(targetType models.FlagTargetType, targetID uint) (uint, error) {
+	ownerID, err := moderation.FlagTargetOwner(r.db, targetType, targetID)
+	return ownerID, notFound(err)
+}
+
+func (r *gormFlagRepository) Exists(targetType models.FlagTargetType, targetID, reporterID uint) (bool, error) {
+	total, err := count(r.db.Model(&models.Flag{}).
+		Where("target_type = ? AND target_id = ? AND reporter_id = ?", targetType, targetID, reporterID))
+	return total > 0, err
+}
+
+func (r *gormFlagRepository) Create(flag *models.Flag) error {
+	return r.db.Create(flag).Error
+}
+
+func (r *gormFlagRepository) ApplyAutoHide(targetType models.FlagTargetType, targetID uint) (bool, error) {
+	return moderation.ApplyAutoHide(r.db, targetType, targetID)
+}
+
+func (r *gormFlagRepository) Queue(filter FlagQueueFilter) ([]FlagQueueEntry, int64, error) {
+	grouped := r.db.Model(&models.Flag{}).
+		Select("target_type, target_id, COUNT(*) AS flag_count, COUNT(DISTINCT reporter_id) AS reporter_count, "+
+			"STRING_AGG(DISTINCT reason, ',') AS reasons, MIN(created_at) AS first_flagged_at, MAX(created_at) AS last_flagged_at").
+		Where("status = ?", filter.Status).
+		Group("target_type, target_id")
+	if filter.TargetType != "" {
+		grouped = grouped.Where("target_type = ?", filter.TargetType)
+	}
+
+	total, err := count(r.db.Table("(?) AS grouped_flags", grouped))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entries []FlagQueueEntry
+	if err := grouped.
+		Order("reporter_count desc, first_flagged_at asc").
+		Offset(filter.Offset).Limit(filter.Limit).
+		Scan(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (r *gormFlagRepository) pending(targetType models.FlagTargetType, targetID uint) *gorm.DB {
+	return r.db.Model(&models.Flag{}).
+		Where("target_type = ? AND target_id = ? AND status IN ?", targetType, targetID, pendingFlagStatuses)
+}
+
+func (r *gormFlagRepository) CountPending(targetType models.FlagTargetType, targetID uint) (int64, error) {
+	return count(r.pending(targetType, targetID))
+}
+
+func (r *gormFlagRepository) CountEscalated(targetType models.FlagTargetType, targetID uint) (int64, error) {
+	return count(r.db.Model(&models.Flag{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.FlagStatusEscalated))
+}
+
+func (r *gormFlagRepository) ResolvePending(targetType models.FlagTargetType, targetID uint, fields map[string]interface{}) error {
+	return r.pending(targetType, targetID).Updates(fields).Error
+}
+
+func (r *gormFlagRepository) SetContentHidden(targetType models.FlagTargetType, targetID uint, hidden bool) error {
+	return moderation.SetContentHidden(r.db, targetType, targetID, hidden)
+}
+
+func (r *gormFlagRepository) DeleteContent(targetType models.FlagTargetType, targetID uint) error {
+	return moderation.DeleteFlaggedContent(r.db, targetType, targetID)
+}
+
+type gormQuestionRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormQuestionRepository) FindByID(id uint) (*models.Question, error) {
+	var question models.Question
+	if err := r.db.First(&question, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &question, nil
+}
+
+type gormDeletionRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormDeletionRepository) Create(deletion *models.AccountDeletion) error {
+	return r.db.Create(deletion).Error
+}
+
+func (r *gormDeletionRepository) Latest(userID uint) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&deletion).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &deletion, nil
+}
+
+func (r *gormDeletionRepository) Cancel(userID uint, now time.Time) (bool, error) {
+	result := r.db.Model(&models.AccountDeletion{}).
+		Where("user_id = ? AND status = ? AND scheduled_for > ?", userID, models.AccountDeletionScheduled, now).
+		Updates(map[string]interface{}{
+			"status":       models.AccountDeletionCancelled,
+			"cancelled_at": now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+type gormMergeRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormMergeRepository) IsMergedSource(userID uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.UserMerge{}).Where("source_user_id = ?", userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *gormMergeRepository) Apply(merge *models.UserMerge) error {
+	tx := r.db
+	sourceID, targetID := merge.SourceUserID, merge.TargetUserID
+
+	// Runs before content moves so the flags still point at their original owners
+	if err := r.dismissMutualFlags(merge); err != nil {
+		return err
+	}
+
+	// Remember what source voted on so the counts can be recomputed afterwards
+	var questionIDs, answerIDs []uint
+	if err := tx.Model(&models.Vote{}).Where("user_id = ? AND answer_id IS NULL", sourceID).
+		Distinct().Pluck("question_id", &questionIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Vote{}).Where("user_id = ? AND answer_id IS NOT NULL", sourceID).
+		Distinct().Pluck("answer_id", &answerIDs).Error; err != nil {
+		return err
+	}
+
+	// Target's vote wins when both users voted on the same content
+	result := tx.Where("user_id = ? AND EXISTS (SELECT 1 FROM votes existing WHERE existing.user_id = ? AND existing.deleted_at IS NULL "+
+		"AND existing.question_id IS NOT DISTINCT FROM votes.question_id AND existing.answer_id IS NOT DISTINCT FROM votes.answer_id)",
+		sourceID, targetID).Delete(&models.Vote{})
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.VotesDropped = result.RowsAffected
+
+	result = tx.Model(&models.Vote{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.VotesMoved = result.RowsAffected
+
+	result = tx.Unscoped().Model(&models.Question{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.QuestionsMoved = result.RowsAffected
+
+	result = tx.Unscoped().Model(&models.Answer{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.AnswersMoved = result.RowsAffected
+
+	result = tx.Model(&models.Account{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.AccountsMoved = result.RowsAffected
+
+	if err := r.moveNotifications(merge); err != nil {
+		return err
+	}
+
+	if err := r.settleRestrictions(merge); err != nil {
+		return err
+	}
+
+	if err := r.moveHistories(merge); err != nil {
+		return err
+	}
+
+	if err := r.moveFlags(merge); err != nil {
+		return err
+	}
+
+	if err := votes.Recompute(tx, questionIDs, answerIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Where("user_id = ?", sourceID).Delete(&models.Session{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Delete(&models.User{}, sourceID).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(merge).Error
+}
+
+// moveHistories points every moderation history of the source at the target
+func (r *gormMergeRepository) moveHistories(merge *models.UserMerge) error {
+	for _, model := range []interface{}{
+		&models.BanHistory{},
+		&models.RoleHistory{},
+		&models.RestoreHistory{},
+		&models.FreezeHistory{},
+		&models.RestrictionHistory{},
+	} {
+		result := r.db.Model(model).Where("user_id = ?", merge.SourceUserID).Update("user_id", merge.TargetUserID)
+		if result.Error != nil {
+			return result.Error
+		}
+		merge.HistoriesMoved += result.RowsAffected
+	}
+	return nil
+}
+
+// moveFlags moves flags reported by and about the source to the target, dropping the
+// ones that would duplicate the target's flags
+func (r *gormMergeRepository) moveFlags(merge *models.UserMerge) error {
+	tx := r.db
+	sourceID, targetID := merge.SourceUserID, merge.TargetUserID
+
+	result := tx.Where("reporter_id = ? AND EXISTS (SELECT 1 FROM flags existing WHERE existing.reporter_id = ? "+
+		"AND existing.target_type = flags.target_type AND existing.target_id = flags.target_id AND existing.deleted_at IS NULL)",
+		sourceID, targetID).Delete(&models.Flag{})
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.FlagsDropped += result.RowsAffected
+
+	result = tx.Model(&models.Flag{}).Where("reporter_id = ?", sourceID).Update("reporter_id", targetID)
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.FlagsMoved += result.RowsAffected
+
+	result = tx.Where("target_type = ? AND target_id = ? AND EXISTS (SELECT 1 FROM flags existing WHERE existing.target_type = flags.target_type "+
+		"AND existing.target_id = ? AND existing.reporter_id = flags.reporter_id AND existing.deleted_at IS NULL)",
+		models.FlagTargetUser, sourceID, targetID).Delete(&models.Flag{})
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.FlagsDropped += result.RowsAffected
+
+	result = tx.Model(&models.Flag{}).Where("target_type = ? AND target_id = ?", models.FlagTargetUser, sourceID).Update("target_id", targetID)
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.FlagsMoved += result.RowsAffected
+
+	return nil
+}
+
+// mutualFlagCondition matches flags filed by one user against the other or the other's
+// questions and answers. Its arguments come from mutualFlagArgs.
+const mutualFlagCondition = "reporter_id = ? AND ((target_type = ? AND target_id = ?) OR " +
+	"(target_type = ? AND target_id IN (SELECT id FROM questions WHERE questions.user_id = ?)) OR " +
+	"(target_type = ? AND target_id IN (SELECT id FROM answers WHERE answers.user_id = ?)))"
+
+func mutualFlagArgs(reporterID, ownerID uint) []interface{} {
+	return []interface{}{reporterID, models.FlagTargetUser, ownerID, models.FlagTargetQuestion, ownerID, models.FlagTargetAnswer, ownerID}
+}
+
+// dismissMutualFlags dismisses the open flags the source and target filed against each
+// other. After the merge they would be reports of the target against itself.
+func (r *gormMergeRepository) dismissMutualFlags(merge *models.UserMerge) error {
+	now := time.Now()
+	note := "Dismissed automatically: the reporter and the reported user were merged"
+	result := r.db.Model(&models.Flag{}).
+		Where("status = ?", models.FlagStatusOpen).
+		Where("("+mutualFlagCondition+") OR ("+mutualFlagCondition+")",
+			append(mutualFlagArgs(merge.SourceUserID, merge.TargetUserID), mutualFlagArgs(merge.TargetUserID, merge.SourceUserID)...)...).
+		Updates(map[string]interface{}{
+			"status":          models.FlagStatusDismissed,
+			"resolved_by_id":  merge.MergedByID,
+			"resolved_at":     now,
+			"resolution_note": note,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.FlagsDismissed = result.RowsAffected
+	return nil
+}
+
+// settleRestrictions lifts the source's active content restrictions that do not carry
+// over to the target: all of them unless merge.CarryRestrictions is set, and otherwise
+// the ones whose type the target already has active. The histories are moved afterwards.
+func (r *gormMergeRepository) settleRestrictions(merge *models.UserMerge) error {
+	query := r.db.Model(&models.RestrictionHistory{}).Where("user_id = ? AND is_active = ?", merge.SourceUserID, true)
+	reason := "Not carried over when the user was merged into another user"
+	if merge.CarryRestrictions {
+		query = query.Where("type IN (?)", r.db.Model(&models.RestrictionHistory{}).
+			Select("type").
+			Where("user_id = ? AND is_active = ?", merge.TargetUserID, true))
+		reason = "Merged into a user that already has an active restriction of this type"
+	}
+
+	now := time.Now()
+	result := query.Updates(map[string]interface{}{
+		"is_active":    false,
+		"lifted_at":    now,
+		"lifted_by_id": merge.MergedByID,
+		"lift_reason":  reason,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.RestrictionsLifted = result.RowsAffected
+	return nil
+}
+
+// moveNotifications moves the source's notifications, notification preferences and data
+// exports to the target. The target's preference wins when both users set one for a type.
+func (r *gormMergeRepository) moveNotifications(merge *models.UserMerge) error {
+	tx := r.db
+	sourceID, targetID := merge.SourceUserID, merge.TargetUserID
+
+	result := tx.Model(&models.Notification{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.NotificationsMoved = result.RowsAffected
+
+	if err := tx.Where("user_id = ? AND type IN (?)", sourceID, tx.Model(&models.NotificationPreference{}).
+		Select("type").
+		Where("user_id = ?", targetID)).
+		Delete(&models.NotificationPreference{}).Error; err != nil {
+		return err
+	}
+	result = tx.Model(&models.NotificationPreference{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.NotificationsMoved += result.RowsAffected
+
+	result = tx.Unscoped().Model(&models.DataExport{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+	if result.Error != nil {
+		return result.Error
+	}
+	merge.ExportsMoved = result.RowsAffected
+	return nil
+}
+
+type gormBulkJobRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormBulkJobRepository) Create(job *models.BulkJob) error {
+	return r.db.Create(job).Error
+}
+
+func (r *gormBulkJobRepository) FindByID(id uint) (*models.BulkJob, error) {
+	var job models.BulkJob
+	if err := r.db.First(&job, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &job, nil
+}
+
+func (r *gormBulkJobRepository) Update(id uint, fields map[string]interface{}) error {
+	return r.db.Model(&models.BulkJob{}).Where("id = ?", id).Updates(fields).Error
+}
+
+type gormDataExportRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormDataExportRepository) HasInProgress(userID uint) (bool, error) {
+	total, err := count(r.db.Model(&models.DataExport{}).
+		Where("user_id = ? AND status IN ?", userID, []models.DataExportStatus{models.DataExportPending, models.DataExportProcessing}))
+	return total > 0, err
+}
+
+func (r *gormDataExportRepository) Create(export *models.DataExport) error {
+	return r.db.Create(export).Error
+}
+
+func (r *gormDataExportRepository) ListByUser(userID uint, limit int) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *gormDataExportRepository) FindDownloadable(tokenHash string, now time.Time) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := r.db.Where("token_hash = ? AND status = ? AND expires_at > ?", tokenHash, models.DataExportReady, now).
+		First(&export).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &export, nil
+}
+
+type gormStatsRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormStatsRepository) Dashboard(from, to time.Time) (*stats.Snapshot, error) {
+	return stats.Compute(r.db, from, to)
+}
+
+type gormOutboxRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormOutboxRepository) Create(msg *models.OutboxMessage) error {
+	return r.db.Create(msg).Error
+}
+
+func (r *gormOutboxRepository) FindByID(id uint) (*models.OutboxMessage, error) {
+	var msg models.OutboxMessage
+	if err := r.db.First(&msg, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &msg, nil
+}
+
+func (r *gormOutboxRepository) ClaimDue(now time.Time, limit int, lease time.Duration) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED lets several replicas claim disjoint batches concurrently
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.OutboxPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(messages))
+		for i := range messages {
+			ids[i] = messages[i].ID
+			messages[i].Attempts++
+		}
+		return tx.Model(&models.OutboxMessage{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"attempts":        gorm.Expr("attempts + 1"),
+				"next_attempt_at": now.Add(lease),
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *gormOutboxRepository) MarkSent(id uint, now time.Time) error {
+	return r.db.Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.OutboxSent,
+		"sent_at":    now,
+		"last_error": nil,
+	}).Error
+}
+
+func (r *gormOutboxRepository) Retry(id uint, lastError string, at time.Time) error {
+	return r.db.Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_error":      lastError,
+		"next_attempt_at": at,
+	}).Error
+}
+
+func (r *gormOutboxRepository) MarkDead(id uint, lastError string) error {
+	return r.db.Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.OutboxDead,
+		"last_error": lastError,
+	}).Error
+}
+
+func (r *gormOutboxRepository) Requeue(id uint, now time.Time) error {
+	result := r.db.Model(&models.OutboxMessage{}).
+		Where("id = ? AND status = ?", id, models.OutboxDead).
+		Updates(map[string]interface{}{
+			"status":          models.OutboxPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormOutboxRepository) List(status models.OutboxStatus, offset, limit int) ([]models.OutboxMessage, int64, error) {
+	query := r.db.Model(&models.OutboxMessage{}).Where("status = ?", status)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var messages []models.OutboxMessage
+	if err := query.Order("updated_at DESC").Offset(offset).Limit(limit).Find(&messages).Error; err != nil {
+		return nil, 0, err
+	}
+	return messages, total, nil
+}
+
+func (r *gormOutboxRepository) DeleteSentBefore(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND updated_at < ?", models.OutboxSent, before).Delete(&models.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
+
+type gormNotificationRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormNotificationRepository) Create(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+func (r *gormNotificationRepository) List(userID uint, unreadOnly bool, offset, limit int) ([]models.Notification, int64, error) {
+	query := r.db.Model(&models.Notification{}).Where("user_id = ? AND in_app", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+func (r *gormNotificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND in_app AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *gormNotificationRepository) MarkRead(userID, id uint, now time.Time) error {
+	var notification models.Notification
+	if err := r.db.Where("id = ? AND user_id = ? AND in_app", id, userID).First(&notification).Error; err != nil {
+		return notFound(err)
+	}
+	if notification.ReadAt != nil {
+		return nil
+	}
+	return r.db.Model(&notification).Updates(map[string]interface{}{
+		"read_at":        now,
+		"digest_pending": false,
+	}).Error
+}
+
+func (r *gormNotificationRepository) MarkAllRead(userID uint, now time.Time) (int64, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND in_app AND read_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"read_at":        now,
+			"digest_pending": false,
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *gormNotificationRepository) Preference(userID uint, notificationType models.NotificationType) (*models.NotificationPreference, error) {
+	var preference models.NotificationPreference
+	if err := r.db.Where("user_id = ? AND type = ?", userID, notificationType).First(&preference).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &preference, nil
+}
+
+func (r *gormNotificationRepository) Preferences(userID uint) ([]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+	if err := r.db.Where("user_id = ?", userID).Order("type").Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+func (r *gormNotificationRepository) SavePreference(preference *models.NotificationPreference) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "digest", "updated_at"}),
+	}).Create(preference).Error
+}
+
+func (r *gormNotificationRepository) DigestUserIDs(afterID uint, limit int) ([]uint, error) {
+	var userIDs []uint
+	err := r.db.Model(&models.Notification{}).
+		Distinct("user_id").
+		Where("digest_pending AND user_id > ?", afterID).
+		Order("user_id").
+		Limit(limit).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+func (r *gormNotificationRepository) PendingDigest(userID uint, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.db.Where("user_id = ? AND digest_pending AND read_at IS NULL", userID).
+		Order("created_at, id").
+		Limit(limit).
+		Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *gormNotificationRepository) ClearDigest(userID uint) error {
+	return r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND digest_pending", userID).
+		Update("digest_pending", false).Error
+}
+
+type gormEventRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormEventRepository) Publish(channel, payload string) error {
+	return r.db.Exec("SELECT pg_notify(?, ?)", channel, payload).Error
+}
+
+type gormWebhookRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormWebhookRepository) CreateSubscription(subscription *models.WebhookSubscription) error {
+	return r.db.Create(subscription).Error
+}
+
+func (r *gormWebhookRepository) FindSubscription(id uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := r.db.Preload("CreatedBy").First(&subscription, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &subscription, nil
+}
+
+func (r *gormWebhookRepository) ListSubscriptions(offset, limit int) ([]models.WebhookSubscription, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.WebhookSubscription{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var subscriptions []models.WebhookSubscription
+	if err := r.db.Preload("CreatedBy").Order("id").Offset(offset).Limit(limit).Find(&subscriptions).Error; err != nil {
+		return nil, 0, err
+	}
+	return subscriptions, total, nil
+}
+
+func (r *gormWebhookRepository) ActiveSubscriptions(eventType string) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := r.db.Where("active AND ? = ANY(string_to_array(event_types, ','))", eventType).
+		Order("id").
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *gormWebhookRepository) UpdateSubscription(subscription *models.WebhookSubscription, fields map[string]interface{}) error {
+	return r.db.Model(subscription).Updates(fields).Error
+}
+
+func (r *gormWebhookRepository) DeleteSubscription(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.WebhookSubscription{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *gormWebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+func (r *gormWebhookRepository) FindDelivery(subscriptionID, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.Where("id = ? AND subscription_id = ?", id, subscriptionID).First(&delivery).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &delivery, nil
+}
+
+func (r *gormWebhookRepository) ListDeliveries(filter WebhookDeliveryFilter) ([]models.WebhookDelivery, int64, error) {
+	query := r.db.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", filter.SubscriptionID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("created_at DESC, id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+func (r *gormWebhookRepository) ClaimDue(now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED lets several replicas claim disjoint batches concurrently
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].ID
+			deliveries[i].Attempts++
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"attempts":        gorm.Expr("attempts + 1"),
+				"next_attempt_at": now.Add(lease),
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *gormWebhookRepository) RecordAttempt(id uint, fields map[string]interface{}) error {
+	return r.db.Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *gormWebhookRepository) Redeliver(subscriptionID, id uint, now time.Time) error {
+	result := r.db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND subscription_id = ? AND status = ?", id, subscriptionID, models.WebhookDeliveryFailed).
+		Updates(map[string]interface{}{
+			"status":          models.WebhookDeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormWebhookRepository) DeleteFinishedBefore(before time.Time) (int64, error) {
+	result := r.db.Where("status IN ? AND updated_at < ?",
+		[]models.WebhookDeliveryStatus{models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed}, before).
+		Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
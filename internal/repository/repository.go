@@ -0,0 +1,397 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-backend/internal/models"
+	"ai-backend/internal/stats"
+)
+
+// ErrNotFound is returned when a lookup matches no record
+var ErrNotFound = errors.New("record not found")
+
+// UserFilter narrows and orders a user listing
+type UserFilter struct {
+	Search string // matched against username and email
+	Role   string
+	Status string
+	Sort   string // column name, validated by the caller
+	Order  string // "asc" or "desc"
+	Offset int
+	Limit  int
+}
+
+// Validate rejects unknown roles and statuses, which would otherwise silently match no user
+func (f UserFilter) Validate() error {
+	if f.Role != "" && !models.UserRole(f.Role).IsValid() {
+		return fmt.Errorf("invalid role filter %q", f.Role)
+	}
+	if f.Status != "" && !models.UserStatus(f.Status).IsValid() {
+		return fmt.Errorf("invalid status filter %q", f.Status)
+	}
+	return nil
+}
+
+// UserRepository stores users. Lookups skip soft deleted users unless stated otherwise.
+type UserRepository interface {
+	FindByID(id uint) (*models.User, error)
+	// FindByIDUnscoped also returns soft deleted users
+	FindByIDUnscoped(id uint) (*models.User, error)
+	// FindByLogin matches identifier against email and username
+	FindByLogin(identifier string) (*models.User, error)
+	FindByEmail(email string) (*models.User, error)
+	// FindReactivatable returns the passive user, or the deleted user still inside the
+	// deletion grace period, registered with email
+	FindReactivatable(email string, now time.Time) (*models.User, error)
+	// FindFirstSuperAdmin returns the earliest created SUPER_ADMIN
+	FindFirstSuperAdmin() (*models.User, error)
+	EmailTaken(email string, exceptID uint) (bool, error)
+	UsernameTaken(username string, exceptID uint) (bool, error)
+	List(filter UserFilter) ([]models.User, int64, error)
+	// ListIDs returns the IDs of the users matching the search, role and status
+	// of filter in ascending order. Sort and paging are ignored.
+	ListIDs(filter UserFilter) ([]uint, error)
+	// EachBatch walks the users matching the search, role and status of filter in
+	// ID order, size users at a time, stopping at the first error fn returns
+	EachBatch(filter UserFilter, size int, fn func([]models.User) error) error
+	Create(user *models.User) error
+	Save(user *models.User) error
+	Update(user *models.User, fields map[string]interface{}) error
+	Delete(user *models.User) error
+	// Restore clears the soft delete of user and applies fields
+	Restore(user *models.User, fields map[string]interface{}) error
+}
+
+// HistoryFilter narrows a moderation history export
+type HistoryFilter struct {
+	UserID uint       // any user when 0
+	From   *time.Time // created at or after
+	To     *time.Time // created before
+}
+
+// FreezeRepository stores account freezes
+type FreezeRepository interface {
+	// FindActive returns the user's active freeze that has not reached its end date
+	FindActive(userID uint, now time.Time) (*models.FreezeHistory, error)
+	ListByUser(userID uint) ([]models.FreezeHistory, error)
+	Create(freeze *models.FreezeHistory) error
+	// CloseExpired closes the user's active freezes that reached their end date
+	CloseExpired(userID uint, now time.Time) error
+	// ExpireDue closes every freeze that reached its end date, reactivates the users
+	// left without an active freeze and returns the number of freezes closed
+	ExpireDue(now time.Time) (int64, error)
+	// EachBatch walks the freezes matching filter in ID order, size freezes at a time,
+	// with User loaded even when it is soft deleted
+	EachBatch(filter HistoryFilter, size int, fn func([]models.FreezeHistory) error) error
+}
+
+// BanFilter narrows a ban history listing
+type BanFilter struct {
+	Active    *bool // any state when nil
+	Permanent *bool // any duration when nil
+	Offset    int
+	Limit     int
+}
+
+// BanRepository stores ban histories
+type BanRepository interface {
+	// FindActive returns the user's active ban with BannedBy loaded
+	FindActive(userID uint) (*models.BanHistory, error)
+	Create(ban *models.BanHistory) error
+	Save(ban *models.BanHistory) error
+	// ExpireDue closes every temporary ban that reached its end date, reactivates the
+	// users left without an active ban and returns the number of bans closed
+	ExpireDue(now time.Time) (int64, error)
+	// ListByUser returns the user's bans, newest first, with User, BannedBy and Unbanner loaded
+	ListByUser(userID uint) ([]models.BanHistory, error)
+	// List returns bans, newest first, with the same users loaded as ListByUser, and the total count
+	List(filter BanFilter) ([]models.BanHistory, int64, error)
+	// EachBatch walks the bans matching filter in ID order, size bans at a time,
+	// with User and BannedBy loaded even when they are soft deleted
+	EachBatch(filter HistoryFilter, size int, fn func([]models.BanHistory) error) error
+}
+
+// RoleHistoryRepository stores role changes
+type RoleHistoryRepository interface {
+	Create(history *models.RoleHistory) error
+	// ListByUser returns the user's role changes, newest first, with User and ChangedBy loaded
+	ListByUser(userID uint) ([]models.RoleHistory, error)
+	// List returns role changes, newest first, with the same users loaded as ListByUser, and the total count
+	List(offset, limit int) ([]models.RoleHistory, int64, error)
+	// EachBatch walks the role changes matching filter in ID order, size changes at a
+	// time, with User and ChangedBy loaded even when they are soft deleted
+	EachBatch(filter HistoryFilter, size int, fn func([]models.RoleHistory) error) error
+}
+
+// RestoreHistoryRepository stores admin restores of deleted and passive users
+type RestoreHistoryRepository interface {
+	Create(history *models.RestoreHistory) error
+}
+
+// RestrictionRepository stores content restrictions
+type RestrictionRepository interface {
+	// HasActive reports whether the user has an active restriction of restrictionType
+	// that has not reached its end date
+	HasActive(userID uint, restrictionType models.RestrictionType, now time.Time) (bool, error)
+	// FindActive returns the user's active restriction with RestrictedBy loaded
+	FindActive(userID, id uint) (*models.RestrictionHistory, error)
+	// ListByUser returns the user's restrictions, newest first, with RestrictedBy and LiftedBy loaded
+	ListByUser(userID uint) ([]models.RestrictionHistory, error)
+	// ListActive returns the user's active restrictions, newest first, closing the ones that have expired
+	ListActive(userID uint) ([]models.RestrictionHistory, error)
+	// CountRecentPosts counts the questions and answers the user created since the given time,
+	// which rate-limited restrictions cap
+	CountRecentPosts(userID uint, since time.Time) (int64, error)
+	Create(restriction *models.RestrictionHistory) error
+	Save(restriction *models.RestrictionHistory) error
+}
+
+// SessionRepository stores login sessions
+type SessionRepository interface {
+	Create(session *models.Session) error
+	DeleteByUser(userID uint) error
+}
+
+// TokenRepository stores emailed verification tokens
+type TokenRepository interface {
+	Create(token *models.VerificationToken) error
+	// FindValid returns the token if it has not expired
+	FindValid(token string, now time.Time) (*models.VerificationToken, error)
+	Delete(token *models.VerificationToken) error
+}
+
+// BlocklistRepository checks and records blocked networks and email domains
+type BlocklistRepository interface {
+	IsIPBlocked(ip string) (bool, error)
+	IsEmailDomainBlocked(email string, includeDisposable bool) (bool, error)
+	// RecordUserIPs blocks the IPs the user logged in from during the last sinceDays days,
+	// extending shorter active blocks and replacing expired ones
+	RecordUserIPs(userID uint, createdByID uint, reason string, sinceDays int, expiresAt *time.Time) ([]string, error)
+	// List returns entries of entryType, or of any type when it is empty, newest
+	// first, with CreatedBy loaded, and the total count
+	List(entryType models.BlocklistType, offset, limit int) ([]models.BlocklistEntry, int64, error)
+	// ActiveEntry returns the unexpired entry for value. An expired entry is deleted
+	// and ErrNotFound returned, so a new entry can take its place.
+	ActiveEntry(entryType models.BlocklistType, value string) (*models.BlocklistEntry, error)
+	FindByID(id uint) (*models.BlocklistEntry, error)
+	Create(entry *models.BlocklistEntry) error
+	Delete(entry *models.BlocklistEntry) error
+}
+
+// FlagQueueFilter narrows the moderation queue
+type FlagQueueFilter struct {
+	Status     models.FlagStatus
+	TargetType models.FlagTargetType // any type when empty
+	Offset     int
+	Limit      int
+}
+
+// FlagQueueEntry groups the flags of a single target
+type FlagQueueEntry struct {
+	TargetType     models.FlagTargetType
+	TargetID       uint
+	FlagCount      int64
+	ReporterCount  int64
+	Reasons        string // comma separated
+	FirstFlaggedAt time.Time
+	LastFlaggedAt  time.Time
+}
+
+// FlagRepository stores content flags and acts on the flagged questions and answers
+type FlagRepository interface {
+	// TargetOwner returns the ID of the user responsible for a flag target
+	TargetOwner(targetType models.FlagTargetType, targetID uint) (uint, error)
+	// Exists reports whether reporterID already flagged the target
+	Exists(targetType models.FlagTargetType, targetID, reporterID uint) (bool, error)
+	Create(flag *models.Flag) error
+	// ApplyAutoHide hides a question or answer once enough distinct users have open
+	// flags on it and reports whether it did
+	ApplyAutoHide(targetType models.FlagTargetType, targetID uint) (bool, error)
+	// Queue returns flagged targets, most reporters first, and the total count
+	Queue(filter FlagQueueFilter) ([]FlagQueueEntry, int64, error)
+	// CountPending counts the open and escalated flags on a target
+	CountPending(targetType models.FlagTargetType, targetID uint) (int64, error)
+	// CountEscalated counts the flags on a target that were escalated to admins
+	CountEscalated(targetType models.FlagTargetType, targetID uint) (int64, error)
+	// ResolvePending applies fields to the open and escalated flags on a target
+	ResolvePending(targetType models.FlagTargetType, targetID uint, fields map[string]interface{}) error
+	// SetContentHidden hides or shows a flagged question or answer; user targets are ignored
+	SetContentHidden(targetType models.FlagTargetType, targetID uint, hidden bool) error
+	// DeleteContent soft deletes a flagged question or answer; user targets are ignored
+	DeleteContent(targetType models.FlagTargetType, targetID uint) error
+}
+
+// QuestionRepository reads questions
+type QuestionRepository interface {
+	FindByID(id uint) (*models.Question, error)
+}
+
+// DeletionRepository stores scheduled account deletions
+type DeletionRepository interface {
+	Create(deletion *models.AccountDeletion) error
+	// Latest returns the user's most recent deletion
+	Latest(userID uint) (*models.AccountDeletion, error)
+	// Cancel cancels the user's scheduled deletion if it is still inside the grace
+	// period and reports whether one was cancelled
+	Cancel(userID uint, now time.Time) (bool, error)
+}
+
+// MergeRepository stores duplicate account merges
+type MergeRepository interface {
+	// IsMergedSource reports whether the user was merged into another user
+	IsMergedSource(userID uint) (bool, error)
+	// Apply moves everything the source user of merge owns to its target user,
+	// recomputes the affected vote counts, soft deletes the source and records
+	// merge with the counts of what was moved. Run it inside a transaction.
+	Apply(merge *models.UserMerge) error
+}
+
+// BulkJobRepository stores background bulk jobs
+type BulkJobRepository interface {
+	Create(job *models.BulkJob) error
+	FindByID(id uint) (*models.BulkJob, error)
+	// Update applies fields, which hold the status and progress columns, to the job
+	Update(id uint, fields map[string]interface{}) error
+}
+
+// DataExportRepository stores personal data export requests
+type DataExportRepository interface {
+	// HasInProgress reports whether the user has a pending or processing export
+	HasInProgress(userID uint) (bool, error)
+	Create(export *models.DataExport) error
+	// ListByUser returns the user's latest exports, newest first
+	ListByUser(userID uint, limit int) ([]models.DataExport, error)
+	// FindDownloadable returns the ready export with tokenHash whose link has not expired
+	FindDownloadable(tokenHash string, now time.Time) (*models.DataExport, error)
+}
+
+// StatsRepository computes dashboard statistics
+type StatsRepository interface {
+	// Dashboard aggregates the metrics of [from, to)
+	Dashboard(from, to time.Time) (*stats.Snapshot, error)
+}
+
+// OutboxRepository stores emails waiting for delivery
+type OutboxRepository interface {
+	Create(msg *models.OutboxMessage) error
+	FindByID(id uint) (*models.OutboxMessage, error)
+	// ClaimDue returns up to limit pending messages that are due at now, counts
+	// the attempt and hides them from other claims for lease. Messages claimed by
+	// another replica are skipped. A message whose delivery is never recorded
+	// becomes due again when the lease ends.
+	ClaimDue(now time.Time, limit int, lease time.Duration) ([]models.OutboxMessage, error)
+	MarkSent(id uint, now time.Time) error
+	// Retry records a failed attempt and schedules the next one at
+	Retry(id uint, lastError string, at time.Time) error
+	// MarkDead records a failed attempt and gives up on the message
+	MarkDead(id uint, lastError string) error
+	// Requeue makes a dead message pending again with a fresh set of attempts
+	Requeue(id uint, now time.Time) error
+	// List returns messages with status, newest first, and the total count
+	List(status models.OutboxStatus, offset, limit int) ([]models.OutboxMessage, int64, error)
+	// DeleteSentBefore deletes messages delivered before before and returns how many
+	DeleteSentBefore(before time.Time) (int64, error)
+}
+
+// NotificationRepository stores notifications and notification preferences.
+// Lists and unread counts only include notifications shown in the app.
+type NotificationRepository interface {
+	Create(notification *models.Notification) error
+	// List returns the user's notifications, newest first, and the total count
+	List(userID uint, unreadOnly bool, offset, limit int) ([]models.Notification, int64, error)
+	CountUnread(userID uint) (int64, error)
+	// MarkRead marks the user's notification as read, returning ErrNotFound when
+	// the user has no such notification
+	MarkRead(userID, id uint, now time.Time) error
+	// MarkAllRead marks every unread notification of the user as read and returns how many
+	MarkAllRead(userID uint, now time.Time) (int64, error)
+	// Preference returns the user's stored preference for notificationType
+	Preference(userID uint, notificationType models.NotificationType) (*models.NotificationPreference, error)
+	Preferences(userID uint) ([]models.NotificationPreference, error)
+	// SavePreference creates or replaces the user's preference for its type
+	SavePreference(preference *models.NotificationPreference) error
+	// DigestUserIDs returns up to limit users above afterID with notifications
+	// waiting for a digest, in ascending order
+	DigestUserIDs(afterID uint, limit int) ([]uint, error)
+	// PendingDigest returns the user's unread notifications waiting for a digest, oldest first
+	PendingDigest(userID uint, limit int) ([]models.Notification, error)
+	// ClearDigest removes every notification of the user from the next digest
+	ClearDigest(userID uint) error
+}
+
+// WebhookDeliveryFilter narrows a subscription's delivery log
+type WebhookDeliveryFilter struct {
+	SubscriptionID uint
+	Status         models.WebhookDeliveryStatus // any status when empty
+	Offset         int
+	Limit          int
+}
+
+// WebhookRepository stores webhook subscriptions and their deliveries
+type WebhookRepository interface {
+	CreateSubscription(subscription *models.WebhookSubscription) error
+	FindSubscription(id uint) (*models.WebhookSubscription, error)
+	// ListSubscriptions returns subscriptions, oldest first, and the total count
+	ListSubscriptions(offset, limit int) ([]models.WebhookSubscription, int64, error)
+	// ActiveSubscriptions returns the active subscriptions receiving eventType
+	ActiveSubscriptions(eventType string) ([]models.WebhookSubscription, error)
+	UpdateSubscription(subscription *models.WebhookSubscription, fields map[string]interface{}) error
+	// DeleteSubscription deletes the subscription together with its deliveries
+	DeleteSubscription(id uint) error
+	CreateDelivery(delivery *models.WebhookDelivery) error
+	FindDelivery(subscriptionID, id uint) (*models.WebhookDelivery, error)
+	// ListDeliveries returns deliveries, newest first, and the total count
+	ListDeliveries(filter WebhookDeliveryFilter) ([]models.WebhookDelivery, int64, error)
+	// ClaimDue returns up to limit pending deliveries that are due at now, counts
+	// the attempt and hides them from other claims for lease, like OutboxRepository.ClaimDue
+	ClaimDue(now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	// RecordAttempt stores the outcome of an attempt; fields holds the status,
+	// response and error columns to update
+	RecordAttempt(id uint, fields map[string]interface{}) error
+	// Redeliver makes a failed delivery pending again with a fresh set of attempts
+	Redeliver(subscriptionID, id uint, now time.Time) error
+	// DeleteFinishedBefore deletes succeeded and failed deliveries last updated
+	// before before and returns how many
+	DeleteFinishedBefore(before time.Time) (int64, error)
+}
+
+// EventRepository publishes events to other database sessions
+type EventRepository interface {
+	// Publish sends payload to the listeners of channel. Inside a transaction it
+	// is sent when the transaction commits, and not at all if it rolls back.
+	Publish(channel, payload string) error
+}
+
+// Store gives access to every repository. A Store returned to a Transaction
+// callback runs all its repositories inside that transaction.
+type Store interface {
+	Users() UserRepository
+	Freezes() FreezeRepository
+	Bans() BanRepository
+	RoleHistories() RoleHistoryRepository
+	RestoreHistories() RestoreHistoryRepository
+	Restrictions() RestrictionRepository
+	Sessions() SessionRepository
+	Tokens() TokenRepository
+	Blocklist() BlocklistRepository
+	Flags() FlagRepository
+	Questions() QuestionRepository
+	Deletions() DeletionRepository
+	Merges() MergeRepository
+	BulkJobs() BulkJobRepository
+	DataExports() DataExportRepository
+	Stats() StatsRepository
+	Outbox() OutboxRepository
+	Notifications() NotificationRepository
+	Events() EventRepository
+	Webhooks() WebhookRepository
+	// WithContext returns a Store whose queries run with ctx, so they are cancelled
+	// with the request and traced as part of it
+	WithContext(ctx context.Context) Store
+	// Transaction commits when fn returns nil and rolls back otherwise.
+	// Nested calls run in a savepoint.
+	Transaction(fn func(tx Store) error) error
+}
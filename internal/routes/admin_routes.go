@@ -2,26 +2,80 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 
 	"ai-backend/internal/handlers/admin"
+	"ai-backend/internal/metrics"
 	"ai-backend/internal/middleware"
 	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+	"ai-backend/internal/service"
 )
 
-func SetupAdminRoutes(router *gin.Engine, db *gorm.DB) {
+func SetupAdminRoutes(router *gin.Engine, store repository.Store, m *metrics.Metrics) {
 	adminGroup := router.Group("/api/admin")
-	adminGroup.Use(middleware.AuthMiddleware())
+	adminGroup.Use(middleware.AuthMiddleware(store))
 	adminGroup.Use(middleware.AdminRoleMiddleware([]models.UserRole{models.RoleAdmin, models.RoleSuperAdmin}))
 
+	moderationService := service.NewModerationService(store, m)
+	userService := service.NewUserService(store)
+
+	// Dashboard statistics
+	adminGroup.GET("/stats", admin.GetDashboardStats(store))
+
 	// Role management
-	adminGroup.PUT("/users/role", admin.UpdateUserRole(db))
-	adminGroup.GET("/users/:user_id/role-history", admin.GetUserRoleHistory(db))
-	adminGroup.GET("/role-histories", admin.GetAllRoleHistories(db))
+	adminGroup.PUT("/users/role", admin.UpdateUserRole(moderationService))
+	adminGroup.GET("/users/:user_id/role-history", admin.GetUserRoleHistory(store))
+	adminGroup.GET("/role-histories", admin.GetAllRoleHistories(store))
 
 	// Ban management
-	adminGroup.POST("/users/ban", admin.BanUser(db))
-	adminGroup.GET("/users/:user_id/ban-history", admin.GetUserBanHistory(db))
-	adminGroup.GET("/ban-histories", admin.GetAllBanHistories(db))
-	adminGroup.POST("/users/:user_id/unban", admin.UnbanUser(db))
+	adminGroup.POST("/users/ban", admin.BanUser(moderationService))
+	adminGroup.GET("/users/:user_id/ban-history", admin.GetUserBanHistory(store))
+	adminGroup.GET("/ban-histories", admin.GetAllBanHistories(store))
+	adminGroup.POST("/users/:user_id/unban", admin.UnbanUser(moderationService))
+
+	// Account restore
+	adminGroup.POST("/users/:user_id/restore", admin.RestoreUser(userService))
+
+	// Duplicate account merge (SUPER_ADMIN only)
+	adminGroup.POST("/users/merge", middleware.AdminRoleMiddleware([]models.UserRole{models.RoleSuperAdmin}), admin.MergeUsers(store))
+
+	// Content restrictions
+	adminGroup.POST("/users/restrict", admin.RestrictUser(store, moderationService))
+	adminGroup.POST("/users/:user_id/restrictions/:restriction_id/lift", admin.LiftRestriction(store))
+	adminGroup.GET("/users/:user_id/restriction-history", admin.GetUserRestrictionHistory(store))
+
+	// Blocklist management
+	adminGroup.GET("/blocklist", admin.GetBlocklist(store))
+	adminGroup.POST("/blocklist", admin.CreateBlocklistEntry(store))
+	adminGroup.DELETE("/blocklist/:entry_id", admin.DeleteBlocklistEntry(store))
+
+	// Compliance exports
+	adminGroup.GET("/exports/users", admin.ExportUsers(store))
+	adminGroup.GET("/exports/ban-histories", admin.ExportBanHistories(store))
+	adminGroup.GET("/exports/role-histories", admin.ExportRoleHistories(store))
+	adminGroup.GET("/exports/freeze-histories", admin.ExportFreezeHistories(store))
+
+	// Email template previews
+	adminGroup.GET("/email-templates", admin.ListEmailTemplates())
+	adminGroup.GET("/email-templates/:name/preview", admin.PreviewEmailTemplate())
+
+	// Email outbox
+	adminGroup.GET("/email-outbox", admin.GetOutboxMessages(store))
+	adminGroup.POST("/email-outbox/:message_id/resend", admin.ResendOutboxMessage(store))
+
+	// Webhooks
+	adminGroup.GET("/webhooks/event-types", admin.GetWebhookEventTypes())
+	adminGroup.GET("/webhooks", admin.GetWebhooks(store))
+	adminGroup.POST("/webhooks", admin.CreateWebhook(store))
+	adminGroup.GET("/webhooks/:webhook_id", admin.GetWebhook(store))
+	adminGroup.PUT("/webhooks/:webhook_id", admin.UpdateWebhook(store))
+	adminGroup.DELETE("/webhooks/:webhook_id", admin.DeleteWebhook(store))
+	adminGroup.POST("/webhooks/:webhook_id/rotate-secret", admin.RotateWebhookSecret(store))
+	adminGroup.POST("/webhooks/:webhook_id/ping", admin.PingWebhook(store))
+	adminGroup.GET("/webhooks/:webhook_id/deliveries", admin.GetWebhookDeliveries(store))
+	adminGroup.POST("/webhooks/:webhook_id/deliveries/:delivery_id/redeliver", admin.RedeliverWebhookDelivery(store))
+
+	// Bulk operations
+	adminGroup.POST("/users/bulk", admin.BulkUserAction(store, moderationService, m))
+	adminGroup.GET("/bulk-jobs/:job_id", admin.GetBulkJob(store))
 } 
\ No newline at end of file
@@ -3,22 +3,25 @@ package routes
 import (
 	"ai-backend/internal/handlers/auth"
 	"ai-backend/internal/middleware"
+	"ai-backend/internal/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupAuthRoutes configures the auth routes
-func SetupAuthRoutes(router *gin.Engine) {
+func SetupAuthRoutes(router *gin.Engine, store repository.Store, authHandler *auth.AuthHandler) {
 	authGroup := router.Group("/api/auth")
 	{
 		// Public routes
-		authGroup.POST("/login", auth.Login)
-		authGroup.POST("/register", auth.Register)
-		authGroup.POST("/reset-password", auth.RequestPasswordReset)
-		authGroup.POST("/update-password", auth.UpdatePassword)
+		authGroup.POST("/login", authHandler.Login)
+		authGroup.POST("/register", authHandler.Register)
+		authGroup.POST("/reset-password", authHandler.RequestPasswordReset)
+		authGroup.POST("/update-password", authHandler.UpdatePassword)
+		authGroup.POST("/reactivate/request", authHandler.RequestReactivation)
+		authGroup.POST("/reactivate", authHandler.ReactivateAccount)
 
 		// Protected routes
-		authGroup.Use(middleware.AuthMiddleware())
-		authGroup.POST("/change-password", auth.ChangePassword)
+		authGroup.Use(middleware.AuthMiddleware(store))
+		authGroup.POST("/change-password", authHandler.ChangePassword)
 	}
 } 
\ No newline at end of file
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/handlers/admin"
+	"ai-backend/internal/handlers/report"
+	"ai-backend/internal/metrics"
+	"ai-backend/internal/middleware"
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+	"ai-backend/internal/service"
+)
+
+// SetupFlagRoutes configures content flagging and the moderation queue
+func SetupFlagRoutes(router *gin.Engine, store repository.Store, m *metrics.Metrics) {
+	flagGroup := router.Group("/api/flags")
+	flagGroup.Use(middleware.AuthMiddleware(store))
+	flagGroup.POST("", report.CreateFlag(store))
+
+	moderationGroup := router.Group("/api/moderation")
+	moderationGroup.Use(middleware.AuthMiddleware(store))
+	moderationGroup.Use(middleware.AdminRoleMiddleware([]models.UserRole{models.RoleEditor, models.RoleAdmin, models.RoleSuperAdmin}))
+
+	moderationGroup.GET("/flags", admin.GetFlagQueue(store))
+	moderationGroup.POST("/flags/resolve", admin.ResolveFlags(store, service.NewModerationService(store, m)))
+}
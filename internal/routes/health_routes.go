@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/health"
+)
+
+// SetupHealthRoutes configures the probe endpoints. /livez fails only when the
+// process should be restarted; /readyz fails while a dependency is unavailable
+// and once shutdown has begun.
+func SetupHealthRoutes(router *gin.Engine, checks *health.Health) {
+	router.GET("/livez", func(c *gin.Context) {
+		respondWithReport(c, checks.Liveness(c.Request.Context()))
+	})
+
+	router.GET("/readyz", func(c *gin.Context) {
+		respondWithReport(c, checks.Readiness(c.Request.Context()))
+	})
+
+	// Basic health check endpoint, kept for existing monitors
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "ok",
+		})
+	})
+}
+
+func respondWithReport(c *gin.Context, report health.Report) {
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"ai-backend/internal/metrics"
+)
+
+// SetupMetricsRoutes exposes m in the Prometheus text format at /metrics.
+// When token is set, scrapers must send it as a bearer token.
+func SetupMetricsRoutes(router *gin.Engine, m *metrics.Metrics, token string) {
+	handler := gin.WrapH(promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{}))
+
+	router.GET("/metrics", func(c *gin.Context) {
+		if token != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		handler(c)
+	})
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/handlers/user"
+	"ai-backend/internal/middleware"
+	"ai-backend/internal/repository"
+)
+
+// SetupNotificationRoutes configures the current user's notifications
+func SetupNotificationRoutes(router *gin.Engine, store repository.Store, notificationHandler *user.NotificationHandler) {
+	notificationGroup := router.Group("/api/notifications")
+	notificationGroup.Use(middleware.AuthMiddleware(store))
+
+	notificationGroup.GET("", notificationHandler.GetNotifications)
+	notificationGroup.GET("/unread-count", notificationHandler.GetUnreadCount)
+	notificationGroup.POST("/read-all", notificationHandler.MarkAllRead)
+	notificationGroup.POST("/:notification_id/read", notificationHandler.MarkRead)
+	notificationGroup.GET("/preferences", notificationHandler.GetPreferences)
+	notificationGroup.PUT("/preferences", notificationHandler.UpdatePreferences)
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"ai-backend/internal/handlers/user"
+	"ai-backend/internal/middleware"
+	"ai-backend/internal/repository"
+)
+
+// SetupRealtimeRoutes configures the real-time event streams
+func SetupRealtimeRoutes(router *gin.Engine, store repository.Store, realtimeHandler *user.RealtimeHandler) {
+	realtimeGroup := router.Group("/api/realtime")
+	realtimeGroup.Use(middleware.StreamAuthMiddleware(store))
+
+	realtimeGroup.GET("/events", realtimeHandler.StreamEvents)
+	realtimeGroup.GET("/ws", realtimeHandler.ServeWebSocket)
+}
@@ -3,23 +3,29 @@ package routes
 import (
 	"ai-backend/internal/handlers/user"
 	"ai-backend/internal/middleware"
+	"ai-backend/internal/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupUserRoutes configures the user routes
-func SetupUserRoutes(router *gin.Engine, userHandler *user.UserHandler) {
+func SetupUserRoutes(router *gin.Engine, store repository.Store, userHandler *user.UserHandler, dataExportHandler *user.DataExportHandler) {
 	userGroup := router.Group("/api/users")
 	{
+		// Public routes (the download link is authorized by its token)
+		userGroup.GET("/data-export/download", dataExportHandler.DownloadDataExport)
+
 		// Protected routes that require authentication
-		userGroup.Use(middleware.AuthMiddleware())
+		userGroup.Use(middleware.AuthMiddleware(store))
 		
 		// All authenticated users can access these endpoints
 		userGroup.GET("", userHandler.ListUsers)
-		userGroup.PUT("/status", user.UpdateUserStatus)
+		userGroup.PUT("/status", userHandler.UpdateUserStatus)
 		userGroup.PUT("/profile", userHandler.UpdateProfile)
 		userGroup.DELETE("/account", userHandler.DeleteAccount)
 		userGroup.POST("/freeze", userHandler.FreezeAccount)
 		userGroup.GET("/freeze/history", userHandler.GetFreezeHistory)
+		userGroup.POST("/data-export", dataExportHandler.RequestDataExport)
+		userGroup.GET("/data-export", dataExportHandler.GetDataExports)
 	}
 } 
\ No newline at end of file
@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"ai-backend/internal/accountdeletion"
+	"ai-backend/internal/config"
+	"ai-backend/internal/events"
+	"ai-backend/internal/metrics"
+	"ai-backend/internal/models"
+	"ai-backend/internal/outbox"
+	"ai-backend/internal/repository"
+	"ai-backend/pkg/email"
+	"ai-backend/pkg/utils"
+)
+
+// AuthService logs users in and manages their passwords and reactivation
+type AuthService struct {
+	store   repository.Store
+	metrics *metrics.Metrics
+	ctx     context.Context
+}
+
+func NewAuthService(store repository.Store, m *metrics.Metrics) *AuthService {
+	return &AuthService{store: store, metrics: m, ctx: context.Background()}
+}
+
+// WithContext returns a copy of the service that runs its queries and outgoing
+// calls with ctx
+func (s *AuthService) WithContext(ctx context.Context) *AuthService {
+	return &AuthService{store: s.store.WithContext(ctx), metrics: s.metrics, ctx: ctx}
+}
+
+// ClientInfo identifies the client a request came from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type RegisterInput struct {
+	Username string
+	Email    string
+	Password string
+}
+
+type AuthResult struct {
+	Token string
+	User  *models.User
+}
+
+// FrozenError is returned by Login when the account has an active freeze
+type FrozenError struct {
+	Freeze *models.FreezeHistory
+}
+
+func (e *FrozenError) Error() string {
+	return "account is frozen"
+}
+
+// randomToken returns 32 random bytes, hex encoded
+func randomToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// recordSession stores a session row with the client's IP and user agent for moderation tooling
+func (s *AuthService) recordSession(userID uint, client ClientInfo) {
+	token, err := randomToken()
+	if err != nil {
+		slog.ErrorContext(s.ctx, "Failed to generate session token", "error", err)
+		return
+	}
+
+	session := models.Session{
+		UserID:       userID,
+		Expires:      time.Now().Add(config.Get().Auth.SessionTTL),
+		SessionToken: token,
+		IPAddress:    &client.IP,
+		UserAgent:    &client.UserAgent,
+	}
+
+	if err := s.store.Sessions().Create(&session); err != nil {
+		slog.ErrorContext(s.ctx, "Failed to record session", "target_user_id", userID, "error", err)
+	}
+}
+
+// issueToken generates the JWT for user and records the session
+func (s *AuthService) issueToken(user *models.User, client ClientInfo) (*AuthResult, error) {
+	token, err := utils.GenerateToken(*user)
+	if err != nil {
+		return nil, NewError(KindInternal, "Failed to generate token")
+	}
+
+	s.recordSession(user.ID, client)
+
+	return &AuthResult{Token: token, User: user}, nil
+}
+
+// Login checks the credentials of a user identified by email or username
+func (s *AuthService) Login(identifier, password string, client ClientInfo) (*AuthResult, error) {
+	result, err := s.login(identifier, password, client)
+	s.metrics.ObserveLogin(err == nil)
+	return result, err
+}
+
+func (s *AuthService) login(identifier, password string, client ClientInfo) (*AuthResult, error) {
+	// Reject logins from blocked networks
+	if blocked, err := s.store.Blocklist().IsIPBlocked(client.IP); err != nil {
+		slog.ErrorContext(s.ctx, "Failed to check IP blocklist", "error", err)
+	} else if blocked {
+		return nil, NewError(KindForbidden, "Access from this network is not allowed")
+	}
+
+	invalidCredentials := NewError(KindUnauthorized, "Invalid credentials")
+
+	user, err := s.store.Users().FindByLogin(identifier)
+	if err != nil {
+		return nil, invalidCredentials
+	}
+
+	// Check password
+	if !checkPassword(s.ctx, user.Password, password) {
+		return nil, invalidCredentials
+	}
+
+	// Check email domain blocklist
+	if user.Email != nil {
+		if blocked, err := s.store.Blocklist().IsEmailDomainBlocked(*user.Email, false); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to check email domain blocklist", "error", err)
+		} else if blocked {
+			return nil, NewError(KindForbidden, "Email domain is not allowed")
+		}
+	}
+
+	// Check user status
+	if user.Status == models.StatusBanned {
+		return nil, NewError(KindForbidden, "Account is banned")
+	}
+
+	if user.Status == models.StatusFrozen {
+		// Aktif dondurma işlemi var mı kontrol et
+		now := time.Now()
+		activeFreeze, err := s.store.Freezes().FindActive(user.ID, now)
+		if err == nil {
+			return nil, &FrozenError{Freeze: activeFreeze}
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+
+		// Dondurma süresi dolmuş, hesabı aktif et
+		if err := s.store.Users().Update(user, map[string]interface{}{"status": models.StatusActive}); err != nil {
+			return nil, NewError(KindInternal, "Failed to update user status")
+		}
+
+		// Dondurma kaydını güncelle
+		if err := s.store.Freezes().CloseExpired(user.ID, now); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to update freeze history", "error", err)
+		}
+	}
+
+	if user.Status == models.StatusPassive {
+		return nil, NewError(KindForbidden, "Account is passive. Please request a reactivation email to reactivate your account.")
+	}
+
+	return s.issueToken(user, client)
+}
+
+// Register creates an active user and logs them in
+func (s *AuthService) Register(input RegisterInput, client ClientInfo) (*AuthResult, error) {
+	// Reject registrations from blocked networks and email domains
+	if blocked, err := s.store.Blocklist().IsIPBlocked(client.IP); err != nil {
+		slog.ErrorContext(s.ctx, "Failed to check IP blocklist", "error", err)
+	} else if blocked {
+		return nil, NewError(KindForbidden, "Registration from this network is not allowed")
+	}
+
+	if blocked, err := s.store.Blocklist().IsEmailDomainBlocked(input.Email, true); err != nil {
+		slog.ErrorContext(s.ctx, "Failed to check email domain blocklist", "error", err)
+	} else if blocked {
+		return nil, NewError(KindForbidden, "Email domain is not allowed")
+	}
+
+	// Check if email or username already exists (excluding soft deleted users)
+	if taken, err := s.store.Users().EmailTaken(input.Email, 0); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, NewError(KindConflict, "Email already exists")
+	}
+
+	if accountdeletion.IsReservedUsername(input.Username) {
+		return nil, NewError(KindInvalid, "Username is reserved")
+	}
+	if taken, err := s.store.Users().UsernameTaken(input.Username, 0); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, NewError(KindConflict, "Username already exists")
+	}
+
+	password, err := hashPassword(s.ctx, input.Password)
+	if err != nil {
+		return nil, NewError(KindInternal, "Failed to process password")
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Username:      &input.Username,
+		Email:         &input.Email,
+		Password:      &password,
+		Role:          models.RoleUser,
+		Status:        models.StatusActive,
+		EmailVerified: &now,
+	}
+
+	err = s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().Create(user); err != nil {
+			return NewError(KindInternal, "Failed to create user")
+		}
+		if err := events.Emit(s.ctx, tx, events.Registered(user)); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to emit registration event", "error", err)
+			return NewError(KindInternal, "Failed to create user")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	events.Committed()
+
+	return s.issueToken(user, client)
+}
+
+// RequestPasswordReset emails a password reset token. It reports false without
+// an error when no user is registered with the email.
+func (s *AuthService) RequestPasswordReset(address string) (bool, error) {
+	user, err := s.store.Users().FindByEmail(address)
+	if err != nil {
+		return false, nil
+	}
+
+	resetToken, err := randomToken()
+	if err != nil {
+		return false, NewError(KindInternal, "Failed to generate reset token")
+	}
+
+	verificationToken := models.VerificationToken{
+		Identifier: *user.Email,
+		Token:      resetToken,
+		Expires:    time.Now().Add(config.Get().Auth.PasswordResetTTL),
+	}
+
+	// The token and its email are committed together; the outbox retries the delivery
+	err = s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Tokens().Create(&verificationToken); err != nil {
+			return NewError(KindInternal, "Failed to create reset token")
+		}
+		err := outbox.Enqueue(s.ctx, tx, *user.Email, email.TemplatePasswordReset, email.PasswordResetData{
+			Token:     resetToken,
+			ExpiresIn: config.Get().Auth.PasswordResetTTL,
+		})
+		if err != nil {
+			slog.ErrorContext(s.ctx, "Failed to queue reset email", "target_user_id", user.ID, "error", err)
+			return NewError(KindInternal, "Failed to send reset email. Please try again later.")
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	outbox.Wake()
+
+	slog.InfoContext(s.ctx, "Password reset email queued", "target_user_id", user.ID)
+	return true, nil
+}
+
+// ResetPassword sets a new password using an emailed reset token
+func (s *AuthService) ResetPassword(token, newPassword string) error {
+	verificationToken, err := s.store.Tokens().FindValid(token, time.Now())
+	if err != nil {
+		return NewError(KindInvalid, "Invalid or expired reset token")
+	}
+
+	user, err := s.store.Users().FindByEmail(verificationToken.Identifier)
+	if err != nil {
+		return NewError(KindNotFound, "User not found")
+	}
+
+	if err := s.setPassword(user, newPassword); err != nil {
+		return err
+	}
+
+	// Delete used token
+	s.store.Tokens().Delete(verificationToken)
+	return nil
+}
+
+// ChangePassword replaces the password of an authenticated user after checking the old one
+func (s *AuthService) ChangePassword(user *models.User, oldPassword, newPassword string) error {
+	if !checkPassword(s.ctx, user.Password, oldPassword) {
+		return NewError(KindUnauthorized, "Invalid old password")
+	}
+
+	return s.setPassword(user, newPassword)
+}
+
+// setPassword hashes and stores a new password for user
+func (s *AuthService) setPassword(user *models.User, newPassword string) error {
+	password, err := hashPassword(s.ctx, newPassword)
+	if err != nil {
+		return NewError(KindInternal, "Failed to process password")
+	}
+
+	user.Password = &password
+	if err := s.store.Users().Save(user); err != nil {
+		return NewError(KindInternal, "Failed to update password")
+	}
+	return nil
+}
+
+// RequestReactivation emails a reactivation token to a passive user or to a deleted
+// user whose account is still inside the deletion grace period. Nothing is sent when
+// no such user is registered with the email.
+func (s *AuthService) RequestReactivation(address string) error {
+	user, err := s.store.Users().FindReactivatable(address, time.Now())
+	if err != nil {
+		return nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return NewError(KindInternal, "Failed to generate reactivation token")
+	}
+
+	verificationToken := models.VerificationToken{
+		Identifier: accountdeletion.ReactivationIdentifier(user.ID),
+		Token:      token,
+		Expires:    time.Now().Add(config.Get().Auth.ReactivationTTL),
+	}
+
+	err = s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Tokens().Create(&verificationToken); err != nil {
+			return NewError(KindInternal, "Failed to create reactivation token")
+		}
+		err := outbox.Enqueue(s.ctx, tx, *user.Email, email.TemplateReactivation, email.ReactivationData{
+			Token:     token,
+			ExpiresIn: config.Get().Auth.ReactivationTTL,
+		})
+		if err != nil {
+			slog.ErrorContext(s.ctx, "Failed to queue reactivation email", "target_user_id", user.ID, "error", err)
+			return NewError(KindInternal, "Failed to send reactivation email. Please try again later.")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	outbox.Wake()
+
+	slog.InfoContext(s.ctx, "Reactivation email queued", "target_user_id", user.ID)
+	return nil
+}
+
+// Reactivate restores a passive or deleted account using an emailed reactivation token.
+// Username replaces the old username when it was taken in the meantime.
+func (s *AuthService) Reactivate(token string, username *string) (*models.User, error) {
+	invalidToken := NewError(KindInvalid, "Invalid or expired reactivation token")
+
+	verificationToken, err := s.store.Tokens().FindValid(token, time.Now())
+	if err != nil {
+		return nil, invalidToken
+	}
+
+	userID, ok := accountdeletion.ParseReactivationIdentifier(verificationToken.Identifier)
+	if !ok {
+		return nil, invalidToken
+	}
+
+	user, err := s.store.Users().FindByIDUnscoped(userID)
+	if err != nil {
+		return nil, NewError(KindNotFound, "User not found")
+	}
+
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		if err := restoreUser(tx, user, username, nil); err != nil {
+			return err
+		}
+		return tx.Tokens().Delete(verificationToken)
+	}); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
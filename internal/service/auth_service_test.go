@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"ai-backend/internal/models"
+	"ai-backend/pkg/utils"
+)
+
+const testPassword = "correct horse battery"
+
+var (
+	testHashOnce sync.Once
+	testHash     string
+)
+
+// addLoginUser stores a user whose password is testPassword
+func addLoginUser(t *testing.T, store *fakeStore, username string, status models.UserStatus) *models.User {
+	t.Helper()
+	testHashOnce.Do(func() {
+		hashed, err := utils.HashPassword(testPassword)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testHash = hashed
+	})
+	user := store.addUser(username, models.RoleUser, status)
+	user.Password = &testHash
+	store.users[user.ID] = *user
+	return user
+}
+
+var testClient = ClientInfo{IP: "203.0.113.7", UserAgent: "test"}
+
+func TestLogin(t *testing.T) {
+	store := newFakeStore()
+	user := addLoginUser(t, store, "alice", models.StatusActive)
+
+	for _, identifier := range []string{*user.Username, *user.Email} {
+		result, err := NewAuthService(store, newTestMetrics()).Login(identifier, testPassword, testClient)
+		if err != nil {
+			t.Fatalf("login with %s: %v", identifier, err)
+		}
+		if result.Token == "" || result.User.ID != user.ID {
+			t.Errorf("login with %s returned %+v, want a token for the user", identifier, result)
+		}
+	}
+	if len(store.sessions) != 2 {
+		t.Fatalf("recorded %d sessions, want 2", len(store.sessions))
+	}
+	if ip := store.sessions[0].IPAddress; ip == nil || *ip != testClient.IP {
+		t.Errorf("session IP is %v, want %s", ip, testClient.IP)
+	}
+}
+
+func TestLoginRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   models.UserStatus
+		login    string
+		password string
+		setup    func(store *fakeStore)
+		want     ErrorKind
+	}{
+		{"wrong password", models.StatusActive, "alice", "wrong password", nil, KindUnauthorized},
+		{"unknown user", models.StatusActive, "bob", testPassword, nil, KindUnauthorized},
+		{"banned", models.StatusBanned, "alice", testPassword, nil, KindForbidden},
+		{"passive", models.StatusPassive, "alice", testPassword, nil, KindForbidden},
+		{"blocked network", models.StatusActive, "alice", testPassword, func(store *fakeStore) {
+			store.blockedIPs[testClient.IP] = true
+		}, KindForbidden},
+		{"blocked email domain", models.StatusActive, "alice", testPassword, func(store *fakeStore) {
+			store.blockedDomains["example.com"] = true
+		}, KindForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			addLoginUser(t, store, "alice", tt.status)
+			if tt.setup != nil {
+				tt.setup(store)
+			}
+
+			_, err := NewAuthService(store, newTestMetrics()).Login(tt.login, tt.password, testClient)
+			assertKind(t, err, tt.want)
+			if len(store.sessions) != 0 {
+				t.Errorf("recorded %d sessions, want none", len(store.sessions))
+			}
+		})
+	}
+}
+
+func TestLoginFrozen(t *testing.T) {
+	store := newFakeStore()
+	user := addLoginUser(t, store, "alice", models.StatusFrozen)
+	store.freezes = append(store.freezes, models.FreezeHistory{
+		UserID:    user.ID,
+		StartDate: time.Now().Add(-time.Hour),
+		EndDate:   time.Now().Add(24 * time.Hour),
+		IsActive:  true,
+	})
+
+	_, err := NewAuthService(store, newTestMetrics()).Login(*user.Username, testPassword, testClient)
+	var frozenErr *FrozenError
+	if !errors.As(err, &frozenErr) {
+		t.Fatalf("got error %v, want a FrozenError", err)
+	}
+	if status := store.user(user.ID).Status; status != models.StatusFrozen {
+		t.Errorf("user status is %s, want %s", status, models.StatusFrozen)
+	}
+}
+
+func TestLoginEndsExpiredFreeze(t *testing.T) {
+	store := newFakeStore()
+	user := addLoginUser(t, store, "alice", models.StatusFrozen)
+	store.freezes = append(store.freezes, models.FreezeHistory{
+		UserID:    user.ID,
+		StartDate: time.Now().Add(-48 * time.Hour),
+		EndDate:   time.Now().Add(-time.Hour),
+		IsActive:  true,
+	})
+
+	if _, err := NewAuthService(store, newTestMetrics()).Login(*user.Username, testPassword, testClient); err != nil {
+		t.Fatal(err)
+	}
+	if status := store.user(user.ID).Status; status != models.StatusActive {
+		t.Errorf("user status is %s, want %s", status, models.StatusActive)
+	}
+	if freeze := store.freezes[0]; freeze.IsActive || freeze.UnfrozenAt == nil {
+		t.Errorf("freeze after login is %+v, want it closed", freeze)
+	}
+}
+
+func TestLoginRecordsMetrics(t *testing.T) {
+	store := newFakeStore()
+	addLoginUser(t, store, "alice", models.StatusActive)
+	m := newTestMetrics()
+	authService := NewAuthService(store, m)
+
+	if _, err := authService.Login("alice", testPassword, testClient); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := authService.WithContext(context.Background()).Login("alice", "wrong password", testClient); err == nil {
+			t.Fatal("login with a wrong password succeeded")
+		}
+	}
+
+	want := `
+# HELP ai_backend_logins_total Login attempts by result (success or failure).
+# TYPE ai_backend_logins_total counter
+ai_backend_logins_total{result="failure"} 2
+ai_backend_logins_total{result="success"} 1
+`
+	if err := testutil.GatherAndCompare(m.Registry, strings.NewReader(want), "ai_backend_logins_total"); err != nil {
+		t.Error(err)
+	}
+}
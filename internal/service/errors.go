@@ -0,0 +1,35 @@
+package service
+
+// ErrorKind tells callers what went wrong, so a handler can pick the response status
+type ErrorKind int
+
+const (
+	// KindInternal is a failure the caller cannot fix
+	KindInternal ErrorKind = iota
+	// KindInvalid rejects the input
+	KindInvalid
+	// KindUnauthorized rejects the credentials
+	KindUnauthorized
+	// KindForbidden refuses an action the user is not allowed to take
+	KindForbidden
+	// KindNotFound reports a missing resource
+	KindNotFound
+	// KindConflict refuses an action that clashes with the current state
+	KindConflict
+)
+
+// Error is a failure whose message can be shown to the user
+type Error struct {
+	Kind    ErrorKind
+	Message string
+}
+
+// Error implements the error interface
+func (e Error) Error() string {
+	return e.Message
+}
+
+// NewError returns an Error of kind with a message for the user
+func NewError(kind ErrorKind, message string) Error {
+	return Error{Kind: kind, Message: message}
+}
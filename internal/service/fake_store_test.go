@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"ai-backend/internal/metrics"
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+)
+
+// newTestMetrics returns metrics on a fresh registry
+func newTestMetrics() *metrics.Metrics {
+	return metrics.New(prometheus.NewRegistry())
+}
+
+// fakeStore keeps the records the services read and write in memory. The
+// repositories a test does not reach are left nil and panic when called.
+// Transactions are not rolled back.
+type fakeStore struct {
+	nextID uint
+
+	users         map[uint]models.User
+	bans          []models.BanHistory
+	roleHistories []models.RoleHistory
+	restores      []models.RestoreHistory
+	freezes       []models.FreezeHistory
+	deletions     []models.AccountDeletion
+	sessions      []models.Session
+	notifications []models.Notification
+	outbox        []models.OutboxMessage
+	published     []string
+
+	blockedIPs     map[string]bool
+	blockedDomains map[string]bool
+	mergedSources  map[uint]bool
+
+	// restoreErr is returned by Users().Restore when set
+	restoreErr error
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		users:          map[uint]models.User{},
+		blockedIPs:     map[string]bool{},
+		blockedDomains: map[string]bool{},
+		mergedSources:  map[uint]bool{},
+	}
+}
+
+func (s *fakeStore) id() uint {
+	s.nextID++
+	return s.nextID
+}
+
+// addUser stores a user with role and status and returns a copy of it
+func (s *fakeStore) addUser(username string, role models.UserRole, status models.UserStatus) *models.User {
+	email := username + "@example.com"
+	user := models.User{Username: &username, Email: &email, Role: role, Status: status}
+	user.ID = s.id()
+	user.CreatedAt = time.Now().Add(time.Duration(user.ID) * time.Second)
+	s.users[user.ID] = user
+	return &user
+}
+
+// user returns the stored copy of a user
+func (s *fakeStore) user(id uint) models.User {
+	return s.users[id]
+}
+
+func (s *fakeStore) Users() repository.UserRepository                 { return fakeUsers{s: s} }
+func (s *fakeStore) Freezes() repository.FreezeRepository             { return fakeFreezes{s: s} }
+func (s *fakeStore) Bans() repository.BanRepository                   { return fakeBans{s: s} }
+func (s *fakeStore) RoleHistories() repository.RoleHistoryRepository  { return fakeRoleHistories{s: s} }
+func (s *fakeStore) Restrictions() repository.RestrictionRepository   { return nil }
+func (s *fakeStore) Sessions() repository.SessionRepository           { return fakeSessions{s: s} }
+func (s *fakeStore) Tokens() repository.TokenRepository               { return nil }
+func (s *fakeStore) Blocklist() repository.BlocklistRepository        { return fakeBlocklist{s: s} }
+func (s *fakeStore) Flags() repository.FlagRepository                 { return nil }
+func (s *fakeStore) Questions() repository.QuestionRepository         { return nil }
+func (s *fakeStore) Deletions() repository.DeletionRepository         { return fakeDeletions{s: s} }
+func (s *fakeStore) Merges() repository.MergeRepository               { return fakeMerges{s: s} }
+func (s *fakeStore) BulkJobs() repository.BulkJobRepository           { return nil }
+func (s *fakeStore) DataExports() repository.DataExportRepository     { return nil }
+func (s *fakeStore) Stats() repository.StatsRepository                { return nil }
+func (s *fakeStore) Outbox() repository.OutboxRepository              { return fakeOutbox{s: s} }
+func (s *fakeStore) Notifications() repository.NotificationRepository { return fakeNotifications{s: s} }
+func (s *fakeStore) Events() repository.EventRepository               { return fakeEvents{s: s} }
+func (s *fakeStore) Webhooks() repository.WebhookRepository           { return nil }
+
+func (s *fakeStore) RestoreHistories() repository.RestoreHistoryRepository {
+	return fakeRestoreHistories{s: s}
+}
+
+func (s *fakeStore) WithContext(context.Context) repository.Store { return s }
+
+func (s *fakeStore) Transaction(fn func(tx repository.Store) error) error {
+	return fn(s)
+}
+
+type fakeUsers struct {
+	repository.UserRepository
+	s *fakeStore
+}
+
+func (r fakeUsers) find(match func(models.User) bool) (*models.User, error) {
+	var found *models.User
+	for _, user := range r.s.users {
+		if match(user) && (found == nil || user.CreatedAt.Before(found.CreatedAt)) {
+			user := user
+			found = &user
+		}
+	}
+	if found == nil {
+		return nil, repository.ErrNotFound
+	}
+	return found, nil
+}
+
+func (r fakeUsers) FindByID(id uint) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.ID == id && !u.DeletedAt.Valid })
+}
+
+func (r fakeUsers) FindByIDUnscoped(id uint) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.ID == id })
+}
+
+func (r fakeUsers) FindByLogin(identifier string) (*models.User, error) {
+	return r.find(func(u models.User) bool {
+		return !u.DeletedAt.Valid && ((u.Email != nil && *u.Email == identifier) || (u.Username != nil && *u.Username == identifier))
+	})
+}
+
+func (r fakeUsers) FindFirstSuperAdmin() (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.Role == models.RoleSuperAdmin && !u.DeletedAt.Valid })
+}
+
+func (r fakeUsers) EmailTaken(email string, exceptID uint) (bool, error) {
+	_, err := r.find(func(u models.User) bool {
+		return u.ID != exceptID && !u.DeletedAt.Valid && u.Email != nil && *u.Email == email
+	})
+	return err == nil, nil
+}
+
+func (r fakeUsers) UsernameTaken(username string, exceptID uint) (bool, error) {
+	_, err := r.find(func(u models.User) bool {
+		return u.ID != exceptID && !u.DeletedAt.Valid && u.Username != nil && *u.Username == username
+	})
+	return err == nil, nil
+}
+
+func (r fakeUsers) Save(user *models.User) error {
+	r.s.users[user.ID] = *user
+	return nil
+}
+
+// Update applies the status, username and email columns the services update
+func (r fakeUsers) Update(user *models.User, fields map[string]interface{}) error {
+	for column, value := range fields {
+		switch column {
+		case "status":
+			user.Status = value.(models.UserStatus)
+		case "username":
+			user.Username = value.(*string)
+		case "email":
+			user.Email = value.(*string)
+		}
+	}
+	r.s.users[user.ID] = *user
+	return nil
+}
+
+func (r fakeUsers) Restore(user *models.User, fields map[string]interface{}) error {
+	if r.s.restoreErr != nil {
+		return r.s.restoreErr
+	}
+	user.DeletedAt.Valid = false
+	return r.Update(user, fields)
+}
+
+type fakeFreezes struct {
+	repository.FreezeRepository
+	s *fakeStore
+}
+
+func (r fakeFreezes) FindActive(userID uint, now time.Time) (*models.FreezeHistory, error) {
+	for _, freeze := range r.s.freezes {
+		if freeze.UserID == userID && freeze.IsActive && freeze.EndDate.After(now) {
+			return &freeze, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r fakeFreezes) CloseExpired(userID uint, now time.Time) error {
+	for i, freeze := range r.s.freezes {
+		if freeze.UserID == userID && freeze.IsActive && !freeze.EndDate.After(now) {
+			r.s.freezes[i].IsActive = false
+			r.s.freezes[i].UnfrozenAt = &now
+		}
+	}
+	return nil
+}
+
+type fakeBans struct {
+	repository.BanRepository
+	s *fakeStore
+}
+
+func (r fakeBans) FindActive(userID uint) (*models.BanHistory, error) {
+	for _, ban := range r.s.bans {
+		if ban.UserID == userID && ban.IsActive {
+			ban.BannedBy = r.s.user(ban.BannedByID)
+			return &ban, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r fakeBans) Create(ban *models.BanHistory) error {
+	ban.ID = r.s.id()
+	r.s.bans = append(r.s.bans, *ban)
+	return nil
+}
+
+func (r fakeBans) Save(ban *models.BanHistory) error {
+	for i := range r.s.bans {
+		if r.s.bans[i].ID == ban.ID {
+			r.s.bans[i] = *ban
+			return nil
+		}
+	}
+	return r.Create(ban)
+}
+
+type fakeRoleHistories struct {
+	repository.RoleHistoryRepository
+	s *fakeStore
+}
+
+func (r fakeRoleHistories) Create(history *models.RoleHistory) error {
+	history.ID = r.s.id()
+	r.s.roleHistories = append(r.s.roleHistories, *history)
+	return nil
+}
+
+type fakeRestoreHistories struct {
+	repository.RestoreHistoryRepository
+	s *fakeStore
+}
+
+func (r fakeRestoreHistories) Create(history *models.RestoreHistory) error {
+	history.ID = r.s.id()
+	r.s.restores = append(r.s.restores, *history)
+	return nil
+}
+
+type fakeSessions struct {
+	repository.SessionRepository
+	s *fakeStore
+}
+
+func (r fakeSessions) Create(session *models.Session) error {
+	session.ID = r.s.id()
+	r.s.sessions = append(r.s.sessions, *session)
+	return nil
+}
+
+type fakeBlocklist struct {
+	repository.BlocklistRepository
+	s *fakeStore
+}
+
+func (r fakeBlocklist) IsIPBlocked(ip string) (bool, error) {
+	return r.s.blockedIPs[ip], nil
+}
+
+func (r fakeBlocklist) IsEmailDomainBlocked(email string, _ bool) (bool, error) {
+	_, domain, _ := strings.Cut(email, "@")
+	return r.s.blockedDomains[domain], nil
+}
+
+type fakeDeletions struct {
+	repository.DeletionRepository
+	s *fakeStore
+}
+
+func (r fakeDeletions) Latest(userID uint) (*models.AccountDeletion, error) {
+	for i := len(r.s.deletions) - 1; i >= 0; i-- {
+		if r.s.deletions[i].UserID == userID {
+			deletion := r.s.deletions[i]
+			return &deletion, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r fakeDeletions) Cancel(userID uint, now time.Time) (bool, error) {
+	cancelled := false
+	for i, deletion := range r.s.deletions {
+		if deletion.UserID == userID && deletion.Status == models.AccountDeletionScheduled && deletion.ScheduledFor.After(now) {
+			r.s.deletions[i].Status = models.AccountDeletionCancelled
+			r.s.deletions[i].CancelledAt = &now
+			cancelled = true
+		}
+	}
+	return cancelled, nil
+}
+
+type fakeMerges struct {
+	repository.MergeRepository
+	s *fakeStore
+}
+
+func (r fakeMerges) IsMergedSource(userID uint) (bool, error) {
+	return r.s.mergedSources[userID], nil
+}
+
+type fakeOutbox struct {
+	repository.OutboxRepository
+	s *fakeStore
+}
+
+func (r fakeOutbox) Create(msg *models.OutboxMessage) error {
+	msg.ID = r.s.id()
+	r.s.outbox = append(r.s.outbox, *msg)
+	return nil
+}
+
+type fakeNotifications struct {
+	repository.NotificationRepository
+	s *fakeStore
+}
+
+func (r fakeNotifications) Create(notification *models.Notification) error {
+	notification.ID = r.s.id()
+	r.s.notifications = append(r.s.notifications, *notification)
+	return nil
+}
+
+func (r fakeNotifications) CountUnread(userID uint) (int64, error) {
+	var unread int64
+	for _, notification := range r.s.notifications {
+		if notification.UserID == userID && notification.InApp && notification.ReadAt == nil {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+// Preference returns ErrNotFound, so every user has the default preferences
+func (r fakeNotifications) Preference(uint, models.NotificationType) (*models.NotificationPreference, error) {
+	return nil, repository.ErrNotFound
+}
+
+type fakeEvents struct {
+	s *fakeStore
+}
+
+func (r fakeEvents) Publish(_, payload string) error {
+	r.s.published = append(r.s.published, payload)
+	return nil
+}
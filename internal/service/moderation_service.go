@@ -0,0 +1,396 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ai-backend/internal/events"
+	"ai-backend/internal/metrics"
+	"ai-backend/internal/models"
+	"ai-backend/internal/notification"
+	"ai-backend/internal/outbox"
+	"ai-backend/internal/repository"
+)
+
+// recentSessionDays is how far back sessions are scanned when blocking a banned user's IPs
+const recentSessionDays = 30
+
+// ModerationService bans, unbans and changes the roles of users
+type ModerationService struct {
+	store   repository.Store
+	metrics *metrics.Metrics
+	ctx     context.Context
+}
+
+func NewModerationService(store repository.Store, m *metrics.Metrics) *ModerationService {
+	return &ModerationService{store: store, metrics: m, ctx: context.Background()}
+}
+
+// WithContext returns a copy of the service that runs its queries and outgoing
+// calls with ctx
+func (s *ModerationService) WithContext(ctx context.Context) *ModerationService {
+	return &ModerationService{store: s.store.WithContext(ctx), metrics: s.metrics, ctx: ctx}
+}
+
+// WithStore returns a copy of the service that runs on store, such as a
+// transaction
+func (s *ModerationService) WithStore(store repository.Store) *ModerationService {
+	return &ModerationService{store: store, metrics: s.metrics, ctx: s.ctx}
+}
+
+type BanInput struct {
+	UserID   uint
+	Reason   string
+	Duration string // number of days or "permanent"
+	BlockIPs bool   // also block the IPs the user recently logged in from
+}
+
+type BanResult struct {
+	User       *models.User
+	Ban        *models.BanHistory
+	BlockedIPs []string
+}
+
+type UnbanResult struct {
+	User       *models.User
+	UnbannedAt time.Time
+}
+
+type RoleChangeResult struct {
+	User    *models.User
+	History *models.RoleHistory
+}
+
+// CalculateBanEndDate calculates the end date based on duration
+func CalculateBanEndDate(duration string) (*time.Time, *int, error) {
+	if duration == string(models.BanDurationPermanent) {
+		return nil, nil, nil
+	}
+
+	// Parse duration as number of days
+	var days int
+	if _, err := fmt.Sscanf(duration, "%d", &days); err != nil {
+		return nil, nil, fmt.Errorf("invalid duration format: must be a number or 'permanent'")
+	}
+
+	if days < 1 {
+		return nil, nil, fmt.Errorf("duration must be at least 1 day")
+	}
+
+	endDate := time.Now().AddDate(0, 0, days)
+	return &endDate, &days, nil
+}
+
+// FirstSuperAdminID returns the ID of the earliest created SUPER_ADMIN, or 0 if none exists
+func (s *ModerationService) FirstSuperAdminID() (uint, error) {
+	return firstSuperAdminID(s.ctx, s.store)
+}
+
+// validateBan checks whether the current user is allowed to ban the target user
+func validateBan(ctx context.Context, cu *models.User, targetUser *models.User, firstSuperAdminID uint) error {
+	if targetUser.Status == models.StatusBanned {
+		return NewError(KindInvalid, "User is already banned")
+	}
+
+	if targetUser.ID == firstSuperAdminID {
+		slog.WarnContext(ctx, "Attempt to ban first SUPER_ADMIN", "target_user_id", targetUser.ID)
+		return NewError(KindForbidden, "Cannot ban first SUPER_ADMIN")
+	}
+
+	if cu.Role == models.RoleAdmin {
+		// Admin cannot ban SUPER_ADMIN
+		if targetUser.Role == models.RoleSuperAdmin {
+			slog.WarnContext(ctx, "Admin attempted to ban SUPER_ADMIN", "target_user_id", targetUser.ID)
+			return NewError(KindForbidden, "Admin cannot ban SUPER_ADMIN")
+		}
+	}
+
+	return nil
+}
+
+// Ban bans the user on behalf of cu and records the ban history
+func (s *ModerationService) Ban(cu *models.User, input BanInput) (*BanResult, error) {
+	targetUser, err := findUser(s.ctx, s.store, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	firstSuperAdminID, err := firstSuperAdminID(s.ctx, s.store)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateBan(s.ctx, cu, targetUser, firstSuperAdminID); err != nil {
+		return nil, err
+	}
+
+	// Calculate ban end date
+	endDate, durationDays, err := CalculateBanEndDate(input.Duration)
+	if err != nil {
+		slog.WarnContext(s.ctx, "Invalid duration", "error", err)
+		return nil, NewError(KindInvalid, err.Error())
+	}
+
+	result := &BanResult{User: targetUser, BlockedIPs: []string{}}
+	err = s.store.Transaction(func(tx repository.Store) error {
+		// Create ban history record
+		result.Ban = &models.BanHistory{
+			UserID:       targetUser.ID,
+			BannedByID:   cu.ID,
+			Reason:       input.Reason,
+			Duration:     models.BanDurationType(input.Duration),
+			DurationDays: durationDays,
+			StartDate:    time.Now(),
+			EndDate:      endDate,
+			IsActive:     true,
+		}
+		if err := tx.Bans().Create(result.Ban); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to create ban history", "error", err)
+			return NewError(KindInternal, "Failed to create ban history")
+		}
+
+		// Update user status
+		targetUser.Status = models.StatusBanned
+		if err := tx.Users().Save(targetUser); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to update user status", "error", err)
+			return NewError(KindInternal, "Failed to update user status")
+		}
+
+		// Optionally block the IPs the user recently logged in from
+		if input.BlockIPs {
+			recorded, err := tx.Blocklist().RecordUserIPs(targetUser.ID, cu.ID, input.Reason, recentSessionDays, endDate)
+			if err != nil {
+				slog.ErrorContext(s.ctx, "Failed to record blocked IPs", "error", err)
+				return NewError(KindInternal, "Failed to record blocked IPs")
+			}
+			result.BlockedIPs = append(result.BlockedIPs, recorded...)
+		}
+
+		if err := notification.Notify(s.ctx, tx, notification.Banned(result.Ban)); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to notify banned user", "error", err)
+			return NewError(KindInternal, "Failed to notify user")
+		}
+		if err := events.Emit(s.ctx, tx, events.Banned(result.Ban)); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to emit ban event", "error", err)
+			return NewError(KindInternal, "Failed to record ban event")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	outbox.Wake()
+	events.Committed()
+
+	s.metrics.ObserveModeration("ban")
+	return result, nil
+}
+
+// Unban lifts the user's active ban on behalf of cu and records the unban action
+func (s *ModerationService) Unban(cu *models.User, userID uint, reason string) (*UnbanResult, error) {
+	targetUser, err := findUser(s.ctx, s.store, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if user is banned
+	if targetUser.Status != models.StatusBanned {
+		slog.WarnContext(s.ctx, "User is not banned", "target_user_id", targetUser.ID, "status", targetUser.Status)
+		return nil, NewError(KindInvalid, "User is not banned")
+	}
+
+	// Get active ban record
+	activeBan, err := s.store.Bans().FindActive(targetUser.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.WarnContext(s.ctx, "No active ban found", "target_user_id", targetUser.ID)
+			return nil, NewError(KindNotFound, "No active ban found")
+		}
+		slog.ErrorContext(s.ctx, "Database error while fetching active ban", "error", err)
+		return nil, err
+	}
+
+	// Check if ADMIN is trying to unban a user banned by SUPER_ADMIN
+	if cu.Role == models.RoleAdmin && activeBan.BannedBy.Role == models.RoleSuperAdmin {
+		slog.WarnContext(s.ctx, "Admin attempted to unban user banned by SUPER_ADMIN", "target_user_id", targetUser.ID)
+		return nil, NewError(KindForbidden, "Cannot unban user banned by SUPER_ADMIN")
+	}
+
+	now := time.Now()
+	err = s.store.Transaction(func(tx repository.Store) error {
+		// Update old ban record
+		activeBan.IsActive = false
+		activeBan.UnbannedAt = &now
+		activeBan.UnbannedBy = &cu.ID
+		if err := tx.Bans().Save(activeBan); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to update ban record", "error", err)
+			return NewError(KindInternal, "Failed to update ban record")
+		}
+
+		// Create new ban history record for unban action
+		unbanHistory := models.BanHistory{
+			UserID:     targetUser.ID,
+			BannedByID: cu.ID,
+			Reason:     reason,
+			Duration:   "unban",
+			StartDate:  now,
+			IsActive:   false,
+			UnbannedAt: &now,
+			UnbannedBy: &cu.ID,
+		}
+		if err := tx.Bans().Create(&unbanHistory); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to create unban history", "error", err)
+			return NewError(KindInternal, "Failed to create unban history")
+		}
+
+		// Update user status
+		targetUser.Status = models.StatusActive
+		if err := tx.Users().Save(targetUser); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to update user status", "error", err)
+			return NewError(KindInternal, "Failed to update user status")
+		}
+
+		if err := notification.Notify(s.ctx, tx, notification.Unbanned(targetUser.ID, cu.ID, reason)); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to notify unbanned user", "error", err)
+			return NewError(KindInternal, "Failed to notify user")
+		}
+		if err := events.Emit(s.ctx, tx, events.Unbanned(targetUser.ID, cu.ID, reason)); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to emit unban event", "error", err)
+			return NewError(KindInternal, "Failed to record unban event")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	outbox.Wake()
+	events.Committed()
+
+	s.metrics.ObserveModeration("unban")
+	return &UnbanResult{User: targetUser, UnbannedAt: now}, nil
+}
+
+// validateRoleChange checks whether the current user is allowed to assign newRole to the target user
+func validateRoleChange(ctx context.Context, cu *models.User, targetUser *models.User, newRole models.UserRole, reason string, firstSuperAdminID uint) error {
+	switch newRole {
+	case models.RoleUser, models.RoleEditor, models.RoleAdmin, models.RoleSuperAdmin:
+	default:
+		return NewError(KindInvalid, "Invalid role")
+	}
+
+	if targetUser.ID == firstSuperAdminID && targetUser.Role == models.RoleSuperAdmin {
+		slog.WarnContext(ctx, "Attempt to change first SUPER_ADMIN's role", "target_user_id", targetUser.ID)
+		return NewError(KindForbidden, "Cannot change first SUPER_ADMIN's role")
+	}
+
+	if cu.Role == models.RoleAdmin {
+		// Admin can only modify between USER and EDITOR roles
+		if newRole != models.RoleUser && newRole != models.RoleEditor {
+			slog.WarnContext(ctx, "Admin attempted to assign invalid role", "role", newRole)
+			return NewError(KindForbidden, "Admin can only assign USER or EDITOR roles")
+		}
+
+		// Admin cannot modify SUPER_ADMIN or other ADMIN roles
+		if targetUser.Role == models.RoleSuperAdmin || targetUser.Role == models.RoleAdmin {
+			slog.WarnContext(ctx, "Admin attempted to modify ADMIN/SUPER_ADMIN role", "target_user_id", targetUser.ID, "target_role", targetUser.Role)
+			return NewError(KindForbidden, "Cannot modify ADMIN or SUPER_ADMIN roles")
+		}
+
+		// Admin must provide a reason with minimum 15 characters
+		if len(reason) < 15 {
+			slog.WarnContext(ctx, "Admin provided insufficient reason length", "length", len(reason))
+			return NewError(KindInvalid, "Reason must be at least 15 characters long")
+		}
+	} else if cu.Role == models.RoleSuperAdmin {
+		// Only first SUPER_ADMIN can grant SUPER_ADMIN role
+		if newRole == models.RoleSuperAdmin && cu.ID != firstSuperAdminID {
+			slog.WarnContext(ctx, "Non-first SUPER_ADMIN attempted to grant SUPER_ADMIN role")
+			return NewError(KindForbidden, "Only first SUPER_ADMIN can grant SUPER_ADMIN role")
+		}
+	}
+
+	return nil
+}
+
+// applyRoleChange creates the role history record and updates the target user's role inside tx
+func applyRoleChange(ctx context.Context, tx repository.Store, cu *models.User, targetUser *models.User, newRole models.UserRole, reason string) (*models.RoleHistory, error) {
+	// Create role history record
+	roleHistory := models.RoleHistory{
+		UserID:      targetUser.ID,
+		ChangedByID: cu.ID,
+		OldRole:     targetUser.Role,
+		NewRole:     newRole,
+		Reason:      reason,
+	}
+	if err := tx.RoleHistories().Create(&roleHistory); err != nil {
+		slog.ErrorContext(ctx, "Failed to create role history", "error", err)
+		return nil, NewError(KindInternal, "Failed to create role history")
+	}
+
+	// Update user role
+	targetUser.Role = newRole
+	if err := tx.Users().Save(targetUser); err != nil {
+		slog.ErrorContext(ctx, "Failed to update user role", "error", err)
+		return nil, NewError(KindInternal, "Failed to update role")
+	}
+
+	return &roleHistory, nil
+}
+
+// ChangeRole assigns newRole to the user on behalf of cu and records the change
+func (s *ModerationService) ChangeRole(cu *models.User, userID uint, newRole models.UserRole, reason string) (*RoleChangeResult, error) {
+	targetUser, err := findUser(s.ctx, s.store, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	firstSuperAdminID, err := firstSuperAdminID(s.ctx, s.store)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateRoleChange(s.ctx, cu, targetUser, newRole, reason, firstSuperAdminID); err != nil {
+		return nil, err
+	}
+
+	result := &RoleChangeResult{User: targetUser}
+	err = s.store.Transaction(func(tx repository.Store) error {
+		var err error
+		result.History, err = applyRoleChange(s.ctx, tx, cu, targetUser, newRole, reason)
+		if err != nil {
+			return err
+		}
+
+		if err := notification.Notify(s.ctx, tx, notification.RoleChanged(result.History)); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to notify user of role change", "error", err)
+			return NewError(KindInternal, "Failed to notify user")
+		}
+		if err := events.Emit(s.ctx, tx, events.RoleChanged(result.History)); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to emit role change event", "error", err)
+			return NewError(KindInternal, "Failed to record role change event")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	outbox.Wake()
+	events.Committed()
+
+	return result, nil
+}
+
+// ExpireBans closes temporary bans whose end date has passed and reactivates the
+// users that have no other active ban. It returns the number of bans closed.
+func (s *ModerationService) ExpireBans() (int64, error) {
+	return s.store.Bans().ExpireDue(time.Now())
+}
+
+// ExpireFreezes closes account freezes whose end date has passed and reactivates the
+// frozen users, the same way Login does lazily. It returns the number of freezes closed.
+func (s *ModerationService) ExpireFreezes() (int64, error) {
+	return s.store.Freezes().ExpireDue(time.Now())
+}
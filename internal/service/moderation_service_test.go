@@ -0,0 +1,284 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"ai-backend/internal/models"
+)
+
+const testReason = "Repeated violations of the rules"
+
+// assertKind fails the test unless err is an Error of kind
+func assertKind(t *testing.T, err error, kind ErrorKind) {
+	t.Helper()
+	var serviceErr Error
+	if !errors.As(err, &serviceErr) {
+		t.Fatalf("got error %v, want an Error of kind %d", err, kind)
+	}
+	if serviceErr.Kind != kind {
+		t.Fatalf("got %q of kind %d, want kind %d", serviceErr.Message, serviceErr.Kind, kind)
+	}
+}
+
+func TestBan(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("root", models.RoleSuperAdmin, models.StatusActive)
+	admin := store.addUser("admin", models.RoleAdmin, models.StatusActive)
+	target := store.addUser("target", models.RoleUser, models.StatusActive)
+
+	result, err := NewModerationService(store, newTestMetrics()).Ban(admin, BanInput{UserID: target.ID, Reason: testReason, Duration: "7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status := store.user(target.ID).Status; status != models.StatusBanned {
+		t.Errorf("user status is %s, want %s", status, models.StatusBanned)
+	}
+	if len(store.bans) != 1 {
+		t.Fatalf("recorded %d bans, want 1", len(store.bans))
+	}
+	ban := store.bans[0]
+	if !ban.IsActive || ban.BannedByID != admin.ID || ban.EndDate == nil || *ban.DurationDays != 7 {
+		t.Errorf("recorded ban %+v, want an active 7 day ban by the admin", ban)
+	}
+	if result.Ban.ID != ban.ID {
+		t.Errorf("result holds ban %d, want %d", result.Ban.ID, ban.ID)
+	}
+	if len(store.notifications) != 1 || store.notifications[0].UserID != target.ID {
+		t.Errorf("recorded notifications %+v, want one for the banned user", store.notifications)
+	}
+	if len(store.outbox) != 1 || store.outbox[0].Recipient != *target.Email {
+		t.Errorf("queued emails %+v, want one to the banned user", store.outbox)
+	}
+}
+
+func TestBanPermanent(t *testing.T) {
+	store := newFakeStore()
+	root := store.addUser("root", models.RoleSuperAdmin, models.StatusActive)
+	target := store.addUser("target", models.RoleUser, models.StatusActive)
+
+	if _, err := NewModerationService(store, newTestMetrics()).Ban(root, BanInput{UserID: target.ID, Reason: testReason, Duration: "permanent"}); err != nil {
+		t.Fatal(err)
+	}
+	if ban := store.bans[0]; ban.EndDate != nil || ban.DurationDays != nil {
+		t.Errorf("recorded ban ends at %v, want a permanent ban", ban.EndDate)
+	}
+}
+
+func TestBanRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		actor    string
+		target   string
+		duration string
+		want     ErrorKind
+	}{
+		{"first super admin", "second", "root", "7", KindForbidden},
+		{"admin bans super admin", "admin", "second", "7", KindForbidden},
+		{"already banned", "admin", "banned", "7", KindInvalid},
+		{"invalid duration", "admin", "user", "forever", KindInvalid},
+		{"zero duration", "admin", "user", "0", KindInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			users := map[string]*models.User{
+				"root":   store.addUser("root", models.RoleSuperAdmin, models.StatusActive),
+				"second": store.addUser("second", models.RoleSuperAdmin, models.StatusActive),
+				"admin":  store.addUser("admin", models.RoleAdmin, models.StatusActive),
+				"banned": store.addUser("banned", models.RoleUser, models.StatusBanned),
+				"user":   store.addUser("user", models.RoleUser, models.StatusActive),
+			}
+
+			_, err := NewModerationService(store, newTestMetrics()).Ban(users[tt.actor], BanInput{
+				UserID:   users[tt.target].ID,
+				Reason:   testReason,
+				Duration: tt.duration,
+			})
+			assertKind(t, err, tt.want)
+			if len(store.bans) != 0 {
+				t.Errorf("recorded %d bans, want none", len(store.bans))
+			}
+		})
+	}
+}
+
+func TestBanUnknownUser(t *testing.T) {
+	store := newFakeStore()
+	admin := store.addUser("admin", models.RoleAdmin, models.StatusActive)
+
+	_, err := NewModerationService(store, newTestMetrics()).Ban(admin, BanInput{UserID: 99, Reason: testReason, Duration: "7"})
+	assertKind(t, err, KindNotFound)
+}
+
+func TestUnban(t *testing.T) {
+	store := newFakeStore()
+	admin := store.addUser("admin", models.RoleAdmin, models.StatusActive)
+	target := store.addUser("target", models.RoleUser, models.StatusActive)
+
+	moderationService := NewModerationService(store, newTestMetrics())
+	if _, err := moderationService.Ban(admin, BanInput{UserID: target.ID, Reason: testReason, Duration: "7"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := moderationService.Unban(admin, target.ID, testReason); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := store.user(target.ID).Status; status != models.StatusActive {
+		t.Errorf("user status is %s, want %s", status, models.StatusActive)
+	}
+	if len(store.bans) != 2 {
+		t.Fatalf("recorded %d ban histories, want the ban and the unban", len(store.bans))
+	}
+	if ban := store.bans[0]; ban.IsActive || ban.UnbannedAt == nil || *ban.UnbannedBy != admin.ID {
+		t.Errorf("ban after unban is %+v, want it lifted by the admin", ban)
+	}
+	if unban := store.bans[1]; unban.Duration != "unban" || unban.IsActive {
+		t.Errorf("unban history is %+v, want an inactive unban record", unban)
+	}
+}
+
+func TestBanRecordsMetrics(t *testing.T) {
+	store := newFakeStore()
+	admin := store.addUser("admin", models.RoleAdmin, models.StatusActive)
+	target := store.addUser("target", models.RoleUser, models.StatusActive)
+	m := newTestMetrics()
+	moderationService := NewModerationService(store, m)
+
+	if _, err := moderationService.Ban(admin, BanInput{UserID: target.ID, Reason: testReason, Duration: "7"}); err != nil {
+		t.Fatal(err)
+	}
+	// Rejected actions are not recorded
+	if _, err := moderationService.Ban(admin, BanInput{UserID: target.ID, Reason: testReason, Duration: "7"}); err == nil {
+		t.Fatal("banning a banned user succeeded")
+	}
+	if _, err := moderationService.WithStore(store).Unban(admin, target.ID, testReason); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `
+# HELP ai_backend_moderation_actions_total Moderation actions by action (ban or unban).
+# TYPE ai_backend_moderation_actions_total counter
+ai_backend_moderation_actions_total{action="ban"} 1
+ai_backend_moderation_actions_total{action="unban"} 1
+`
+	if err := testutil.GatherAndCompare(m.Registry, strings.NewReader(want), "ai_backend_moderation_actions_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUnbanRules(t *testing.T) {
+	t.Run("not banned", func(t *testing.T) {
+		store := newFakeStore()
+		admin := store.addUser("admin", models.RoleAdmin, models.StatusActive)
+		target := store.addUser("target", models.RoleUser, models.StatusActive)
+
+		_, err := NewModerationService(store, newTestMetrics()).Unban(admin, target.ID, testReason)
+		assertKind(t, err, KindInvalid)
+	})
+
+	t.Run("no active ban", func(t *testing.T) {
+		store := newFakeStore()
+		admin := store.addUser("admin", models.RoleAdmin, models.StatusActive)
+		target := store.addUser("target", models.RoleUser, models.StatusBanned)
+
+		_, err := NewModerationService(store, newTestMetrics()).Unban(admin, target.ID, testReason)
+		assertKind(t, err, KindNotFound)
+	})
+
+	t.Run("admin lifts a super admin's ban", func(t *testing.T) {
+		store := newFakeStore()
+		root := store.addUser("root", models.RoleSuperAdmin, models.StatusActive)
+		admin := store.addUser("admin", models.RoleAdmin, models.StatusActive)
+		target := store.addUser("target", models.RoleUser, models.StatusActive)
+
+		moderationService := NewModerationService(store, newTestMetrics())
+		if _, err := moderationService.Ban(root, BanInput{UserID: target.ID, Reason: testReason, Duration: "7"}); err != nil {
+			t.Fatal(err)
+		}
+		_, err := moderationService.Unban(admin, target.ID, testReason)
+		assertKind(t, err, KindForbidden)
+		if status := store.user(target.ID).Status; status != models.StatusBanned {
+			t.Errorf("user status is %s, want %s", status, models.StatusBanned)
+		}
+	})
+}
+
+func TestChangeRole(t *testing.T) {
+	store := newFakeStore()
+	admin := store.addUser("admin", models.RoleAdmin, models.StatusActive)
+	target := store.addUser("target", models.RoleUser, models.StatusActive)
+
+	result, err := NewModerationService(store, newTestMetrics()).ChangeRole(admin, target.ID, models.RoleEditor, testReason)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if role := store.user(target.ID).Role; role != models.RoleEditor {
+		t.Errorf("user role is %s, want %s", role, models.RoleEditor)
+	}
+	if len(store.roleHistories) != 1 {
+		t.Fatalf("recorded %d role changes, want 1", len(store.roleHistories))
+	}
+	history := store.roleHistories[0]
+	if history.OldRole != models.RoleUser || history.NewRole != models.RoleEditor || history.ChangedByID != admin.ID {
+		t.Errorf("recorded role change %+v, want USER to EDITOR by the admin", history)
+	}
+	if result.History.ID != history.ID {
+		t.Errorf("result holds role change %d, want %d", result.History.ID, history.ID)
+	}
+}
+
+func TestChangeRoleRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		actor  string
+		target string
+		role   models.UserRole
+		reason string
+		want   ErrorKind
+	}{
+		{"invalid role", "root", "user", "OWNER", testReason, KindInvalid},
+		{"first super admin", "second", "root", models.RoleUser, testReason, KindForbidden},
+		{"second super admin grants super admin", "second", "user", models.RoleSuperAdmin, testReason, KindForbidden},
+		{"admin grants admin", "admin", "user", models.RoleAdmin, testReason, KindForbidden},
+		{"admin demotes admin", "admin", "other", models.RoleUser, testReason, KindForbidden},
+		{"admin demotes super admin", "admin", "second", models.RoleUser, testReason, KindForbidden},
+		{"admin gives a short reason", "admin", "user", models.RoleEditor, "too short", KindInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			users := map[string]*models.User{
+				"root":   store.addUser("root", models.RoleSuperAdmin, models.StatusActive),
+				"second": store.addUser("second", models.RoleSuperAdmin, models.StatusActive),
+				"admin":  store.addUser("admin", models.RoleAdmin, models.StatusActive),
+				"other":  store.addUser("other", models.RoleAdmin, models.StatusActive),
+				"user":   store.addUser("user", models.RoleUser, models.StatusActive),
+			}
+
+			_, err := NewModerationService(store, newTestMetrics()).ChangeRole(users[tt.actor], users[tt.target].ID, tt.role, tt.reason)
+			assertKind(t, err, tt.want)
+			if len(store.roleHistories) != 0 {
+				t.Errorf("recorded %d role changes, want none", len(store.roleHistories))
+			}
+		})
+	}
+}
+
+func TestChangeRoleFirstSuperAdminGrantsSuperAdmin(t *testing.T) {
+	store := newFakeStore()
+	root := store.addUser("root", models.RoleSuperAdmin, models.StatusActive)
+	target := store.addUser("target", models.RoleAdmin, models.StatusActive)
+
+	if _, err := NewModerationService(store, newTestMetrics()).ChangeRole(root, target.ID, models.RoleSuperAdmin, ""); err != nil {
+		t.Fatal(err)
+	}
+	if role := store.user(target.ID).Role; role != models.RoleSuperAdmin {
+		t.Errorf("user role is %s, want %s", role, models.RoleSuperAdmin)
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"ai-backend/internal/models"
+	"ai-backend/internal/notification"
+	"ai-backend/internal/repository"
+)
+
+// NotificationService lists a user's notifications and manages how they are delivered
+type NotificationService struct {
+	store repository.Store
+	ctx   context.Context
+}
+
+func NewNotificationService(store repository.Store) *NotificationService {
+	return &NotificationService{store: store, ctx: context.Background()}
+}
+
+// WithContext returns a copy of the service that runs its queries and outgoing
+// calls with ctx
+func (s *NotificationService) WithContext(ctx context.Context) *NotificationService {
+	return &NotificationService{store: s.store.WithContext(ctx), ctx: ctx}
+}
+
+type ListNotificationsInput struct {
+	Page       int
+	Limit      int
+	UnreadOnly bool
+}
+
+// NotificationPreference is the effective preference for one notification type
+type NotificationPreference struct {
+	Type models.NotificationType `json:"type"`
+	notification.Preference
+}
+
+// List returns a page of the user's notifications, newest first, and the total count
+func (s *NotificationService) List(userID uint, input ListNotificationsInput) ([]models.Notification, int64, error) {
+	notifications, total, err := s.store.Notifications().List(userID, input.UnreadOnly, (input.Page-1)*input.Limit, input.Limit)
+	if err != nil {
+		slog.ErrorContext(s.ctx, "Failed to fetch notifications", "error", err)
+		return nil, 0, NewError(KindInternal, "Failed to fetch notifications")
+	}
+	return notifications, total, nil
+}
+
+// UnreadCount returns how many of the user's notifications are unread
+func (s *NotificationService) UnreadCount(userID uint) (int64, error) {
+	count, err := s.store.Notifications().CountUnread(userID)
+	if err != nil {
+		slog.ErrorContext(s.ctx, "Failed to count unread notifications", "error", err)
+		return 0, NewError(KindInternal, "Failed to count unread notifications")
+	}
+	return count, nil
+}
+
+// MarkRead marks one of the user's notifications as read
+func (s *NotificationService) MarkRead(userID, notificationID uint) error {
+	if err := s.store.Notifications().MarkRead(userID, notificationID, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return NewError(KindNotFound, "Notification not found")
+		}
+		slog.ErrorContext(s.ctx, "Failed to mark notification as read", "error", err)
+		return NewError(KindInternal, "Failed to mark notification as read")
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread notification of the user as read and returns how many
+func (s *NotificationService) MarkAllRead(userID uint) (int64, error) {
+	marked, err := s.store.Notifications().MarkAllRead(userID, time.Now())
+	if err != nil {
+		slog.ErrorContext(s.ctx, "Failed to mark notifications as read", "error", err)
+		return 0, NewError(KindInternal, "Failed to mark notifications as read")
+	}
+	return marked, nil
+}
+
+// Preferences returns the user's preference for every notification type,
+// filling in the defaults for the types the user has not chosen one for
+func (s *NotificationService) Preferences(userID uint) ([]NotificationPreference, error) {
+	stored, err := s.store.Notifications().Preferences(userID)
+	if err != nil {
+		slog.ErrorContext(s.ctx, "Failed to fetch notification preferences", "error", err)
+		return nil, NewError(KindInternal, "Failed to fetch notification preferences")
+	}
+
+	byType := make(map[models.NotificationType]notification.Preference, len(stored))
+	for _, p := range stored {
+		byType[p.Type] = notification.Preference{InApp: p.InApp, Email: p.Email, Digest: p.Digest}
+	}
+
+	preferences := make([]NotificationPreference, len(models.NotificationTypes))
+	for i, t := range models.NotificationTypes {
+		preference, ok := byType[t]
+		if !ok {
+			preference = notification.Default(t)
+		}
+		preferences[i] = NotificationPreference{Type: t, Preference: preference}
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences stores the given preferences of the user and returns the
+// effective preferences for every type. Types left out keep their preference.
+func (s *NotificationService) UpdatePreferences(userID uint, preferences []NotificationPreference) ([]NotificationPreference, error) {
+	for _, p := range preferences {
+		if !notification.IsType(p.Type) {
+			return nil, NewError(KindInvalid, "Unknown notification type: "+string(p.Type))
+		}
+	}
+
+	err := s.store.Transaction(func(tx repository.Store) error {
+		for _, p := range preferences {
+			if err := tx.Notifications().SavePreference(&models.NotificationPreference{
+				UserID: userID,
+				Type:   p.Type,
+				InApp:  p.InApp,
+				Email:  p.Email,
+				Digest: p.Digest,
+			}); err != nil {
+				slog.ErrorContext(s.ctx, "Failed to save notification preference", "error", err)
+				return NewError(KindInternal, "Failed to save notification preferences")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Preferences(userID)
+}
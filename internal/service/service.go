@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+	"ai-backend/internal/tracing"
+	"ai-backend/pkg/utils"
+)
+
+// findUser loads a user by ID, returning a not found Error when it does not exist
+func findUser(ctx context.Context, store repository.Store, id uint) (*models.User, error) {
+	user, err := store.Users().FindByID(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.WarnContext(ctx, "User not found", "target_user_id", id)
+			return nil, NewError(KindNotFound, "User not found")
+		}
+		slog.ErrorContext(ctx, "Database error while fetching user", "error", err)
+		return nil, err
+	}
+	return user, nil
+}
+
+// hashPassword hashes password with bcrypt. It is traced because bcrypt is
+// deliberately slow and dominates registration and password change latency.
+func hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := tracing.Start(ctx, "bcrypt.hash")
+	hashed, err := utils.HashPassword(password)
+	tracing.End(span, err)
+	return hashed, err
+}
+
+// checkPassword reports whether password matches hash; a nil hash never matches
+func checkPassword(ctx context.Context, hash *string, password string) bool {
+	if hash == nil {
+		return false
+	}
+	_, span := tracing.Start(ctx, "bcrypt.compare")
+	defer span.End()
+	return utils.CheckPasswordHash(password, *hash)
+}
+
+// firstSuperAdminID returns the ID of the earliest created SUPER_ADMIN, or 0 if none exists
+func firstSuperAdminID(ctx context.Context, store repository.Store) (uint, error) {
+	firstSuperAdmin, err := store.Users().FindFirstSuperAdmin()
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, nil
+		}
+		slog.ErrorContext(ctx, "Error finding first super admin", "error", err)
+		return 0, err
+	}
+	return firstSuperAdmin.ID, nil
+}
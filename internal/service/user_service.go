@@ -0,0 +1,488 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"ai-backend/internal/accountdeletion"
+	"ai-backend/internal/database"
+	"ai-backend/internal/locale"
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+)
+
+// UserService manages user accounts and profiles
+type UserService struct {
+	store repository.Store
+	ctx   context.Context
+}
+
+func NewUserService(store repository.Store) *UserService {
+	return &UserService{store: store, ctx: context.Background()}
+}
+
+// WithContext returns a copy of the service that runs its queries and outgoing
+// calls with ctx
+func (s *UserService) WithContext(ctx context.Context) *UserService {
+	return &UserService{store: s.store.WithContext(ctx), ctx: ctx}
+}
+
+type ProfileInput struct {
+	Username  *string
+	Email     *string
+	FullName  *string
+	Bio       *string
+	AvatarURL *string
+	Locale    *string // language of emails, empty for the default
+}
+
+type ListUsersInput struct {
+	Page   int
+	Limit  int
+	Search string
+	Role   string
+	Status string
+	Sort   string
+	Order  string
+}
+
+// allowedSortFields are the columns users can be ordered by
+var allowedSortFields = map[string]bool{
+	"created_at": true,
+	"username":   true,
+	"email":      true,
+}
+
+// isUserAllowedStatus checks if the status is allowed for regular users
+func isUserAllowedStatus(status models.UserStatus) bool {
+	allowedStatuses := []models.UserStatus{
+		models.StatusActive,
+		models.StatusPassive,
+		models.StatusFrozen,
+	}
+
+	for _, s := range allowedStatuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateStatus sets the status of the user. Regular users may only change their own status.
+func (s *UserService) UpdateStatus(cu *models.User, userID uint, status models.UserStatus) (*models.User, error) {
+	targetUser, err := findUser(s.ctx, s.store, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check permissions and validate status
+	isAdmin := cu.Role == models.RoleAdmin || cu.Role == models.RoleSuperAdmin
+	isSelfUpdate := cu.ID == targetUser.ID
+
+	// Regular users can only update their own status
+	if !isAdmin && !isSelfUpdate {
+		return nil, NewError(KindForbidden, "You can only update your own status")
+	}
+
+	// Regular users can't set banned status
+	if !isAdmin && status == models.StatusBanned {
+		return nil, NewError(KindForbidden, "Only administrators can set banned status")
+	}
+
+	// Regular users can only set allowed statuses
+	if !isAdmin && !isUserAllowedStatus(status) {
+		return nil, NewError(KindInvalid, "Invalid status for user")
+	}
+
+	// Prevent status update of SUPER_ADMIN by ADMIN
+	if targetUser.Role == models.RoleSuperAdmin && cu.Role == models.RoleAdmin {
+		return nil, NewError(KindForbidden, "Cannot modify SUPER_ADMIN status")
+	}
+
+	targetUser.Status = status
+	if err := s.store.Users().Save(targetUser); err != nil {
+		return nil, NewError(KindInternal, "Failed to update user status")
+	}
+
+	return targetUser, nil
+}
+
+// UpdateProfile applies the set fields of input to the user's profile
+func (s *UserService) UpdateProfile(userID uint, input ProfileInput) (*models.User, error) {
+	user, err := findUser(s.ctx, s.store, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Locale != nil && *input.Locale != "" && !locale.IsSupported(*input.Locale) {
+		return nil, NewError(KindInvalid, "Unsupported locale")
+	}
+
+	// Email veya kullanıcı adı değişikliği varsa, benzersizlik kontrolü yap
+	if input.Email != nil && (user.Email == nil || *input.Email != *user.Email) {
+		taken, err := s.store.Users().EmailTaken(*input.Email, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, NewError(KindConflict, "Email already exists")
+		}
+
+		if blocked, err := s.store.Blocklist().IsEmailDomainBlocked(*input.Email, true); err != nil {
+			slog.ErrorContext(s.ctx, "Failed to check email domain blocklist", "error", err)
+		} else if blocked {
+			return nil, NewError(KindForbidden, "Email domain is not allowed")
+		}
+	}
+
+	if input.Username != nil && (user.Username == nil || *input.Username != *user.Username) {
+		if accountdeletion.IsReservedUsername(*input.Username) {
+			return nil, NewError(KindInvalid, "Username is reserved")
+		}
+		taken, err := s.store.Users().UsernameTaken(*input.Username, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, NewError(KindConflict, "Username already exists")
+		}
+	}
+
+	updates := map[string]interface{}{}
+	if input.Username != nil {
+		updates["username"] = input.Username
+	}
+	if input.Email != nil {
+		updates["email"] = input.Email
+	}
+	if input.FullName != nil {
+		updates["name"] = input.FullName
+	}
+	if input.Bio != nil {
+		updates["bio"] = input.Bio
+	}
+	if input.AvatarURL != nil {
+		updates["image"] = input.AvatarURL
+	}
+	if input.Locale != nil {
+		updates["locale"] = *input.Locale
+	}
+
+	if err := s.store.Users().Update(user, updates); err != nil {
+		return nil, NewError(KindInternal, "Failed to update profile")
+	}
+
+	// Güncellenmiş kullanıcı bilgilerini getir
+	user, err = s.store.Users().FindByID(userID)
+	if err != nil {
+		return nil, NewError(KindInternal, "Failed to fetch updated user")
+	}
+	return user, nil
+}
+
+// DeleteAccount checks the user's password, soft deletes the user, ends their
+// sessions and schedules the erasure after the grace period
+func (s *UserService) DeleteAccount(userID uint, password string) (*models.AccountDeletion, error) {
+	user, err := findUser(s.ctx, s.store, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !checkPassword(s.ctx, user.Password, password) {
+		return nil, NewError(KindUnauthorized, "Invalid password")
+	}
+
+	deletion := &models.AccountDeletion{
+		UserID:       user.ID,
+		Status:       models.AccountDeletionScheduled,
+		ScheduledFor: time.Now().Add(accountdeletion.GracePeriod()),
+	}
+	err = s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Deletions().Create(deletion); err != nil {
+			return err
+		}
+		if err := tx.Users().Delete(user); err != nil {
+			return err
+		}
+		return tx.Sessions().DeleteByUser(user.ID)
+	})
+	if err != nil {
+		slog.ErrorContext(s.ctx, "Failed to schedule account deletion", "target_user_id", user.ID, "error", err)
+		return nil, NewError(KindInternal, "Failed to delete account")
+	}
+
+	return deletion, nil
+}
+
+// Freeze freezes the user's account for duration days
+func (s *UserService) Freeze(userID uint, duration int, reason string) (*models.FreezeHistory, error) {
+	user, err := findUser(s.ctx, s.store, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Aktif dondurma işlemi var mı kontrol et
+	if _, err := s.store.Freezes().FindActive(user.ID, time.Now()); err == nil {
+		return nil, NewError(KindInvalid, "Account is already frozen")
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	startDate := time.Now()
+	freezeHistory := &models.FreezeHistory{
+		UserID:    user.ID,
+		Reason:    reason,
+		Duration:  duration,
+		StartDate: startDate,
+		EndDate:   startDate.AddDate(0, 0, duration),
+		IsActive:  true,
+	}
+
+	err = s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Freezes().Create(freezeHistory); err != nil {
+			return NewError(KindInternal, "Failed to create freeze record")
+		}
+		if err := tx.Users().Update(user, map[string]interface{}{"status": models.StatusFrozen}); err != nil {
+			return NewError(KindInternal, "Failed to update user status")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return freezeHistory, nil
+}
+
+// FreezeHistory returns the user's freezes, newest first
+func (s *UserService) FreezeHistory(userID uint) ([]models.FreezeHistory, error) {
+	return s.store.Freezes().ListByUser(userID)
+}
+
+// List returns a page of users and the total number of matching users
+func (s *UserService) List(input ListUsersInput) ([]models.User, int64, error) {
+	if !allowedSortFields[input.Sort] {
+		input.Sort = "created_at"
+	}
+	if input.Order != "asc" && input.Order != "desc" {
+		input.Order = "desc"
+	}
+
+	filter := repository.UserFilter{
+		Search: input.Search,
+		Role:   input.Role,
+		Status: input.Status,
+		Sort:   input.Sort,
+		Order:  input.Order,
+		Offset: (input.Page - 1) * input.Limit,
+		Limit:  input.Limit,
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, 0, NewError(KindInvalid, err.Error())
+	}
+	return s.store.Users().List(filter)
+}
+
+// Restore brings back a soft deleted or passive user on behalf of cu and records
+// the reason in the restore history. Username and email replace the stored
+// values when set.
+func (s *UserService) Restore(cu *models.User, userID uint, username, email *string, reason string) (*models.User, error) {
+	user, err := s.store.Users().FindByIDUnscoped(userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			slog.WarnContext(s.ctx, "User not found", "target_user_id", userID)
+			return nil, NewError(KindNotFound, "User not found")
+		}
+		slog.ErrorContext(s.ctx, "Database error while fetching user", "error", err)
+		return nil, err
+	}
+
+	// The placeholder is passive so it cannot log in; it owns erased users' content
+	if user.IsPlaceholder {
+		return nil, NewError(KindInvalid, "The deleted user placeholder cannot be restored")
+	}
+	if !user.DeletedAt.Valid && user.Status != models.StatusPassive {
+		return nil, NewError(KindInvalid, "User is not deleted or passive")
+	}
+
+	history := models.RestoreHistory{
+		UserID:       user.ID,
+		RestoredByID: cu.ID,
+		Reason:       reason,
+		OldStatus:    user.Status,
+		WasDeleted:   user.DeletedAt.Valid,
+	}
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		if err := restoreUser(tx, user, username, email); err != nil {
+			return err
+		}
+		return tx.RestoreHistories().Create(&history)
+	}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// restoreUser brings back a soft deleted or passive user inside tx. Username and email
+// replace the stored values when set, which resolves unique index conflicts with accounts
+// created while the user was gone. Deleted users can only be restored within the grace period.
+func restoreUser(tx repository.Store, user *models.User, username, email *string) error {
+	if user.DeletedAt.Valid {
+		merged, err := tx.Merges().IsMergedSource(user.ID)
+		if err != nil {
+			return err
+		}
+		if merged {
+			return accountdeletion.ErrMerged
+		}
+
+		deletion, err := tx.Deletions().Latest(user.ID)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			// Deleted before deletions were scheduled, nothing to cancel
+		case err != nil:
+			return err
+		case deletion.Status == models.AccountDeletionCompleted:
+			return accountdeletion.ErrErased
+		case deletion.Status == models.AccountDeletionScheduled:
+			cancelled, err := tx.Deletions().Cancel(user.ID, time.Now())
+			if err != nil {
+				return err
+			}
+			if !cancelled {
+				return accountdeletion.ErrGracePeriodEnded
+			}
+		}
+	}
+
+	if username != nil {
+		if accountdeletion.IsReservedUsername(*username) {
+			return accountdeletion.ErrUsernameReserved
+		}
+		user.Username = username
+	}
+	if email != nil {
+		user.Email = email
+	}
+
+	if user.Username != nil {
+		taken, err := tx.Users().UsernameTaken(*user.Username, user.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return accountdeletion.ErrUsernameTaken
+		}
+	}
+
+	if user.Email != nil {
+		taken, err := tx.Users().EmailTaken(*user.Email, user.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return accountdeletion.ErrEmailTaken
+		}
+	}
+
+	status := user.Status
+	if status == models.StatusPassive {
+		status = models.StatusActive
+	}
+
+	if err := tx.Users().Restore(user, map[string]interface{}{
+		"username": user.Username,
+		"email":    user.Email,
+		"status":   status,
+	}); err != nil {
+		// Another account took the username or email after the checks above
+		if database.IsUniqueViolation(err) {
+			if database.ViolatedConstraint(err) == "idx_users_email" {
+				return accountdeletion.ErrEmailTaken
+			}
+			return accountdeletion.ErrUsernameTaken
+		}
+		return err
+	}
+
+	user.Status = status
+	return nil
+}
+
+// SetPassword replaces the user's password
+func (s *UserService) SetPassword(userID uint, password string) error {
+	user, err := findUser(s.ctx, s.store, userID)
+	if err != nil {
+		return err
+	}
+
+	hashed, err := hashPassword(s.ctx, password)
+	if err != nil {
+		return NewError(KindInternal, "Failed to process password")
+	}
+
+	return s.store.Users().Update(user, map[string]interface{}{"password": hashed})
+}
+
+// CreateSuperAdmin creates an active SUPER_ADMIN. The very first SUPER_ADMIN
+// bootstraps the system; later ones are granted the role by it.
+func (s *UserService) CreateSuperAdmin(username, email, password string) (*models.User, error) {
+	if accountdeletion.IsReservedUsername(username) {
+		return nil, NewError(KindInvalid, "Username is reserved")
+	}
+	usernameTaken, err := s.store.Users().UsernameTaken(username, 0)
+	if err != nil {
+		return nil, err
+	}
+	emailTaken, err := s.store.Users().EmailTaken(email, 0)
+	if err != nil {
+		return nil, err
+	}
+	if usernameTaken || emailTaken {
+		return nil, NewError(KindConflict, "Username or email already exists")
+	}
+
+	hashed, err := hashPassword(s.ctx, password)
+	if err != nil {
+		return nil, NewError(KindInternal, "Failed to process password")
+	}
+
+	firstSuperAdmin, err := s.store.Users().FindFirstSuperAdmin()
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Username:      &username,
+		Email:         &email,
+		Password:      &hashed,
+		Role:          models.RoleUser,
+		Status:        models.StatusActive,
+		EmailVerified: &now,
+	}
+
+	err = s.store.Transaction(func(tx repository.Store) error {
+		if firstSuperAdmin == nil {
+			user.Role = models.RoleSuperAdmin
+			return tx.Users().Create(user)
+		}
+
+		if err := tx.Users().Create(user); err != nil {
+			return err
+		}
+		if err := validateRoleChange(s.ctx, firstSuperAdmin, user, models.RoleSuperAdmin, "", firstSuperAdmin.ID); err != nil {
+			return err
+		}
+		_, err := applyRoleChange(s.ctx, tx, firstSuperAdmin, user, models.RoleSuperAdmin, "Created with the manage CLI")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	"ai-backend/internal/accountdeletion"
+	"ai-backend/internal/models"
+)
+
+const restoreReason = "Deleted by mistake, confirmed by support"
+
+// admin restores users in the restore tests
+var admin = &models.User{Model: gorm.Model{ID: 1000}, Role: models.RoleAdmin}
+
+// addDeletedUser stores a soft deleted user with a deletion in status scheduled for at
+func addDeletedUser(store *fakeStore, username string, status models.AccountDeletionStatus, at time.Time) *models.User {
+	user := store.addUser(username, models.RoleUser, models.StatusActive)
+	user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	store.users[user.ID] = *user
+	store.deletions = append(store.deletions, models.AccountDeletion{UserID: user.ID, Status: status, ScheduledFor: at})
+	return user
+}
+
+func TestRestorePassiveUser(t *testing.T) {
+	store := newFakeStore()
+	user := store.addUser("alice", models.RoleUser, models.StatusPassive)
+
+	restored, err := NewUserService(store).Restore(admin, user.ID, nil, nil, restoreReason)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Status != models.StatusActive || store.user(user.ID).Status != models.StatusActive {
+		t.Errorf("restored user status is %s, want %s", store.user(user.ID).Status, models.StatusActive)
+	}
+
+	if len(store.restores) != 1 {
+		t.Fatalf("recorded %d restores, want 1", len(store.restores))
+	}
+	if got := store.restores[0]; got.UserID != user.ID || got.RestoredByID != admin.ID || got.Reason != restoreReason ||
+		got.OldStatus != models.StatusPassive || got.WasDeleted {
+		t.Errorf("recorded restore %+v, want one of passive user %d by %d with the reason", got, user.ID, admin.ID)
+	}
+}
+
+func TestRestoreDeletedUser(t *testing.T) {
+	store := newFakeStore()
+	user := addDeletedUser(store, "alice", models.AccountDeletionScheduled, time.Now().Add(24*time.Hour))
+
+	if _, err := NewUserService(store).Restore(admin, user.ID, nil, nil, restoreReason); err != nil {
+		t.Fatal(err)
+	}
+	if store.user(user.ID).DeletedAt.Valid {
+		t.Error("user is still deleted")
+	}
+	if status := store.deletions[0].Status; status != models.AccountDeletionCancelled {
+		t.Errorf("deletion status is %s, want %s", status, models.AccountDeletionCancelled)
+	}
+	if len(store.restores) != 1 || !store.restores[0].WasDeleted {
+		t.Errorf("recorded restores %+v, want one of a deleted user", store.restores)
+	}
+}
+
+func TestRestoreReplacesTakenUsername(t *testing.T) {
+	store := newFakeStore()
+	user := addDeletedUser(store, "alice", models.AccountDeletionScheduled, time.Now().Add(24*time.Hour))
+	store.addUser("alice", models.RoleUser, models.StatusActive)
+
+	_, err := NewUserService(store).Restore(admin, user.ID, nil, nil, restoreReason)
+	if !errors.Is(err, accountdeletion.ErrUsernameTaken) {
+		t.Fatalf("got error %v, want %v", err, accountdeletion.ErrUsernameTaken)
+	}
+
+	username, email := "alice2", "alice2@example.com"
+	if _, err := NewUserService(store).Restore(admin, user.ID, &username, &email, restoreReason); err != nil {
+		t.Fatal(err)
+	}
+	if restored := store.user(user.ID); *restored.Username != username || *restored.Email != email {
+		t.Errorf("restored user is %s <%s>, want %s <%s>", *restored.Username, *restored.Email, username, email)
+	}
+}
+
+// A username or email taken between the checks and the update is rejected by
+// the unique indexes and reported as the same conflict
+func TestRestoreUniqueViolation(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       error
+	}{
+		{"idx_users_username", accountdeletion.ErrUsernameTaken},
+		{"idx_users_email", accountdeletion.ErrEmailTaken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			store := newFakeStore()
+			user := store.addUser("alice", models.RoleUser, models.StatusPassive)
+			store.restoreErr = &pgconn.PgError{Code: "23505", ConstraintName: tt.constraint}
+
+			_, err := NewUserService(store).Restore(admin, user.ID, nil, nil, restoreReason)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+			if len(store.restores) != 0 {
+				t.Error("a failed restore was recorded")
+			}
+		})
+	}
+}
+
+func TestRestoreRules(t *testing.T) {
+	t.Run("active user", func(t *testing.T) {
+		store := newFakeStore()
+		user := store.addUser("alice", models.RoleUser, models.StatusActive)
+
+		_, err := NewUserService(store).Restore(admin, user.ID, nil, nil, restoreReason)
+		assertKind(t, err, KindInvalid)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, err := NewUserService(newFakeStore()).Restore(admin, 99, nil, nil, restoreReason)
+		assertKind(t, err, KindNotFound)
+	})
+
+	t.Run("placeholder", func(t *testing.T) {
+		store := newFakeStore()
+		user := store.addUser("deleted-user", models.RoleUser, models.StatusPassive)
+		user.IsPlaceholder = true
+		store.users[user.ID] = *user
+
+		_, err := NewUserService(store).Restore(admin, user.ID, nil, nil, restoreReason)
+		assertKind(t, err, KindInvalid)
+	})
+
+	tests := []struct {
+		name     string
+		status   models.AccountDeletionStatus
+		at       time.Time
+		merged   bool
+		username string
+		want     error
+	}{
+		{"grace period ended", models.AccountDeletionScheduled, time.Now().Add(-time.Hour), false, "", accountdeletion.ErrGracePeriodEnded},
+		{"erased", models.AccountDeletionCompleted, time.Now().Add(-time.Hour), false, "", accountdeletion.ErrErased},
+		{"merged", models.AccountDeletionScheduled, time.Now().Add(time.Hour), true, "", accountdeletion.ErrMerged},
+		{"reserved username", models.AccountDeletionScheduled, time.Now().Add(time.Hour), false, "deleted-user-2", accountdeletion.ErrUsernameReserved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			user := addDeletedUser(store, "alice", tt.status, tt.at)
+			store.mergedSources[user.ID] = tt.merged
+			var username *string
+			if tt.username != "" {
+				username = &tt.username
+			}
+
+			_, err := NewUserService(store).Restore(admin, user.ID, username, nil, restoreReason)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+			if !store.user(user.ID).DeletedAt.Valid {
+				t.Error("user was restored")
+			}
+		})
+	}
+}
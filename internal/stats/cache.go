@@ -0,0 +1,87 @@
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	snapshot  *Snapshot
+	err       error
+	expiresAt time.Time
+	ready     chan struct{}
+}
+
+// Cache keeps computed snapshots per date range for a fixed TTL, similar to a
+// periodically refreshed materialized view. Concurrent requests for the same
+// range share a single computation.
+type Cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*cacheEntry
+}
+
+// NewCache creates a snapshot cache with the given TTL
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, entries: map[string]*cacheEntry{}}
+}
+
+func cacheKey(from, to time.Time) string {
+	return from.Format(time.RFC3339) + "|" + to.Format(time.RFC3339)
+}
+
+// Get returns the cached snapshot for the range, computing it with compute when missing, expired
+// or when refresh is set. The boolean result reports whether the snapshot came from the cache.
+func (c *Cache) Get(compute func(from, to time.Time) (*Snapshot, error), from, to time.Time, refresh bool) (*Snapshot, bool, error) {
+	key := cacheKey(from, to)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && !refresh {
+		select {
+		case <-entry.ready:
+			if entry.err == nil && time.Now().Before(entry.expiresAt) {
+				c.mu.Unlock()
+				return entry.snapshot, true, nil
+			}
+		default:
+			// Another request is computing this range, wait for it
+			c.mu.Unlock()
+			<-entry.ready
+			return entry.snapshot, true, entry.err
+		}
+	}
+
+	c.evictExpired()
+	entry = &cacheEntry{ready: make(chan struct{})}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	entry.snapshot, entry.err = compute(from, to)
+	entry.expiresAt = time.Now().Add(c.ttl)
+	close(entry.ready)
+
+	if entry.err != nil {
+		c.mu.Lock()
+		if c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+	}
+
+	return entry.snapshot, false, entry.err
+}
+
+// evictExpired drops finished entries past their TTL; callers must hold c.mu
+func (c *Cache) evictExpired() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		select {
+		case <-entry.ready:
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		default:
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package stats
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"ai-backend/internal/models"
+)
+
+// DailyCount is the number of rows created on a single day
+type DailyCount struct {
+	Day   string `json:"day"`
+	Count int64  `json:"count"`
+}
+
+// AdminBanCount is the number of bans issued by a single admin
+type AdminBanCount struct {
+	AdminID  uint   `json:"admin_id"`
+	Username string `json:"username"`
+	Count    int64  `json:"count"`
+}
+
+// Snapshot holds the aggregated dashboard metrics for a date range
+type Snapshot struct {
+	From                time.Time        `json:"from"`
+	To                  time.Time        `json:"to"`
+	GeneratedAt         time.Time        `json:"generated_at"`
+	SignupsPerDay       []DailyCount     `json:"signups_per_day"`
+	UsersByStatus       map[string]int64 `json:"users_by_status"`
+	UsersByRole         map[string]int64 `json:"users_by_role"`
+	BansPerAdmin        []AdminBanCount  `json:"bans_per_admin"`
+	QuestionsPerDay     []DailyCount     `json:"questions_per_day"`
+	AnswersPerDay       []DailyCount     `json:"answers_per_day"`
+	VotesPerDay         []DailyCount     `json:"votes_per_day"`
+	TotalQuestions      int64            `json:"total_questions"`
+	UnansweredQuestions int64            `json:"unanswered_questions"`
+	UnansweredRatio     float64          `json:"unanswered_ratio"`
+}
+
+// dailyCounts groups rows of model created in [from, to) by day
+func dailyCounts(db *gorm.DB, model interface{}, from, to time.Time) ([]DailyCount, error) {
+	counts := []DailyCount{}
+	err := db.Model(model).
+		Select("TO_CHAR(DATE_TRUNC('day', created_at), 'YYYY-MM-DD') AS day, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("day").
+		Order("day asc").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// groupCounts counts current users grouped by column, making sure every key in keys is present
+func groupCounts(db *gorm.DB, column string, keys []string) (map[string]int64, error) {
+	var rows []struct {
+		GroupKey string
+		Count    int64
+	}
+	if err := db.Model(&models.User{}).
+		Select(column + " AS group_key, COUNT(*) AS count").
+		Group(column).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int64, len(keys))
+	for _, key := range keys {
+		result[key] = 0
+	}
+	for _, row := range rows {
+		result[row.GroupKey] = row.Count
+	}
+	return result, nil
+}
+
+// Compute runs the aggregate queries for the [from, to) range
+func Compute(db *gorm.DB, from, to time.Time) (*Snapshot, error) {
+	snapshot := &Snapshot{From: from, To: to, GeneratedAt: time.Now()}
+	var err error
+
+	// Deleted users still count as signups
+	if snapshot.SignupsPerDay, err = dailyCounts(db.Unscoped(), &models.User{}, from, to); err != nil {
+		return nil, err
+	}
+
+	if snapshot.UsersByStatus, err = groupCounts(db, "status", []string{
+		string(models.StatusActive),
+		string(models.StatusPassive),
+		string(models.StatusBanned),
+		string(models.StatusFrozen),
+	}); err != nil {
+		return nil, err
+	}
+
+	if snapshot.UsersByRole, err = groupCounts(db, "role", []string{
+		string(models.RoleUser),
+		string(models.RoleEditor),
+		string(models.RoleAdmin),
+		string(models.RoleSuperAdmin),
+	}); err != nil {
+		return nil, err
+	}
+
+	snapshot.BansPerAdmin = []AdminBanCount{}
+	if err := db.Model(&models.BanHistory{}).
+		Select("ban_histories.banned_by_id AS admin_id, COALESCE(users.username, '') AS username, COUNT(*) AS count").
+		Joins("LEFT JOIN users ON users.id = ban_histories.banned_by_id").
+		Where("ban_histories.duration <> ? AND ban_histories.created_at >= ? AND ban_histories.created_at < ?", "unban", from, to).
+		Group("ban_histories.banned_by_id, users.username").
+		Order("count desc").
+		Scan(&snapshot.BansPerAdmin).Error; err != nil {
+		return nil, err
+	}
+
+	if snapshot.QuestionsPerDay, err = dailyCounts(db, &models.Question{}, from, to); err != nil {
+		return nil, err
+	}
+	if snapshot.AnswersPerDay, err = dailyCounts(db, &models.Answer{}, from, to); err != nil {
+		return nil, err
+	}
+	if snapshot.VotesPerDay, err = dailyCounts(db, &models.Vote{}, from, to); err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&models.Question{}).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Count(&snapshot.TotalQuestions).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&models.Question{}).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Where("NOT EXISTS (SELECT 1 FROM answers WHERE answers.question_id = questions.id AND answers.deleted_at IS NULL)").
+		Count(&snapshot.UnansweredQuestions).Error; err != nil {
+		return nil, err
+	}
+
+	if snapshot.TotalQuestions > 0 {
+		snapshot.UnansweredRatio = float64(snapshot.UnansweredQuestions) / float64(snapshot.TotalQuestions)
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,54 @@
+package votes
+
+import (
+	"gorm.io/gorm"
+
+	"ai-backend/internal/models"
+)
+
+// questionScore sums the live votes cast directly on a question
+const questionScore = `COALESCE((SELECT SUM(CASE WHEN votes.vote_type = 'up' THEN 1 ELSE -1 END) FROM votes
+	WHERE votes.question_id = questions.id AND votes.answer_id IS NULL AND votes.deleted_at IS NULL), 0)`
+
+// answerScore sums the live votes cast on an answer
+const answerScore = `COALESCE((SELECT SUM(CASE WHEN votes.vote_type = 'up' THEN 1 ELSE -1 END) FROM votes
+	WHERE votes.answer_id = answers.id AND votes.deleted_at IS NULL), 0)`
+
+// Recompute recalculates the vote counts of the given questions and answers from their votes
+func Recompute(db *gorm.DB, questionIDs, answerIDs []uint) error {
+	if len(questionIDs) > 0 {
+		if err := db.Unscoped().Model(&models.Question{}).
+			Where("id IN ?", questionIDs).
+			UpdateColumn("vote_count", gorm.Expr(questionScore)).Error; err != nil {
+			return err
+		}
+	}
+	if len(answerIDs) > 0 {
+		if err := db.Unscoped().Model(&models.Answer{}).
+			Where("id IN ?", answerIDs).
+			UpdateColumn("vote_count", gorm.Expr(answerScore)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecomputeAll recalculates every question and answer vote count whose stored value has drifted,
+// returning the number of rows fixed
+func RecomputeAll(db *gorm.DB) (int64, error) {
+	questions := db.Unscoped().Model(&models.Question{}).
+		Where("vote_count <> "+questionScore).
+		UpdateColumn("vote_count", gorm.Expr(questionScore))
+	if questions.Error != nil {
+		return 0, questions.Error
+	}
+
+	answers := db.Unscoped().Model(&models.Answer{}).
+		Where("vote_count <> "+answerScore).
+		UpdateColumn("vote_count", gorm.Expr(answerScore))
+	if answers.Error != nil {
+		return questions.RowsAffected, answers.Error
+	}
+
+	return questions.RowsAffected + answers.RowsAffected, nil
+}
@@ -0,0 +1,344 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-backend/internal/background"
+	"ai-backend/internal/config"
+	"ai-backend/internal/events"
+	"ai-backend/internal/metrics"
+	"ai-backend/internal/models"
+	"ai-backend/internal/repository"
+)
+
+const (
+	// DispatcherName identifies the dispatcher in background job metrics and health checks
+	DispatcherName = "webhook_dispatcher"
+	cleanupName    = "webhook_cleanup"
+
+	// PingEvent is sent by Ping to test a subscription. Subscriptions cannot filter it.
+	PingEvent = "webhook.ping"
+
+	// lease hides a claimed delivery from other claims while it is sent. It must
+	// be longer than the delivery timeout, which config limits to a minute.
+	lease = 2 * time.Minute
+
+	// maxResponseBody is how much of a response is kept in the delivery log
+	maxResponseBody = 1024
+	userAgent       = "ai-backend-webhooks/1.0"
+)
+
+// Wake asks the dispatcher to send pending deliveries now instead of at its next poll
+func Wake() {
+	background.Trigger(DispatcherName)
+}
+
+// enqueue records a delivery of event for every active subscription that
+// receives its type. It runs in the transaction that emitted the event.
+func enqueue(_ context.Context, store repository.Store, event events.Event) error {
+	subscriptions, err := store.Webhooks().ActiveSubscriptions(event.Type)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		if err := store.Webhooks().CreateDelivery(&models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        string(payload),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Dispatcher sends pending deliveries. Deliveries are at least once: receivers
+// should use the event ID to ignore repeats.
+type Dispatcher struct {
+	store   repository.Store
+	cfg     config.WebhookConfig
+	client  *http.Client
+	metrics *metrics.Metrics
+}
+
+func NewDispatcher(store repository.Store, cfg config.WebhookConfig, m *metrics.Metrics) *Dispatcher {
+	return &Dispatcher{store: store, cfg: cfg, client: newClient(), metrics: m}
+}
+
+// newClient returns the client deliveries are sent with. Redirects are not
+// followed: a subscription should point at its final URL.
+func newClient() *http.Client {
+	return &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Subscribe records a delivery for every emitted event a subscription
+// receives. Processes that emit events without running the dispatcher call it
+// so their changes are still delivered, by the API's dispatcher.
+func Subscribe() {
+	events.Subscribe(events.Subscriber{
+		Name:      "webhooks",
+		Handle:    enqueue,
+		Committed: Wake,
+	})
+}
+
+// Start subscribes to emitted events, polls for due deliveries every
+// cfg.PollInterval, and whenever events are committed, and deletes finished
+// deliveries older than cfg.Retention once an hour
+func Start(store repository.Store, cfg config.WebhookConfig, m *metrics.Metrics) {
+	d := NewDispatcher(store, cfg, m)
+
+	Subscribe()
+	background.Every(DispatcherName, cfg.PollInterval, m, d.Dispatch)
+	background.Every(cleanupName, time.Hour, m, func(context.Context) {
+		d.cleanup()
+	})
+}
+
+// Dispatch claims due deliveries in batches and sends each batch with
+// cfg.Workers concurrent workers, until no due delivery is left or ctx is done
+func (d *Dispatcher) Dispatch(ctx context.Context) {
+	batchSize := d.cfg.Workers * 4
+	for ctx.Err() == nil {
+		deliveries, err := d.store.Webhooks().ClaimDue(time.Now(), batchSize, lease)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to claim webhook deliveries", "error", err)
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		subscriptions := make(map[uint]*models.WebhookSubscription)
+		for _, delivery := range deliveries {
+			if _, ok := subscriptions[delivery.SubscriptionID]; ok {
+				continue
+			}
+			subscription, err := d.store.Webhooks().FindSubscription(delivery.SubscriptionID)
+			if err != nil {
+				// The deliveries become due again when the lease ends
+				slog.ErrorContext(ctx, "Failed to load webhook subscription", "subscription_id", delivery.SubscriptionID, "error", err)
+				continue
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+
+		// A claimed batch is always sent completely; each delivery has its own
+		// timeout, so shutdown waits for at most one batch
+		jobs := make(chan models.WebhookDelivery)
+		var wg sync.WaitGroup
+		for i := 0; i < min(d.cfg.Workers, len(deliveries)); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for delivery := range jobs {
+					if subscription, ok := subscriptions[delivery.SubscriptionID]; ok {
+						d.deliver(subscription, delivery)
+					}
+				}
+			}()
+		}
+		for _, delivery := range deliveries {
+			jobs <- delivery
+		}
+		close(jobs)
+		wg.Wait()
+
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+// deliver sends a claimed delivery and records the outcome, scheduling a retry
+// or giving up when it failed
+func (d *Dispatcher) deliver(subscription *models.WebhookSubscription, delivery models.WebhookDelivery) {
+	var result attempt
+	if subscription.Active {
+		result = d.send(subscription, &delivery)
+	} else {
+		result = attempt{err: errors.New("subscription is disabled")}
+	}
+	fields := result.fields()
+
+	switch {
+	case result.err == nil:
+		d.metrics.ObserveWebhookDelivery(delivery.EventType, "success")
+		fields["status"] = models.WebhookDeliverySucceeded
+		fields["delivered_at"] = time.Now()
+	case delivery.Attempts >= d.cfg.MaxAttempts || !subscription.Active:
+		slog.Error("Giving up on webhook delivery", "delivery_id", delivery.ID, "subscription_id", subscription.ID,
+			"event_type", delivery.EventType, "attempts", delivery.Attempts, "error", result.err)
+		d.metrics.ObserveWebhookDelivery(delivery.EventType, "failure")
+		fields["status"] = models.WebhookDeliveryFailed
+	default:
+		retryAt := time.Now().Add(Backoff(d.cfg, delivery.Attempts))
+		slog.Warn("Webhook delivery will be retried", "delivery_id", delivery.ID, "subscription_id", subscription.ID,
+			"event_type", delivery.EventType, "attempts", delivery.Attempts, "retry_at", retryAt, "error", result.err)
+		d.metrics.ObserveWebhookDelivery(delivery.EventType, "retry")
+		fields["next_attempt_at"] = retryAt
+	}
+
+	if err := d.store.Webhooks().RecordAttempt(delivery.ID, fields); err != nil {
+		slog.Error("Failed to record webhook delivery attempt", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// attempt is the outcome of sending a delivery once
+type attempt struct {
+	status   *int
+	body     *string
+	duration time.Duration
+	err      error
+}
+
+// fields returns the delivery columns describing the attempt
+func (a attempt) fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"response_status": a.status,
+		"response_body":   a.body,
+		"duration_ms":     nil,
+		"last_error":      nil,
+	}
+	if a.duration > 0 {
+		fields["duration_ms"] = a.duration.Milliseconds()
+	}
+	if a.err != nil {
+		fields["last_error"] = a.err.Error()
+	}
+	return fields
+}
+
+// send posts the payload of delivery to the subscription. Only 2xx responses count as delivered.
+func (d *Dispatcher) send(subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) attempt {
+	ctx, cancel := context.WithTimeout(context.Background(), d.cfg.Timeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return attempt{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, time.Now(), body))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return attempt{duration: time.Since(start), err: err}
+	}
+	defer resp.Body.Close()
+
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	// Drain a little more so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	// Postgres text columns reject NUL bytes and invalid UTF-8
+	text := strings.ReplaceAll(strings.ToValidUTF8(string(excerpt), ""), "\x00", "")
+	result := attempt{status: &resp.StatusCode, body: &text, duration: time.Since(start)}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		result.err = fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return result
+}
+
+// Ping sends a PingEvent to the subscription right away, whether it is active
+// or not, and returns the logged delivery. A failed ping is not retried.
+func Ping(ctx context.Context, store repository.Store, cfg config.WebhookConfig, subscription *models.WebhookSubscription) (*models.WebhookDelivery, error) {
+	id, err := events.NewID()
+	if err != nil {
+		return nil, err
+	}
+	event := events.New(PingEvent, map[string]any{"subscription_id": subscription.ID})
+	event.ID = id
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	store = store.WithContext(ctx)
+	// Counted as attempted and leased, so the dispatcher leaves it alone
+	delivery := &models.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        string(payload),
+		Status:         models.WebhookDeliveryPending,
+		Attempts:       1,
+		NextAttemptAt:  time.Now().Add(lease),
+	}
+	if err := store.Webhooks().CreateDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	// Pings are not counted in the delivery metrics
+	d := NewDispatcher(store, cfg, nil)
+	result := d.send(subscription, delivery)
+	fields := result.fields()
+	if result.err == nil {
+		fields["status"] = models.WebhookDeliverySucceeded
+		fields["delivered_at"] = time.Now()
+	} else {
+		fields["status"] = models.WebhookDeliveryFailed
+	}
+	if err := store.Webhooks().RecordAttempt(delivery.ID, fields); err != nil {
+		return nil, err
+	}
+	return store.Webhooks().FindDelivery(subscription.ID, delivery.ID)
+}
+
+// Backoff returns how long to wait after the given number of failed attempts:
+// BackoffBase doubled per attempt up to BackoffMax, with jitter so deliveries
+// that failed together are not retried together
+func Backoff(cfg config.WebhookConfig, attempts int) time.Duration {
+	delay := cfg.BackoffBase
+	for i := 1; i < attempts && delay < cfg.BackoffMax; i++ {
+		delay *= 2
+	}
+	delay = min(delay, cfg.BackoffMax)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// cleanup deletes finished deliveries older than the retention period
+func (d *Dispatcher) cleanup() {
+	deleted, err := d.store.Webhooks().DeleteFinishedBefore(time.Now().Add(-d.cfg.Retention))
+	if err != nil {
+		slog.Error("Failed to delete old webhook deliveries", "error", err)
+		return
+	}
+	if deleted > 0 {
+		slog.Info("Deleted old webhook deliveries", "count", deleted)
+	}
+}
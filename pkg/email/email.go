@@ -1,50 +1,56 @@
 package email
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"strings"
 
-	"github.com/resend/resend-go/v2"
+	"go.opentelemetry.io/otel/attribute"
+
+	"ai-backend/internal/config"
+	"ai-backend/internal/locale"
+	"ai-backend/internal/tracing"
 )
 
-// SendPasswordResetEmail sends a password reset email to the user
-func SendPasswordResetEmail(to string, resetToken string) error {
-	// Initialize Resend client
-	apiKey := os.Getenv("RESEND_API_KEY")
-	if apiKey == "" {
-		return fmt.Errorf("RESEND_API_KEY is not set")
+// Deliver sends msg through the default mailer inside a span. kind names the
+// email in logs, metrics and traces. The configured sender is used when msg
+// has none.
+func Deliver(ctx context.Context, kind string, msg Message) error {
+	mailer, err := Default()
+	if err != nil {
+		slog.ErrorContext(ctx, "No mailer available", "kind", kind, "error", err)
+		return fmt.Errorf("failed to send email: %w", err)
 	}
-	
-	log.Printf("Sending reset email to: %s", to)
-	client := resend.NewClient(apiKey)
-
-	// Create email params
-	params := &resend.SendEmailRequest{
-		From:    "Answer App <onboarding@resend.dev>",
-		To:      []string{to},
-		Subject: "Password Reset Request",
-		Html: fmt.Sprintf(`
-			<h1>Password Reset Request</h1>
-			<p>You have requested to reset your password. Please use the following token to reset your password:</p>
-			<p><strong>%s</strong></p>
-			<p>This token will expire in 1 hour.</p>
-			<p>If you did not request this password reset, please ignore this email.</p>
-			<br>
-			<p>Best regards,</p>
-			<p>Answer App Team</p>
-		`, resetToken),
+	msg.Kind = kind
+	if msg.From == "" {
+		msg.From = config.Get().Email.From
 	}
 
-	log.Printf("Attempting to send email with params: %+v", params)
-
-	// Send email
-	resp, err := client.Emails.Send(params)
+	ctx, span := tracing.Start(ctx, "email.send",
+		attribute.String("email.kind", kind),
+		attribute.String("email.provider", mailer.Name()),
+	)
+	err = mailer.Send(ctx, msg)
+	tracing.End(span, err)
 	if err != nil {
-		log.Printf("Failed to send email: %v", err)
+		slog.ErrorContext(ctx, "Failed to send email", "kind", kind, "driver", mailer.Name(), "to", strings.Join(msg.To, ","), "error", err)
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
-	log.Printf("Email sent successfully. Response ID: %s", resp.Id)
+	slog.InfoContext(ctx, "Email sent", "kind", kind, "driver", mailer.Name(), "to", strings.Join(msg.To, ","))
 	return nil
-} 
\ No newline at end of file
+}
+
+// Send renders the named template with data in the locale of ctx and sends it
+// to to right away. Emails that report a database change should be queued with
+// the outbox package instead, so they are retried and never sent for a change
+// that was rolled back.
+func Send(ctx context.Context, to, name string, data any) error {
+	msg, err := Render(name, locale.FromContext(ctx), data)
+	if err != nil {
+		return err
+	}
+	msg.To = []string{to}
+	return Deliver(ctx, name, msg)
+}
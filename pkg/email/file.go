@@ -0,0 +1,50 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// File writes every message to a .eml file in a directory instead of sending
+// it, so emails can be read in development without any provider
+type File struct {
+	dir string
+}
+
+// NewFile creates dir if needed and returns a mailer writing to it
+func NewFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create email directory: %w", err)
+	}
+	return &File{dir: dir}, nil
+}
+
+func (*File) Name() string {
+	return "file"
+}
+
+func (m *File) Send(ctx context.Context, msg Message) error {
+	body, err := buildMIME(msg)
+	if err != nil {
+		return err
+	}
+
+	// Names sort by creation time; the random suffix keeps them unique
+	f, err := os.CreateTemp(m.dir, time.Now().UTC().Format("20060102T150405.000")+"-*.eml")
+	if err != nil {
+		return fmt.Errorf("failed to create email file: %w", err)
+	}
+	if _, err := f.Write(body); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write email file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write email file: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Email written to file", "path", f.Name())
+	return nil
+}
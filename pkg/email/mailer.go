@@ -0,0 +1,136 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"ai-backend/internal/config"
+	"ai-backend/internal/metrics"
+)
+
+// Message is an email ready to be delivered. Text is the plain text
+// alternative of HTML; either may be empty but not both.
+type Message struct {
+	// Kind names the email in logs, metrics and traces. Deliver sets it.
+	Kind    string
+	From    string
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Mailer delivers messages through a transport
+type Mailer interface {
+	// Name identifies the transport in logs, metrics and traces
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Pinger is implemented by mailers that can check their transport is reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// New creates the mailer selected by cfg.Driver. The outcome of every send is
+// recorded in m.
+func New(cfg config.EmailConfig, m *metrics.Metrics) (Mailer, error) {
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return Instrument(transport, m), nil
+}
+
+func newTransport(cfg config.EmailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case config.EmailDriverResend:
+		if cfg.ResendAPIKey == "" {
+			return nil, fmt.Errorf("RESEND_API_KEY is not set")
+		}
+		return NewResend(cfg.ResendAPIKey), nil
+	case config.EmailDriverSMTP:
+		return NewSMTP(cfg.SMTP), nil
+	case config.EmailDriverFile:
+		return NewFile(cfg.Dir)
+	case config.EmailDriverMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown email driver %q", cfg.Driver)
+	}
+}
+
+// instrumented records the outcome of the sends of a transport
+type instrumented struct {
+	Mailer
+	metrics *metrics.Metrics
+}
+
+// Instrument returns a mailer that sends through transport and records the
+// outcome of every send, by message kind, in m
+func Instrument(transport Mailer, m *metrics.Metrics) Mailer {
+	return &instrumented{Mailer: transport, metrics: m}
+}
+
+func (i *instrumented) Send(ctx context.Context, msg Message) error {
+	err := i.Mailer.Send(ctx, msg)
+	i.metrics.ObserveEmail(msg.Kind, err)
+	return err
+}
+
+func (i *instrumented) Ping(ctx context.Context) error {
+	if pinger, ok := i.Mailer.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+var (
+	mu      sync.RWMutex
+	current Mailer
+)
+
+// SetDefault makes m the mailer used by the Send functions
+func SetDefault(m Mailer) {
+	mu.Lock()
+	current = m
+	mu.Unlock()
+}
+
+// Default returns the mailer set with SetDefault. Until then it creates the
+// mailer selected by the configuration, recording on a private registry.
+func Default() (Mailer, error) {
+	mu.RLock()
+	m := current
+	mu.RUnlock()
+	if m != nil {
+		return m, nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		created, err := New(config.Get().Email, metrics.New(prometheus.NewRegistry()))
+		if err != nil {
+			return nil, err
+		}
+		current = created
+	}
+	return current, nil
+}
+
+// Ping checks that the default mailer can deliver. Mailers without a
+// reachable transport to check only need to be configured.
+func Ping(ctx context.Context) error {
+	m, err := Default()
+	if err != nil {
+		return err
+	}
+	if pinger, ok := m.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
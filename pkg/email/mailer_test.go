@@ -0,0 +1,57 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"ai-backend/internal/metrics"
+)
+
+// failing is a transport that rejects every message
+type failing struct{}
+
+func (failing) Name() string { return "failing" }
+
+func (failing) Send(context.Context, Message) error {
+	return errors.New("connection refused")
+}
+
+func TestInstrumentRecordsSends(t *testing.T) {
+	m := metrics.New(prometheus.NewRegistry())
+	memory := NewMemory()
+	mailer := Instrument(memory, m)
+	msg := Message{From: "test@example.com", To: []string{"alice@example.com"}, Subject: "Hello", Text: "Hello"}
+
+	SetDefault(mailer)
+	t.Cleanup(func() { SetDefault(nil) })
+	for i := 0; i < 2; i++ {
+		if err := Deliver(context.Background(), "password_reset", msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Instrument(failing{}, m).Send(context.Background(), Message{Kind: "login_alert"}); err == nil {
+		t.Fatal("send through a failing transport succeeded")
+	}
+
+	if sent := memory.Messages(); len(sent) != 2 || sent[0].Kind != "password_reset" {
+		t.Errorf("sent %+v, want two password_reset messages", sent)
+	}
+	if mailer.Name() != memory.Name() {
+		t.Errorf("instrumented mailer is named %q, want %q", mailer.Name(), memory.Name())
+	}
+
+	want := `
+# HELP ai_backend_emails_sent_total Email sends by kind and outcome (success or failure).
+# TYPE ai_backend_emails_sent_total counter
+ai_backend_emails_sent_total{kind="login_alert",outcome="failure"} 1
+ai_backend_emails_sent_total{kind="password_reset",outcome="success"} 2
+`
+	if err := testutil.GatherAndCompare(m.Registry, strings.NewReader(want), "ai_backend_emails_sent_total"); err != nil {
+		t.Error(err)
+	}
+}
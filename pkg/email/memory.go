@@ -0,0 +1,43 @@
+package email
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory keeps sent messages in memory. It is meant for tests, which can
+// inspect what would have been delivered with Messages.
+type Memory struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (*Memory) Name() string {
+	return "memory"
+}
+
+func (m *Memory) Send(_ context.Context, msg Message) error {
+	msg.To = append([]string(nil), msg.To...)
+	m.mu.Lock()
+	m.messages = append(m.messages, msg)
+	m.mu.Unlock()
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// Reset forgets the messages sent so far
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	m.messages = nil
+	m.mu.Unlock()
+}
@@ -0,0 +1,117 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// envelopeAddresses returns the bare sender and recipient addresses of msg, as
+// used by SMTP, or an error when one of them does not parse
+func envelopeAddresses(msg Message) (string, []string, error) {
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid sender %q: %w", msg.From, err)
+	}
+	if len(msg.To) == 0 {
+		return "", nil, fmt.Errorf("message has no recipients")
+	}
+	to := make([]string, 0, len(msg.To))
+	for _, recipient := range msg.To {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid recipient %q: %w", recipient, err)
+		}
+		to = append(to, address.Address)
+	}
+	return from.Address, to, nil
+}
+
+// buildMIME renders msg as an RFC 5322 message. With both an HTML and a text
+// body it is sent as multipart/alternative so clients pick the one they support.
+func buildMIME(msg Message) ([]byte, error) {
+	from, _, err := envelopeAddresses(msg)
+	if err != nil {
+		return nil, err
+	}
+	if msg.HTML == "" && msg.Text == "" {
+		return nil, fmt.Errorf("message has no body")
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", msg.From)
+	header("To", strings.Join(msg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(from))
+	header("MIME-Version", "1.0")
+
+	if msg.HTML == "" || msg.Text == "" {
+		contentType, body := "text/html; charset=utf-8", msg.HTML
+		if msg.HTML == "" {
+			contentType, body = "text/plain; charset=utf-8", msg.Text
+		}
+		header("Content-Type", contentType)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+	// Clients prefer the last alternative they can display, so HTML goes last
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// messageID returns a unique Message-ID in the domain of the sender
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	random := make([]byte, 12)
+	_, _ = rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
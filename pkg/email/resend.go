@@ -0,0 +1,36 @@
+package email
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/resend/resend-go/v2"
+)
+
+// Resend delivers messages through the Resend API
+type Resend struct {
+	client *resend.Client
+}
+
+func NewResend(apiKey string) *Resend {
+	return &Resend{client: resend.NewClient(apiKey)}
+}
+
+func (*Resend) Name() string {
+	return "resend"
+}
+
+func (m *Resend) Send(ctx context.Context, msg Message) error {
+	resp, err := m.client.Emails.SendWithContext(ctx, &resend.SendEmailRequest{
+		From:    msg.From,
+		To:      msg.To,
+		Subject: msg.Subject,
+		Html:    msg.HTML,
+		Text:    msg.Text,
+	})
+	if err != nil {
+		return err
+	}
+	slog.DebugContext(ctx, "Resend accepted email", "resend_id", resp.Id)
+	return nil
+}
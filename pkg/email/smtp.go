@@ -0,0 +1,124 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"ai-backend/internal/config"
+)
+
+// smtpTimeout bounds a delivery whose context has no deadline
+const smtpTimeout = 30 * time.Second
+
+// SMTP delivers messages to an SMTP server. It upgrades to TLS with STARTTLS
+// when the server offers it and authenticates only when a username is set, so
+// it works both with providers and with local catchers such as MailHog.
+type SMTP struct {
+	cfg config.SMTPConfig
+}
+
+func NewSMTP(cfg config.SMTPConfig) *SMTP {
+	return &SMTP{cfg: cfg}
+}
+
+func (*SMTP) Name() string {
+	return "smtp"
+}
+
+func (m *SMTP) Send(ctx context.Context, msg Message) error {
+	from, to, err := envelopeAddresses(msg)
+	if err != nil {
+		return err
+	}
+	body, err := buildMIME(msg)
+	if err != nil {
+		return err
+	}
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("smtp MAIL FROM: %w", err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s: %w", recipient, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	return client.Quit()
+}
+
+// Ping checks that the server accepts a connection and, if configured, the credentials
+func (m *SMTP) Ping(ctx context.Context) error {
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.Noop(); err != nil {
+		return fmt.Errorf("smtp NOOP: %w", err)
+	}
+	return client.Quit()
+}
+
+// dial connects and greets the server, then upgrades and authenticates the connection
+func (m *SMTP) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server %s: %w", addr, err)
+	}
+	// net/smtp does not take a context, so the deadline covers the whole exchange
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp greeting: %w", err)
+	}
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp STARTTLS: %w", err)
+		}
+	}
+	if m.cfg.Username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted connection
+		// to anything but localhost
+		auth := smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp AUTH: %w", err)
+		}
+	}
+	return client, nil
+}
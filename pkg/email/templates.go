@@ -0,0 +1,295 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"ai-backend/internal/config"
+	"ai-backend/internal/locale"
+)
+
+// Template names. Every template has an HTML and a text variant per locale in
+// templates/<locale>/<name>.{html,txt}.tmpl; the text variant also defines the subject.
+const (
+	TemplatePasswordReset      = "password_reset"
+	TemplateVerification       = "verification"
+	TemplateBanNotice          = "ban_notice"
+	TemplateUnbanNotice        = "unban_notice"
+	TemplateFreezeConfirmation = "freeze_confirmation"
+	TemplateLoginAlert         = "login_alert"
+	TemplateDataExport         = "data_export"
+	TemplateReactivation       = "reactivation"
+	TemplateRoleChanged        = "role_changed"
+	TemplateNewAnswer          = "new_answer"
+	TemplateAnswerAccepted     = "answer_accepted"
+	TemplateNotificationDigest = "notification_digest"
+)
+
+// Template data, one type per template
+
+type PasswordResetData struct {
+	Token     string
+	ExpiresIn time.Duration
+}
+
+type VerificationData struct {
+	Link      string
+	ExpiresIn time.Duration
+}
+
+// BanNoticeData describes a ban; Until is nil for a permanent one
+type BanNoticeData struct {
+	Reason string
+	Until  *time.Time
+}
+
+type UnbanNoticeData struct {
+	Reason string
+}
+
+type FreezeConfirmationData struct {
+	Until time.Time
+}
+
+type LoginAlertData struct {
+	At        time.Time
+	IP        string
+	UserAgent string
+}
+
+type DataExportData struct {
+	Link      string
+	ExpiresAt time.Time
+}
+
+type ReactivationData struct {
+	Token     string
+	ExpiresIn time.Duration
+}
+
+type RoleChangedData struct {
+	OldRole string
+	NewRole string
+	Reason  string
+}
+
+type NewAnswerData struct {
+	QuestionTitle string
+	AnsweredBy    string
+}
+
+type AnswerAcceptedData struct {
+	QuestionTitle string
+}
+
+// NotificationDigestData lists unread notifications; More counts the ones left out
+type NotificationDigestData struct {
+	Items []DigestItem
+	More  int
+}
+
+// DigestItem is one notification in a digest. Type is a notification type such
+// as "new_answer"; QuestionTitle is empty for types not about a question.
+type DigestItem struct {
+	Type          string
+	QuestionTitle string
+	At            time.Time
+}
+
+// ErrUnknownTemplate is returned for a template name that does not exist
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+//go:embed templates
+var templateFS embed.FS
+
+// samples holds the data previews are rendered with
+var samples = map[string]any{
+	TemplatePasswordReset: PasswordResetData{Token: "3f9a0c2e7b1d4a6f8e5c", ExpiresIn: time.Hour},
+	TemplateVerification:  VerificationData{Link: "https://example.com/verify-email?token=3f9a0c2e7b1d4a6f8e5c", ExpiresIn: 24 * time.Hour},
+	TemplateBanNotice: BanNoticeData{
+		Reason: "Repeated spam in answers",
+		Until:  timePtr(time.Date(2027, 1, 15, 12, 0, 0, 0, time.UTC)),
+	},
+	TemplateUnbanNotice:        UnbanNoticeData{Reason: "Ban appeal accepted"},
+	TemplateFreezeConfirmation: FreezeConfirmationData{Until: time.Date(2027, 1, 15, 12, 0, 0, 0, time.UTC)},
+	TemplateLoginAlert: LoginAlertData{
+		At:        time.Date(2026, 12, 1, 9, 30, 0, 0, time.UTC),
+		IP:        "203.0.113.7",
+		UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) Firefox/128.0",
+	},
+	TemplateDataExport:     DataExportData{Link: "https://example.com/api/user/data-export/download?token=3f9a0c2e7b1d4a6f8e5c", ExpiresAt: time.Date(2027, 1, 3, 12, 0, 0, 0, time.UTC)},
+	TemplateReactivation:   ReactivationData{Token: "3f9a0c2e7b1d4a6f8e5c", ExpiresIn: 24 * time.Hour},
+	TemplateRoleChanged:    RoleChangedData{OldRole: "USER", NewRole: "EDITOR", Reason: "Consistently helpful answers"},
+	TemplateNewAnswer:      NewAnswerData{QuestionTitle: "How do I paginate GORM queries?", AnsweredBy: "gopher"},
+	TemplateAnswerAccepted: AnswerAcceptedData{QuestionTitle: "How do I paginate GORM queries?"},
+	TemplateNotificationDigest: NotificationDigestData{
+		Items: []DigestItem{
+			{Type: "new_answer", QuestionTitle: "How do I paginate GORM queries?", At: time.Date(2026, 12, 1, 9, 30, 0, 0, time.UTC)},
+			{Type: "answer_accepted", QuestionTitle: "Why is my goroutine leaking?", At: time.Date(2026, 12, 1, 14, 5, 0, 0, time.UTC)},
+		},
+		More: 3,
+	},
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+type localizedTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// templates maps locale and template name to the parsed templates. They are
+// parsed at startup so a missing translation stops the process immediately.
+var templates = mustParseTemplates()
+
+func mustParseTemplates() map[string]map[string]localizedTemplate {
+	parsed := make(map[string]map[string]localizedTemplate, len(locale.Supported))
+	for _, loc := range locale.Supported {
+		funcs := templateFuncs(loc)
+		parsed[loc] = make(map[string]localizedTemplate, len(samples))
+		for name := range samples {
+			html := htmltemplate.Must(htmltemplate.New("layout.html.tmpl").Funcs(htmltemplate.FuncMap(funcs)).ParseFS(templateFS,
+				"templates/layout.html.tmpl",
+				"templates/"+loc+"/common.html.tmpl",
+				"templates/"+loc+"/"+name+".html.tmpl",
+			))
+			text := texttemplate.Must(texttemplate.New("layout.txt.tmpl").Funcs(funcs).ParseFS(templateFS,
+				"templates/layout.txt.tmpl",
+				"templates/"+loc+"/common.txt.tmpl",
+				"templates/"+loc+"/"+name+".txt.tmpl",
+			))
+			parsed[loc][name] = localizedTemplate{html: html, text: text}
+		}
+	}
+	return parsed
+}
+
+// templateFuncs returns the formatting helpers for loc
+func templateFuncs(loc string) texttemplate.FuncMap {
+	return texttemplate.FuncMap{
+		"date": func(value any) string {
+			var t time.Time
+			switch v := value.(type) {
+			case time.Time:
+				t = v
+			case *time.Time:
+				if v == nil {
+					return ""
+				}
+				t = *v
+			default:
+				return fmt.Sprint(value)
+			}
+			if loc == "tr" {
+				return t.Format("02.01.2006 15:04 MST")
+			}
+			return t.Format("2006-01-02 15:04 MST")
+		},
+		"duration": func(d time.Duration) string {
+			return formatDuration(loc, d)
+		},
+	}
+}
+
+// formatDuration writes d in the largest whole unit, e.g. "1 hour" or "24 saat"
+func formatDuration(loc string, d time.Duration) string {
+	units := []struct {
+		size             time.Duration
+		en, enPlural, tr string
+	}{
+		{24 * time.Hour, "day", "days", "gün"},
+		{time.Hour, "hour", "hours", "saat"},
+		{time.Minute, "minute", "minutes", "dakika"},
+	}
+	for i, unit := range units {
+		if d%unit.size != 0 && i < len(units)-1 {
+			continue
+		}
+		n := int64(d / unit.size)
+		if n == 0 && i < len(units)-1 {
+			continue
+		}
+		switch {
+		case loc == "tr":
+			return fmt.Sprintf("%d %s", n, unit.tr)
+		case n == 1:
+			return fmt.Sprintf("%d %s", n, unit.en)
+		default:
+			return fmt.Sprintf("%d %s", n, unit.enPlural)
+		}
+	}
+	return d.String()
+}
+
+// view is what templates are executed with
+type view struct {
+	Locale  string
+	BaseURL string
+	Data    any
+}
+
+// TemplateNames lists the available templates
+func TemplateNames() []string {
+	return []string{
+		TemplatePasswordReset,
+		TemplateVerification,
+		TemplateBanNotice,
+		TemplateUnbanNotice,
+		TemplateFreezeConfirmation,
+		TemplateLoginAlert,
+		TemplateDataExport,
+		TemplateReactivation,
+		TemplateRoleChanged,
+		TemplateNewAnswer,
+		TemplateAnswerAccepted,
+		TemplateNotificationDigest,
+	}
+}
+
+// Render renders the named template in loc, falling back to the default locale
+// for unsupported ones. The message has no sender or recipients yet.
+func Render(name, loc string, data any) (Message, error) {
+	if !locale.IsSupported(loc) {
+		loc = locale.Default
+	}
+	tmpl, ok := templates[loc][name]
+	if !ok {
+		return Message{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+
+	v := view{Locale: loc, BaseURL: config.Get().HTTP.BaseURL, Data: data}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", v); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := tmpl.text.Execute(&text, v); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	if err := tmpl.html.Execute(&html, v); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
+
+// Preview renders the named template in loc with sample data
+func Preview(name, loc string) (Message, error) {
+	data, ok := samples[name]
+	if !ok {
+		return Message{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	return Render(name, loc, data)
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned when a stored object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Storage stores binary objects by key
+type Storage interface {
+	Save(key string, r io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// LocalStorage stores objects as files below a base directory
+type LocalStorage struct {
+	baseDir string
+}
+
+// NewLocalStorage creates a local storage backend, creating baseDir if needed
+func NewLocalStorage(baseDir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(baseDir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &LocalStorage{baseDir: baseDir}, nil
+}
+
+// path resolves key inside the base directory, rejecting keys that escape it
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	full := filepath.Join(s.baseDir, cleaned)
+	if !strings.HasPrefix(full, filepath.Clean(s.baseDir)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("invalid storage key: %s", key)
+	}
+	return full, nil
+}
+
+// Save writes r to key, replacing any existing object
+func (s *LocalStorage) Save(key string, r io.Reader) error {
+	full, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0o750); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(full), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), full)
+}
+
+// Open returns a reader for key
+func (s *LocalStorage) Open(key string) (io.ReadCloser, error) {
+	full, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(full)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete removes key; deleting a missing object is not an error
+func (s *LocalStorage) Delete(key string) error {
+	full, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(full); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -1,8 +1,8 @@
 package utils
 
 import (
+	"ai-backend/internal/config"
 	"ai-backend/internal/models"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -18,11 +18,8 @@ type JWTClaim struct {
 
 // GenerateToken creates a new JWT token for a user
 func GenerateToken(user models.User) (string, error) {
-	// Get JWT secret from environment variable
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-256-bit-secret" // Default secret for development
-	}
+	// Get JWT secret from configuration
+	jwtSecret := config.Get().Auth.JWTSecret
 
 	// Create claims
 	claims := JWTClaim{
@@ -31,7 +28,7 @@ func GenerateToken(user models.User) (string, error) {
 		Email:    *user.Email,
 		Role:     user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // Token expires in 24 hours
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.Get().Auth.TokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
@@ -51,11 +48,8 @@ func GenerateToken(user models.User) (string, error) {
 
 // ValidateToken checks if the token is valid and returns the claims
 func ValidateToken(tokenString string) (*JWTClaim, error) {
-	// Get JWT secret from environment variable
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-256-bit-secret" // Default secret for development
-	}
+	// Get JWT secret from configuration
+	jwtSecret := config.Get().Auth.JWTSecret
 
 	// Parse token
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaim{}, func(token *jwt.Token) (interface{}, error) {